@@ -6,10 +6,8 @@ require (
 	github.com/bmatcuk/doublestar/v4 v4.8.1
 	github.com/google/go-cmp v0.6.0
 	github.com/mattn/go-runewidth v0.0.16
+	golang.org/x/sys v0.29.0
 	golang.org/x/term v0.28.0
 )
 
-require (
-	github.com/rivo/uniseg v0.2.0 // indirect
-	golang.org/x/sys v0.29.0 // indirect
-)
+require github.com/rivo/uniseg v0.2.0 // indirect
@@ -0,0 +1,210 @@
+package backends
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+
+	"github.com/sinclairtarget/git-who/internal/git"
+)
+
+// An archive is a portable copy of a cache that can be restored on another
+// machine. It is a gzipped stream made up of:
+//
+//   - archiveMagic
+//   - the archive format version, as a uvarint
+//   - the state hash of the repo the commits were cached for, as a string
+//   - each commit as a uvarint length followed by a record (see encodeCommit())
+//   - a zero length marking the end of the archive
+//
+// The end marker lets us tell a complete archive from one that was cut off.
+const archiveMagic = "git-who cache archive\n"
+
+// Bump this if the layout above changes. Changes to the record format are
+// covered by the state hash instead.
+const archiveVersion = 1
+
+// No single commit should ever get this big
+const maxArchiveRecordSize = 256 * 1024 * 1024
+
+var errBadArchive = errors.New("not a git-who cache archive")
+
+// Writes the commits to w as an archive. Returns the number of commits written.
+func WriteArchive(
+	w io.Writer,
+	stateHash string,
+	commits iter.Seq[git.Commit],
+) (_ int, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("could not write cache archive: %w", err)
+		}
+	}()
+
+	zw, err := gzip.NewWriterLevel(w, gzip.BestSpeed)
+	if err != nil {
+		return 0, err
+	}
+
+	bw := bufio.NewWriter(zw)
+
+	var buf []byte
+	buf = append(buf, archiveMagic...)
+	buf = binary.AppendUvarint(buf, archiveVersion)
+	buf = appendString(buf, stateHash)
+	_, err = bw.Write(buf)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	var record []byte
+	for c := range commits {
+		record, err = encodeCommit(record[:0], c)
+		if err != nil {
+			return n, err
+		}
+
+		buf = binary.AppendUvarint(buf[:0], uint64(len(record)))
+		buf = append(buf, record...)
+		_, err = bw.Write(buf)
+		if err != nil {
+			return n, err
+		}
+
+		n += 1
+	}
+
+	buf = binary.AppendUvarint(buf[:0], 0)
+	_, err = bw.Write(buf)
+	if err != nil {
+		return n, err
+	}
+
+	err = bw.Flush()
+	if err != nil {
+		return n, err
+	}
+
+	return n, zw.Close()
+}
+
+// Reads an archive written by WriteArchive().
+//
+// Returns the state hash recorded in the archive and the commits in it. The
+// commits are read lazily, so the error returned by finish() should be
+// checked once iteration is done.
+func ReadArchive(r io.Reader) (
+	stateHash string,
+	commits iter.Seq[git.Commit],
+	finish func() error,
+	err error,
+) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("could not read cache archive: %w", err)
+		}
+	}()
+
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return "", nil, nil, errBadArchive
+	}
+
+	br := bufio.NewReader(zr)
+
+	magic := make([]byte, len(archiveMagic))
+	_, err = io.ReadFull(br, magic)
+	if err != nil || string(magic) != archiveMagic {
+		return "", nil, nil, errBadArchive
+	}
+
+	version, err := binary.ReadUvarint(br)
+	if err != nil {
+		return "", nil, nil, errBadArchive
+	}
+
+	if version != archiveVersion {
+		return "", nil, nil, fmt.Errorf(
+			"unsupported archive version %d (expected %d)",
+			version,
+			archiveVersion,
+		)
+	}
+
+	hashLen, err := binary.ReadUvarint(br)
+	if err != nil || hashLen > 256 {
+		return "", nil, nil, errBadArchive
+	}
+
+	hash := make([]byte, hashLen)
+	_, err = io.ReadFull(br, hash)
+	if err != nil {
+		return "", nil, nil, errBadArchive
+	}
+
+	var iterErr error
+	finish = func() error {
+		if iterErr != nil {
+			return fmt.Errorf("could not read cache archive: %w", iterErr)
+		}
+
+		return nil
+	}
+
+	commits = func(yield func(git.Commit) bool) {
+		var record []byte
+		for {
+			length, err := binary.ReadUvarint(br)
+			if err != nil {
+				iterErr = fmt.Errorf("archive was cut off: %w", err)
+				return
+			}
+
+			if length == 0 {
+				// End of archive. Read to the end of the gzip stream too, so
+				// that its checksum gets verified
+				_, err := br.ReadByte()
+				if err == nil {
+					iterErr = errors.New("archive has data after its end")
+				} else if err != io.EOF {
+					iterErr = fmt.Errorf("archive was cut off: %w", err)
+				}
+
+				return
+			}
+
+			if length > maxArchiveRecordSize {
+				iterErr = errBadRecord
+				return
+			}
+
+			if cap(record) < int(length) {
+				record = make([]byte, length)
+			}
+			record = record[:length]
+
+			_, err = io.ReadFull(br, record)
+			if err != nil {
+				iterErr = fmt.Errorf("archive was cut off: %w", err)
+				return
+			}
+
+			c, err := decodeCommit(record)
+			if err != nil {
+				iterErr = err
+				return
+			}
+
+			if !yield(c) {
+				return
+			}
+		}
+	}
+
+	return string(hash), commits, finish, nil
+}
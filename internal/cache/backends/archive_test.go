@@ -0,0 +1,77 @@
+package backends_test
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sinclairtarget/git-who/internal/cache/backends"
+	"github.com/sinclairtarget/git-who/internal/git"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	commits := []git.Commit{indexCommitOne, indexCommitTwo}
+
+	var buf bytes.Buffer
+	n, err := backends.WriteArchive(&buf, "abcd1234", slices.Values(commits))
+	if err != nil {
+		t.Fatalf("writing archive failed with error: %v", err)
+	}
+
+	if n != 2 {
+		t.Errorf("expected to write 2 commits, but wrote %d", n)
+	}
+
+	stateHash, it, finish, err := backends.ReadArchive(&buf)
+	if err != nil {
+		t.Fatalf("reading archive failed with error: %v", err)
+	}
+
+	if stateHash != "abcd1234" {
+		t.Errorf("expected state hash abcd1234, but got %s", stateHash)
+	}
+
+	read := slices.Collect(it)
+	err = finish()
+	if err != nil {
+		t.Fatalf("error iterating archived commits: %v", err)
+	}
+
+	if diff := cmp.Diff(commits, read); diff != "" {
+		t.Errorf("commits are wrong:\n%s", diff)
+	}
+}
+
+func TestArchiveTruncated(t *testing.T) {
+	commits := []git.Commit{indexCommitOne, indexCommitTwo}
+
+	var buf bytes.Buffer
+	_, err := backends.WriteArchive(&buf, "abcd1234", slices.Values(commits))
+	if err != nil {
+		t.Fatalf("writing archive failed with error: %v", err)
+	}
+
+	truncated := bytes.NewReader(buf.Bytes()[:buf.Len()-10])
+	_, it, finish, err := backends.ReadArchive(truncated)
+	if err != nil {
+		t.Fatalf("reading archive header failed with error: %v", err)
+	}
+
+	for range it {
+	}
+
+	err = finish()
+	if err == nil {
+		t.Errorf("expected error reading truncated archive")
+	}
+}
+
+func TestArchiveNotAnArchive(t *testing.T) {
+	_, _, _, err := backends.ReadArchive(strings.NewReader("hello"))
+	if err == nil {
+		t.Errorf("expected error reading something that isn't an archive")
+	}
+}
@@ -0,0 +1,960 @@
+package backends
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/utils/fileutils"
+)
+
+// Stores commits on disk in a directory, indexed by commit hash.
+//
+// Each commit is encoded as a separate record (see encodeCommit()) and appended
+// to a segment file. Once a segment file grows past maxSegmentSize, we start a
+// new one. For every record, we also append an entry to an index file giving
+// the commit hash and where to find the record.
+//
+// The index is read into memory when the cache is opened. It's small compared
+// to the segment files, since it doesn't contain any diffs. Get() then only
+// reads the records for the revs it is asked for, and Add() only appends to
+// the end of the files, so the cost of using the cache depends on the number of
+// commits we use rather than on the number of commits in the cache.
+//
+// Other git-who processes may be using the same cache at the same time. While
+// the cache is open, we hold a shared lock on the index file, so that nobody
+// deletes the cache (see Housekeep()) or moves records around (see Prune())
+// under us. Add() holds an exclusive lock on a separate lock file while it
+// writes. Readers don't need that lock, since they only read records that the
+// index points to and records are always written before the index entries.
+//
+// All files in the directory belong to a particular repo state (see
+// Prefix). Files for any other state are deleted when the cache is closed,
+// except for those of a prune in progress.
+type IndexBackend struct {
+	Dir    string
+	Prefix string // Prefix of every file name, e.g. the repo state hash
+
+	wasOpened   bool
+	index       map[string]recordLoc
+	indexSize   int64    // Bytes of the index file read into index so far
+	indexFile   *os.File // Shared lock held while open
+	lockFile    *os.File // Exclusive lock held while adding
+	segment     int      // Number of segment currently being appended to
+	segmentSize int64    // Size of that segment
+	segmentFile *os.File
+	fileSegment int // Number of segment segmentFile is open for
+}
+
+const IndexBackendName string = "index"
+
+// Segment files are rolled over once they get this big
+const maxSegmentSize = 64 * 1024 * 1024
+
+// Added to the prefix of the files Prune() writes before they replace the old
+// ones
+const prunedSuffix = "-pruned"
+
+// Where to find a commit record.
+type recordLoc struct {
+	segment int
+	offset  int64
+	length  int
+	crc     uint32 // IEEE CRC-32 of the record
+}
+
+// Written at the start of the index file. Change the version if the index
+// entry or record formats change.
+const indexHeader = "git-who index v1\n"
+
+func (b *IndexBackend) Name() string {
+	return IndexBackendName
+}
+
+func (b *IndexBackend) indexPath() string {
+	return filepath.Join(b.Dir, b.Prefix+".idx")
+}
+
+func (b *IndexBackend) lockPath() string {
+	return filepath.Join(b.Dir, b.Prefix+".lock")
+}
+
+func (b *IndexBackend) segmentPath(segment int) string {
+	return filepath.Join(b.Dir, fmt.Sprintf("%s.%d.seg", b.Prefix, segment))
+}
+
+// Whether the file at the given path belongs to this cache.
+func (b *IndexBackend) owns(p string) bool {
+	base := filepath.Base(p)
+	if base == filepath.Base(b.indexPath()) {
+		return true
+	}
+
+	if base == filepath.Base(b.lockPath()) {
+		return true
+	}
+
+	n, found := strings.CutPrefix(base, b.Prefix+".")
+	if !found {
+		return false
+	}
+
+	n, found = strings.CutSuffix(n, ".seg")
+	if !found {
+		return false
+	}
+
+	_, err := strconv.Atoi(n)
+	return err == nil
+}
+
+// Whether the file at the given path was written by Prune(), which may still
+// be running in another process.
+func isPruneFile(p string) bool {
+	return strings.Contains(filepath.Base(p), prunedSuffix+".")
+}
+
+// Opens the cache and reads every entry in the index file into memory.
+func (b *IndexBackend) Open() error {
+	b.wasOpened = true
+	b.resetIndex()
+
+	err := b.openIndexFile()
+	if err != nil {
+		return err
+	}
+
+	_, err = b.readNewEntries(b.indexFile)
+	return err
+}
+
+func (b *IndexBackend) resetIndex() {
+	b.index = map[string]recordLoc{}
+	b.indexSize = 0
+	b.segment = 0
+	b.segmentSize = 0
+}
+
+// Reads the index entries in f that we haven't read yet, i.e. those written
+// since we last read the index, maybe by another process.
+//
+// Stops before a last entry that was only partly written, either because the
+// writer is still writing it or because the writer was killed in the middle of
+// writing it. Returns whether there was such an entry.
+func (b *IndexBackend) readNewEntries(f *os.File) (partial bool, err error) {
+	_, err = f.Seek(b.indexSize, io.SeekStart)
+	if err != nil {
+		return false, err
+	}
+
+	r := bufio.NewReader(f)
+
+	if b.indexSize == 0 {
+		header := make([]byte, len(indexHeader))
+		n, err := io.ReadFull(r, header)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return n > 0, nil
+		} else if err != nil || string(header) != indexHeader {
+			return false, errors.New("index file has bad header")
+		}
+
+		b.indexSize = int64(len(indexHeader))
+	}
+
+	for {
+		hash, loc, n, err := readIndexEntry(r)
+		if err == io.EOF {
+			return false, nil
+		} else if err == io.ErrUnexpectedEOF {
+			return true, nil
+		} else if err != nil {
+			return false, err
+		}
+
+		b.indexSize += int64(n)
+		b.index[hash] = loc
+
+		if loc.segment > b.segment {
+			b.segment = loc.segment
+			b.segmentSize = 0
+		}
+
+		if loc.segment == b.segment {
+			b.segmentSize = max(b.segmentSize, loc.offset+int64(loc.length))
+		}
+	}
+}
+
+func appendIndexEntry(buf []byte, hash string, loc recordLoc) []byte {
+	buf = appendString(buf, hash)
+	buf = binary.AppendUvarint(buf, uint64(loc.segment))
+	buf = binary.AppendUvarint(buf, uint64(loc.offset))
+	buf = binary.AppendUvarint(buf, uint64(loc.length))
+	return binary.LittleEndian.AppendUint32(buf, loc.crc)
+}
+
+// Reads a single index entry. Returns the number of bytes read.
+//
+// Returns io.EOF if there are no more entries and io.ErrUnexpectedEOF if the
+// entry was cut off.
+func readIndexEntry(r *bufio.Reader) (string, recordLoc, int, error) {
+	var loc recordLoc
+	n := 0
+
+	uvarint := func() (uint64, error) {
+		v, err := binary.ReadUvarint(countingByteReader{r, &n})
+		if err == io.EOF && n > 0 {
+			return 0, io.ErrUnexpectedEOF
+		}
+
+		return v, err
+	}
+
+	hashLen, err := uvarint()
+	if err != nil {
+		return "", loc, n, err
+	}
+
+	if hashLen > 256 {
+		return "", loc, n, errors.New("index entry has bad hash length")
+	}
+
+	rest := make([]byte, hashLen)
+	read, err := io.ReadFull(r, rest)
+	n += read
+	if err != nil {
+		return "", loc, n, io.ErrUnexpectedEOF
+	}
+	hash := string(rest)
+
+	segment, err := uvarint()
+	if err != nil {
+		return "", loc, n, err
+	}
+
+	offset, err := uvarint()
+	if err != nil {
+		return "", loc, n, err
+	}
+
+	length, err := uvarint()
+	if err != nil {
+		return "", loc, n, err
+	}
+
+	crc := make([]byte, 4)
+	read, err = io.ReadFull(r, crc)
+	n += read
+	if err != nil {
+		return "", loc, n, io.ErrUnexpectedEOF
+	}
+
+	loc = recordLoc{
+		segment: int(segment),
+		offset:  int64(offset),
+		length:  int(length),
+		crc:     binary.LittleEndian.Uint32(crc),
+	}
+	return hash, loc, n, nil
+}
+
+// Counts the bytes read through it.
+type countingByteReader struct {
+	r *bufio.Reader
+	n *int
+}
+
+func (c countingByteReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		*c.n += 1
+	}
+
+	return b, err
+}
+
+// Adds commits in chunks, so that we don't have to hold them all in memory.
+func (b *IndexBackend) addAll(commits iter.Seq[git.Commit]) error {
+	chunk := []git.Commit{}
+	for c := range commits {
+		chunk = append(chunk, c)
+		if len(chunk) >= 1000 {
+			err := b.Add(chunk)
+			if err != nil {
+				return err
+			}
+
+			chunk = []git.Commit{}
+		}
+	}
+
+	return b.Add(chunk)
+}
+
+func (b *IndexBackend) closeFiles() error {
+	var errs []error
+	if b.segmentFile != nil {
+		errs = append(errs, b.segmentFile.Close())
+		b.segmentFile = nil
+	}
+
+	if b.lockFile != nil {
+		errs = append(errs, b.lockFile.Close())
+		b.lockFile = nil
+	}
+
+	if b.indexFile != nil {
+		errs = append(errs, b.indexFile.Close())
+		b.indexFile = nil
+	}
+
+	return errors.Join(errs...)
+}
+
+func (b *IndexBackend) Close() error {
+	err := b.closeFiles()
+	if err != nil {
+		return err
+	}
+
+	// Remove any other dangling cache files
+	matches, err := filepath.Glob(filepath.Join(b.Dir, "*"))
+	if err != nil {
+		panic(err) // Bad pattern
+	}
+
+	for _, match := range matches {
+		if b.owns(match) || isPruneFile(match) {
+			continue
+		}
+
+		err := os.RemoveAll(match)
+		if err != nil {
+			logger().Warn(
+				fmt.Sprintf("failed to delete old cache file: %v", err),
+			)
+		}
+	}
+
+	return nil
+}
+
+func (b *IndexBackend) Get(revs []string) (iter.Seq[git.Commit], func() error) {
+	if !b.wasOpened {
+		panic("cache not yet open. Did you forget to call Open()?")
+	}
+
+	var iterErr error
+	finish := func() error {
+		return iterErr
+	}
+
+	seq := func(yield func(git.Commit) bool) {
+		b.readRecords(revs, func(_ string, c git.Commit, err error) bool {
+			if err != nil {
+				iterErr = err
+				return false
+			}
+
+			return yield(c)
+		})
+	}
+
+	return seq, finish
+}
+
+// Reads the records for the given hashes, skipping any that aren't in the
+// cache, and calls fn with each decoded commit or the error we got trying to
+// read it. Stops early if fn returns false.
+//
+// Records are read in the order they are stored so that reads are sequential.
+func (b *IndexBackend) readRecords(
+	hashes []string,
+	fn func(hash string, c git.Commit, err error) bool,
+) {
+	type hashLoc struct {
+		hash string
+		loc  recordLoc
+	}
+
+	locs := []hashLoc{}
+	seen := map[string]bool{}
+	for _, hash := range hashes {
+		loc, ok := b.index[hash]
+		if ok && !seen[hash] {
+			seen[hash] = true
+			locs = append(locs, hashLoc{hash, loc})
+		}
+	}
+
+	slices.SortFunc(locs, func(a, b hashLoc) int {
+		return cmp.Or(
+			cmp.Compare(a.loc.segment, b.loc.segment),
+			cmp.Compare(a.loc.offset, b.loc.offset),
+		)
+	})
+
+	var f *os.File
+	var openErr error
+	defer func() {
+		if f != nil {
+			f.Close() // Don't care about error closing when reading
+		}
+	}()
+
+	segment := -1
+	var data []byte
+	for _, hl := range locs {
+		loc := hl.loc
+		if loc.segment != segment {
+			if f != nil {
+				f.Close()
+			}
+
+			f, openErr = os.Open(b.segmentPath(loc.segment))
+			segment = loc.segment
+		}
+
+		if openErr != nil {
+			if !fn(hl.hash, git.Commit{}, openErr) {
+				return
+			}
+
+			continue
+		}
+
+		if cap(data) < loc.length {
+			data = make([]byte, loc.length)
+		}
+		data = data[:loc.length]
+
+		c, err := readRecord(f, loc, data)
+		if err == nil && c.Hash != hl.hash {
+			err = fmt.Errorf("commit record has wrong hash: %s", c.Hash)
+		}
+
+		if !fn(hl.hash, c, err) {
+			return
+		}
+	}
+}
+
+// Reads and decodes a single record into the given buffer.
+func readRecord(f *os.File, loc recordLoc, data []byte) (git.Commit, error) {
+	_, err := f.ReadAt(data, loc.offset)
+	if err != nil {
+		return git.Commit{}, fmt.Errorf("could not read commit record: %w", err)
+	}
+
+	if crc32.ChecksumIEEE(data) != loc.crc {
+		return git.Commit{}, errors.New("commit record failed checksum")
+	}
+
+	return decodeCommit(data)
+}
+
+func (b *IndexBackend) Add(commits []git.Commit) (err error) {
+	if !b.wasOpened {
+		panic("cache not yet open. Did you forget to call Open()?")
+	}
+
+	err = b.openIndexFile()
+	if err != nil {
+		return err
+	}
+
+	if b.lockFile == nil {
+		b.lockFile, err = os.OpenFile(b.lockPath(), os.O_RDWR|os.O_CREATE, 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = fileutils.Lock(b.lockFile)
+	if err != nil {
+		return fmt.Errorf("could not lock cache: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, fileutils.Unlock(b.lockFile))
+	}()
+
+	err = b.prepareAppend()
+	if err != nil {
+		return err
+	}
+
+	var records []byte
+	var entries []byte
+	added := map[string]recordLoc{}
+	for _, c := range commits {
+		if _, ok := b.index[c.Hash]; ok {
+			continue
+		}
+
+		if _, ok := added[c.Hash]; ok {
+			continue
+		}
+
+		start := len(records)
+		records, err = encodeCommit(records, c)
+		if err != nil {
+			return err
+		}
+
+		loc := recordLoc{
+			segment: b.segment,
+			offset:  b.segmentSize + int64(start),
+			length:  len(records) - start,
+			crc:     crc32.ChecksumIEEE(records[start:]),
+		}
+		added[c.Hash] = loc
+		entries = appendIndexEntry(entries, c.Hash, loc)
+	}
+
+	if len(added) == 0 {
+		return nil
+	}
+
+	// Write the records before the index entries pointing to them, so that
+	// the index never points to a record that isn't there
+	_, err = b.segmentFile.Write(records)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.indexFile.Write(entries)
+	if err != nil {
+		return err
+	}
+
+	b.indexSize += int64(len(entries))
+	b.segmentSize += int64(len(records))
+	for hash, loc := range added {
+		b.index[hash] = loc
+	}
+
+	return nil
+}
+
+// Makes sure the index file is open for appending and that we hold a shared
+// lock on it.
+//
+// If the file was deleted while we waited for the lock, e.g. by Housekeep() in
+// another process, we start over with a new one.
+func (b *IndexBackend) openIndexFile() error {
+	for b.indexFile == nil {
+		err := os.MkdirAll(b.Dir, 0o700)
+		if err != nil {
+			return err
+		}
+
+		f, err := os.OpenFile(
+			b.indexPath(),
+			os.O_RDWR|os.O_APPEND|os.O_CREATE,
+			0644,
+		)
+		if err != nil {
+			return err
+		}
+
+		err = fileutils.LockShared(f)
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("could not lock cache index: %w", err)
+		}
+
+		deleted, err := isDeleted(f, b.indexPath())
+		if err != nil || deleted {
+			f.Close() // Releases the lock
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		b.indexFile = f
+	}
+
+	return nil
+}
+
+// Swaps the lock we hold on the index file for the one taken by lock. If the
+// file was deleted while we weren't holding a lock, we start over with a new,
+// empty index.
+func (b *IndexBackend) relockIndex(lock func(*os.File) error) error {
+	for {
+		err := fileutils.Unlock(b.indexFile)
+		if err != nil {
+			return err
+		}
+
+		err = lock(b.indexFile)
+		if err != nil {
+			return fmt.Errorf("could not lock cache index: %w", err)
+		}
+
+		deleted, err := isDeleted(b.indexFile, b.indexPath())
+		if err != nil {
+			return err
+		}
+
+		if !deleted {
+			return nil
+		}
+
+		err = b.closeFiles()
+		b.resetIndex()
+		if err != nil {
+			return err
+		}
+
+		err = b.openIndexFile()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Whether the file at path p is no longer the open file f.
+func isDeleted(f *os.File, p string) (bool, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	pathInfo, err := os.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return !os.SameFile(info, pathInfo), nil
+}
+
+// Gets the files ready for appending. Must be called while holding the lock on
+// the lock file.
+//
+// Other processes may have added to the cache since we last looked, so we
+// first catch up on their index entries. Then we cut off anything written
+// after the last indexed entry or record, since with the lock held, that can
+// only be left over from a writer that was killed. Finally we make sure the
+// current segment file is open, rolling over to a new one if it is full.
+func (b *IndexBackend) prepareAppend() error {
+	partial, err := b.readNewEntries(b.indexFile)
+	if err != nil {
+		return err
+	}
+
+	if partial {
+		logger().Warn("index file was truncated; dropping last entry")
+		err = b.indexFile.Truncate(b.indexSize)
+		if err != nil {
+			return err
+		}
+	}
+
+	if b.indexSize == 0 {
+		_, err = b.indexFile.WriteString(indexHeader)
+		if err != nil {
+			return err
+		}
+
+		b.indexSize = int64(len(indexHeader))
+	}
+
+	if b.segmentSize >= maxSegmentSize {
+		b.segment += 1
+		b.segmentSize = 0
+	}
+
+	if b.segmentFile != nil && b.fileSegment != b.segment {
+		err := b.segmentFile.Close()
+		b.segmentFile = nil
+		if err != nil {
+			return err
+		}
+	}
+
+	if b.segmentFile == nil {
+		f, err := os.OpenFile(
+			b.segmentPath(b.segment),
+			os.O_WRONLY|os.O_CREATE,
+			0644,
+		)
+		if err != nil {
+			return err
+		}
+		b.segmentFile = f
+		b.fileSegment = b.segment
+	}
+
+	// Drop anything written after the last indexed record, e.g. records
+	// whose index entries never got written
+	err = b.segmentFile.Truncate(b.segmentSize)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.segmentFile.Seek(b.segmentSize, io.SeekStart)
+	return err
+}
+
+func (b *IndexBackend) Clear() error {
+	errs := []error{b.closeFiles()}
+	b.resetIndex()
+
+	errs = append(errs, os.RemoveAll(b.Dir))
+	return errors.Join(errs...)
+}
+
+// Summary of what is stored in a cache.
+type Stats struct {
+	Dir      string
+	Prefix   string
+	Commits  int
+	Size     int64     // Bytes on disk
+	LastUsed time.Time // Filled in from the cache manifest; zero if unknown
+}
+
+// A cached commit that could not be read back.
+type CorruptRecord struct {
+	Hash string
+	Err  error
+}
+
+// Files on disk belonging to this cache.
+func (b *IndexBackend) files() []string {
+	matches, err := filepath.Glob(filepath.Join(b.Dir, "*"))
+	if err != nil {
+		panic(err) // Bad pattern
+	}
+
+	files := []string{}
+	for _, match := range matches {
+		if b.owns(match) {
+			files = append(files, match)
+		}
+	}
+
+	return files
+}
+
+func (b *IndexBackend) Stats() (Stats, error) {
+	if !b.wasOpened {
+		panic("cache not yet open. Did you forget to call Open()?")
+	}
+
+	stats := Stats{
+		Dir:     b.Dir,
+		Prefix:  b.Prefix,
+		Commits: len(b.index),
+	}
+
+	for _, p := range b.files() {
+		info, err := os.Stat(p)
+		if err != nil {
+			return stats, err
+		}
+
+		stats.Size += info.Size()
+	}
+
+	return stats, nil
+}
+
+// Returns every commit in the cache, in the order they are stored.
+func (b *IndexBackend) All() (iter.Seq[git.Commit], func() error) {
+	hashes := []string{}
+	for hash := range b.index {
+		hashes = append(hashes, hash)
+	}
+
+	return b.Get(hashes)
+}
+
+// Removes every commit for which keep returns false. Returns the number of
+// commits removed.
+//
+// The commits we keep are copied to a new set of files, which then replace the
+// old ones. Other processes with the cache open would go on reading records
+// from the old files, so we first wait until nobody else has the cache open,
+// then hold an exclusive lock on the index file the whole time.
+func (b *IndexBackend) Prune(keep func(hash string) bool) (_ int, err error) {
+	if !b.wasOpened {
+		panic("cache not yet open. Did you forget to call Open()?")
+	}
+
+	err = b.openIndexFile()
+	if err != nil {
+		return 0, err
+	}
+
+	err = b.relockIndex(fileutils.Lock)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		err = errors.Join(err, b.relockIndex(fileutils.LockShared))
+	}()
+
+	// Other processes may have added commits since we opened the cache
+	_, err = b.readNewEntries(b.indexFile)
+	if err != nil {
+		return 0, err
+	}
+
+	kept := []string{}
+	for hash := range b.index {
+		if keep(hash) {
+			kept = append(kept, hash)
+		}
+	}
+
+	pruned := len(b.index) - len(kept)
+	if pruned == 0 {
+		return 0, nil
+	}
+
+	tmp := &IndexBackend{Dir: b.Dir, Prefix: b.Prefix + prunedSuffix}
+	removeTmp := func() {
+		tmp.closeFiles()
+
+		for _, p := range tmp.files() {
+			os.Remove(p)
+		}
+	}
+
+	removeTmp() // Left over from a prune that was killed
+	err = tmp.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			removeTmp()
+		}
+	}()
+
+	commits, finish := b.Get(kept)
+	err = tmp.addAll(commits)
+	if err != nil {
+		return 0, err
+	}
+
+	err = finish()
+	if err != nil {
+		return 0, err
+	}
+
+	err = tmp.closeFiles()
+	if err != nil {
+		return 0, err
+	}
+
+	if b.segmentFile != nil {
+		err = b.segmentFile.Close()
+		b.segmentFile = nil
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// -- Swap in the new files --
+	// The index file is the one we hold the lock on, so we rewrite it in place
+	// rather than replace it. We empty it before touching the segment files so
+	// that it never points into the new segments at the wrong offsets.
+	entries, err := os.ReadFile(tmp.indexPath())
+	if err != nil {
+		return 0, err
+	}
+
+	err = b.indexFile.Truncate(0)
+	if err != nil {
+		return 0, err
+	}
+
+	b.resetIndex()
+
+	for _, p := range b.files() {
+		if p == b.indexPath() || p == b.lockPath() {
+			continue
+		}
+
+		err = os.Remove(p)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	for segment := 0; segment <= tmp.segment; segment++ {
+		err = os.Rename(tmp.segmentPath(segment), b.segmentPath(segment))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return 0, err
+		}
+	}
+
+	_, err = b.indexFile.Write(entries)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, p := range tmp.files() {
+		err = os.Remove(p)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	b.index = tmp.index
+	b.indexSize = tmp.indexSize
+	b.segment = tmp.segment
+	b.segmentSize = tmp.segmentSize
+	return pruned, nil
+}
+
+// Reads back every commit in the cache. Returns the number of commits checked
+// and the ones we could not read.
+func (b *IndexBackend) Verify() (int, []CorruptRecord) {
+	if !b.wasOpened {
+		panic("cache not yet open. Did you forget to call Open()?")
+	}
+
+	hashes := []string{}
+	for hash := range b.index {
+		hashes = append(hashes, hash)
+	}
+
+	corrupt := []CorruptRecord{}
+	b.readRecords(hashes, func(hash string, _ git.Commit, err error) bool {
+		if err != nil {
+			corrupt = append(corrupt, CorruptRecord{Hash: hash, Err: err})
+		}
+
+		return true
+	})
+
+	slices.SortFunc(corrupt, func(a, b CorruptRecord) int {
+		return strings.Compare(a.Hash, b.Hash)
+	})
+	return len(hashes), corrupt
+}
+
+// Same layout as the Gob cache: one directory per repo.
+func IndexCacheDir(prefix string, gitRootPath string) string {
+	return GobCacheDir(prefix, gitRootPath)
+}
@@ -0,0 +1,503 @@
+package backends_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sinclairtarget/git-who/internal/cache/backends"
+	"github.com/sinclairtarget/git-who/internal/git"
+)
+
+var indexCommitOne = git.Commit{
+	ShortHash:   "1e9ea7662b1",
+	Hash:        "1e9ea7662b1001d860471a4cece5e2f1de8062fb",
+	AuthorName:  "Bob",
+	AuthorEmail: "bob@work.com",
+	Date: time.Date(
+		2025, 1, 30, 16, 35, 26, 0, time.FixedZone("", -5*60*60),
+	),
+	CommitterName:  "Alice",
+	CommitterEmail: "alice@work.com",
+	CommitDate: time.Date(
+		2025, 1, 31, 9, 0, 0, 0, time.UTC,
+	),
+	CoAuthors: []git.CoAuthor{
+		{Name: "Carol", Email: "carol@work.com"},
+	},
+	FileDiffs: []git.FileDiff{
+		{
+			Path:         "foo/bar.txt",
+			PrevPath:     "foo/baz.txt",
+			LinesAdded:   3,
+			LinesRemoved: 5,
+		},
+		{
+			Path:         "foo/bim.txt",
+			LinesAdded:   1,
+			LinesRemoved: 0,
+		},
+	},
+}
+
+var indexCommitTwo = git.Commit{
+	ShortHash:   "2e9ea7662b1",
+	Hash:        "2e9ea7662b1001d860471a4cece5e2f1de8062fb",
+	IsMerge:     true,
+	AuthorName:  "Bob",
+	AuthorEmail: "bob@work.com",
+	Date: time.Date(
+		2025, 1, 31, 16, 35, 26, 0, time.UTC,
+	),
+}
+
+func openIndexBackend(t *testing.T, dir string) *backends.IndexBackend {
+	c := &backends.IndexBackend{Dir: dir, Prefix: "abcd1234"}
+	err := c.Open()
+	if err != nil {
+		t.Fatalf("could not open cache: %v", err)
+	}
+
+	return c
+}
+
+func getCommits(t *testing.T, c *backends.IndexBackend, revs []string) []git.Commit {
+	it, finish := c.Get(revs)
+	commits := slices.Collect(it)
+	err := finish()
+	if err != nil {
+		t.Fatalf("error iterating cached commits: %v", err)
+	}
+
+	return commits
+}
+
+func TestIndexAddGetClear(t *testing.T) {
+	c := openIndexBackend(t, CacheDir(t))
+	defer func() {
+		err := c.Close()
+		if err != nil {
+			t.Fatalf("could not close cache: %v", err)
+		}
+	}()
+
+	// -- Add --
+	err := c.Add([]git.Commit{indexCommitOne})
+	if err != nil {
+		t.Fatalf("add commits to cache failed with error: %v", err)
+	}
+
+	// -- Get --
+	revs := []string{indexCommitOne.Hash}
+	commits := getCommits(t, c, revs)
+	if len(commits) == 0 {
+		t.Fatal("not enough commits in result")
+	}
+
+	if diff := cmp.Diff(indexCommitOne, commits[0]); diff != "" {
+		t.Errorf("commit is wrong:\n%s", diff)
+	}
+
+	// -- Clear --
+	err = c.Clear()
+	if err != nil {
+		t.Fatalf("clearing cache failed with error: %v", err)
+	}
+
+	commits = getCommits(t, c, revs)
+	if len(commits) > 0 {
+		t.Errorf("cache result after clear should have been empty")
+	}
+
+	// -- Add after clear --
+	err = c.Add([]git.Commit{indexCommitOne})
+	if err != nil {
+		t.Fatalf("add commits to cache after clear failed with error: %v", err)
+	}
+
+	commits = getCommits(t, c, revs)
+	if len(commits) != 1 {
+		t.Errorf(
+			"expected to get one commit from cache, but got %d",
+			len(commits),
+		)
+	}
+}
+
+func TestIndexAddGetAddGet(t *testing.T) {
+	c := openIndexBackend(t, CacheDir(t))
+	defer func() {
+		err := c.Close()
+		if err != nil {
+			t.Fatalf("could not close cache: %v", err)
+		}
+	}()
+
+	revs := []string{indexCommitOne.Hash, indexCommitTwo.Hash}
+
+	err := c.Add([]git.Commit{indexCommitOne})
+	if err != nil {
+		t.Fatalf("add commits to cache failed with error: %v", err)
+	}
+
+	commits := getCommits(t, c, revs)
+	if len(commits) != 1 {
+		t.Errorf(
+			"expected to get one commit from cache, but got %d",
+			len(commits),
+		)
+	}
+
+	// Adding a commit that is already cached should not duplicate it
+	err = c.Add([]git.Commit{indexCommitOne, indexCommitTwo})
+	if err != nil {
+		t.Fatalf("add commits to cache failed with error: %v", err)
+	}
+
+	commits = getCommits(t, c, revs)
+	expected := []git.Commit{indexCommitOne, indexCommitTwo}
+	if diff := cmp.Diff(expected, commits); diff != "" {
+		t.Errorf("commits are wrong:\n%s", diff)
+	}
+}
+
+func TestIndexReopen(t *testing.T) {
+	dir := CacheDir(t)
+
+	c := openIndexBackend(t, dir)
+	err := c.Add([]git.Commit{indexCommitOne})
+	if err != nil {
+		t.Fatalf("add commits to cache failed with error: %v", err)
+	}
+
+	err = c.Close()
+	if err != nil {
+		t.Fatalf("could not close cache: %v", err)
+	}
+
+	c = openIndexBackend(t, dir)
+	defer c.Close()
+
+	err = c.Add([]git.Commit{indexCommitTwo})
+	if err != nil {
+		t.Fatalf("add commits to cache failed with error: %v", err)
+	}
+
+	commits := getCommits(t, c, []string{indexCommitTwo.Hash, indexCommitOne.Hash})
+	expected := []git.Commit{indexCommitOne, indexCommitTwo}
+	if diff := cmp.Diff(expected, commits); diff != "" {
+		t.Errorf("commits are wrong:\n%s", diff)
+	}
+}
+
+// Two processes using the same cache, each with its own view of what is in it
+func TestIndexConcurrentWriters(t *testing.T) {
+	dir := CacheDir(t)
+
+	a := openIndexBackend(t, dir)
+	b := openIndexBackend(t, dir)
+
+	err := a.Add([]git.Commit{indexCommitOne})
+	if err != nil {
+		t.Fatalf("add commits to cache failed with error: %v", err)
+	}
+
+	// b opened the cache before a wrote to it, but must not clobber a's commit
+	err = b.Add([]git.Commit{indexCommitTwo})
+	if err != nil {
+		t.Fatalf("add commits to cache failed with error: %v", err)
+	}
+
+	err = a.Close()
+	if err != nil {
+		t.Fatalf("could not close cache: %v", err)
+	}
+
+	err = b.Close()
+	if err != nil {
+		t.Fatalf("could not close cache: %v", err)
+	}
+
+	c := openIndexBackend(t, dir)
+	defer c.Close()
+
+	revs := []string{indexCommitOne.Hash, indexCommitTwo.Hash}
+	commits := getCommits(t, c, revs)
+	expected := []git.Commit{indexCommitOne, indexCommitTwo}
+	if diff := cmp.Diff(expected, commits); diff != "" {
+		t.Errorf("commits are wrong:\n%s", diff)
+	}
+
+	n, corrupt := c.Verify()
+	if n != 2 || len(corrupt) > 0 {
+		t.Errorf("expected 2 good commits but got %d with %v", n, corrupt)
+	}
+}
+
+func TestIndexTruncatedIndex(t *testing.T) {
+	dir := CacheDir(t)
+
+	c := openIndexBackend(t, dir)
+	err := c.Add([]git.Commit{indexCommitOne})
+	if err != nil {
+		t.Fatalf("add commits to cache failed with error: %v", err)
+	}
+
+	err = c.Add([]git.Commit{indexCommitTwo})
+	if err != nil {
+		t.Fatalf("add commits to cache failed with error: %v", err)
+	}
+
+	err = c.Close()
+	if err != nil {
+		t.Fatalf("could not close cache: %v", err)
+	}
+
+	// Simulate being killed while writing the last index entry
+	indexPath := filepath.Join(dir, "abcd1234.idx")
+	info, err := os.Stat(indexPath)
+	if err != nil {
+		t.Fatalf("could not stat index file: %v", err)
+	}
+
+	err = os.Truncate(indexPath, info.Size()-3)
+	if err != nil {
+		t.Fatalf("could not truncate index file: %v", err)
+	}
+
+	c = openIndexBackend(t, dir)
+	defer c.Close()
+
+	revs := []string{indexCommitOne.Hash, indexCommitTwo.Hash}
+	commits := getCommits(t, c, revs)
+	if diff := cmp.Diff([]git.Commit{indexCommitOne}, commits); diff != "" {
+		t.Errorf("commits are wrong:\n%s", diff)
+	}
+
+	// The lost commit can be added again
+	err = c.Add([]git.Commit{indexCommitTwo})
+	if err != nil {
+		t.Fatalf("add commits to cache failed with error: %v", err)
+	}
+
+	commits = getCommits(t, c, revs)
+	expected := []git.Commit{indexCommitOne, indexCommitTwo}
+	if diff := cmp.Diff(expected, commits); diff != "" {
+		t.Errorf("commits are wrong:\n%s", diff)
+	}
+}
+
+func TestIndexPrune(t *testing.T) {
+	dir := CacheDir(t)
+
+	c := openIndexBackend(t, dir)
+	defer c.Close()
+
+	err := c.Add([]git.Commit{indexCommitOne, indexCommitTwo})
+	if err != nil {
+		t.Fatalf("add commits to cache failed with error: %v", err)
+	}
+
+	n, err := c.Prune(func(hash string) bool {
+		return hash == indexCommitTwo.Hash
+	})
+	if err != nil {
+		t.Fatalf("prune failed with error: %v", err)
+	}
+
+	if n != 1 {
+		t.Errorf("expected to prune one commit, but pruned %d", n)
+	}
+
+	revs := []string{indexCommitOne.Hash, indexCommitTwo.Hash}
+	commits := getCommits(t, c, revs)
+	if diff := cmp.Diff([]git.Commit{indexCommitTwo}, commits); diff != "" {
+		t.Errorf("commits are wrong:\n%s", diff)
+	}
+
+	// Cache can still be added to and reopened after pruning
+	err = c.Add([]git.Commit{indexCommitOne})
+	if err != nil {
+		t.Fatalf("add commits to cache failed with error: %v", err)
+	}
+
+	err = c.Close()
+	if err != nil {
+		t.Fatalf("could not close cache: %v", err)
+	}
+
+	c = openIndexBackend(t, dir)
+	commits = getCommits(t, c, revs)
+	expected := []git.Commit{indexCommitTwo, indexCommitOne} // Order on disk
+	if diff := cmp.Diff(expected, commits); diff != "" {
+		t.Errorf("commits are wrong:\n%s", diff)
+	}
+}
+
+// Commits added by another process after we opened the cache are pruned too
+func TestIndexPruneConcurrentWriter(t *testing.T) {
+	dir := CacheDir(t)
+
+	a := openIndexBackend(t, dir)
+	defer a.Close()
+
+	b := openIndexBackend(t, dir)
+	err := b.Add([]git.Commit{indexCommitOne, indexCommitTwo})
+	if err != nil {
+		t.Fatalf("add commits to cache failed with error: %v", err)
+	}
+
+	err = b.Close()
+	if err != nil {
+		t.Fatalf("could not close cache: %v", err)
+	}
+
+	n, err := a.Prune(func(hash string) bool {
+		return hash == indexCommitTwo.Hash
+	})
+	if err != nil {
+		t.Fatalf("prune failed with error: %v", err)
+	}
+
+	if n != 1 {
+		t.Errorf("expected to prune one commit, but pruned %d", n)
+	}
+
+	revs := []string{indexCommitOne.Hash, indexCommitTwo.Hash}
+	commits := getCommits(t, a, revs)
+	if diff := cmp.Diff([]git.Commit{indexCommitTwo}, commits); diff != "" {
+		t.Errorf("commits are wrong:\n%s", diff)
+	}
+}
+
+// Pruning moves records around, so it has to wait until nobody else is
+// reading them
+func TestIndexPruneWaitsForReaders(t *testing.T) {
+	dir := CacheDir(t)
+
+	a := openIndexBackend(t, dir)
+	defer a.Close()
+
+	err := a.Add([]git.Commit{indexCommitOne, indexCommitTwo})
+	if err != nil {
+		t.Fatalf("add commits to cache failed with error: %v", err)
+	}
+
+	b := openIndexBackend(t, dir)
+
+	done := make(chan error)
+	go func() {
+		_, err := a.Prune(func(hash string) bool {
+			return hash == indexCommitTwo.Hash
+		})
+		done <- err
+	}()
+
+	select {
+	case <-done:
+		t.Fatalf("prune finished while cache was still open elsewhere")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// b can still read what it opened
+	revs := []string{indexCommitOne.Hash, indexCommitTwo.Hash}
+	commits := getCommits(t, b, revs)
+	expected := []git.Commit{indexCommitOne, indexCommitTwo}
+	if diff := cmp.Diff(expected, commits); diff != "" {
+		t.Errorf("commits are wrong:\n%s", diff)
+	}
+
+	err = b.Close()
+	if err != nil {
+		t.Fatalf("could not close cache: %v", err)
+	}
+
+	err = <-done
+	if err != nil {
+		t.Fatalf("prune failed with error: %v", err)
+	}
+
+	commits = getCommits(t, a, revs)
+	if diff := cmp.Diff([]git.Commit{indexCommitTwo}, commits); diff != "" {
+		t.Errorf("commits are wrong:\n%s", diff)
+	}
+}
+
+// Closing the cache for another repo state must not delete the files of a
+// prune still in progress
+func TestIndexCloseKeepsPruneFiles(t *testing.T) {
+	dir := CacheDir(t)
+
+	pruning := &backends.IndexBackend{Dir: dir, Prefix: "abcd1234-pruned"}
+	err := pruning.Open()
+	if err != nil {
+		t.Fatalf("could not open cache: %v", err)
+	}
+
+	err = pruning.Add([]git.Commit{indexCommitOne})
+	if err != nil {
+		t.Fatalf("add commits to cache failed with error: %v", err)
+	}
+
+	other := &backends.IndexBackend{Dir: dir, Prefix: "ef567890"}
+	err = other.Open()
+	if err != nil {
+		t.Fatalf("could not open cache: %v", err)
+	}
+
+	err = other.Close()
+	if err != nil {
+		t.Fatalf("could not close cache: %v", err)
+	}
+
+	names := []string{"abcd1234-pruned.idx", "abcd1234-pruned.0.seg"}
+	for _, name := range names {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("expected %s to still exist, but got: %v", name, err)
+		}
+	}
+}
+
+func TestIndexVerify(t *testing.T) {
+	dir := CacheDir(t)
+
+	c := openIndexBackend(t, dir)
+	defer c.Close()
+
+	err := c.Add([]git.Commit{indexCommitOne, indexCommitTwo})
+	if err != nil {
+		t.Fatalf("add commits to cache failed with error: %v", err)
+	}
+
+	n, corrupt := c.Verify()
+	if n != 2 || len(corrupt) > 0 {
+		t.Fatalf("expected 2 good commits, got %d with corrupt: %v", n, corrupt)
+	}
+
+	// Flip a byte in the first record
+	segmentPath := filepath.Join(dir, "abcd1234.0.seg")
+	data, err := os.ReadFile(segmentPath)
+	if err != nil {
+		t.Fatalf("could not read segment file: %v", err)
+	}
+
+	data[10] ^= 0xff
+	err = os.WriteFile(segmentPath, data, 0644)
+	if err != nil {
+		t.Fatalf("could not write segment file: %v", err)
+	}
+
+	n, corrupt = c.Verify()
+	if n != 2 {
+		t.Errorf("expected to check 2 commits, but checked %d", n)
+	}
+
+	if len(corrupt) != 1 || corrupt[0].Hash != indexCommitOne.Hash {
+		t.Errorf("expected first commit to be corrupt, got: %v", corrupt)
+	}
+}
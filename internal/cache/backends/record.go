@@ -0,0 +1,212 @@
+package backends
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sinclairtarget/git-who/internal/git"
+)
+
+// Encodes a single commit as a compact, self-contained record.
+//
+// Unlike Gob, the record format carries no type information, so each record
+// can be decoded on its own without reading anything else from disk.
+func encodeCommit(buf []byte, c git.Commit) ([]byte, error) {
+	date, err := c.Date.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	commitDate, err := c.CommitDate.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	buf = appendString(buf, c.Hash)
+	buf = appendString(buf, c.ShortHash)
+	buf = appendBool(buf, c.IsMerge)
+	buf = appendString(buf, c.AuthorName)
+	buf = appendString(buf, c.AuthorEmail)
+	buf = appendString(buf, string(date))
+	buf = appendString(buf, c.CommitterName)
+	buf = appendString(buf, c.CommitterEmail)
+	buf = appendString(buf, string(commitDate))
+
+	buf = binary.AppendUvarint(buf, uint64(len(c.CoAuthors)))
+	for _, coAuthor := range c.CoAuthors {
+		buf = appendString(buf, coAuthor.Name)
+		buf = appendString(buf, coAuthor.Email)
+	}
+
+	buf = binary.AppendUvarint(buf, uint64(len(c.FileDiffs)))
+	for _, diff := range c.FileDiffs {
+		buf = appendString(buf, diff.Path)
+		buf = appendString(buf, diff.PrevPath)
+		buf = binary.AppendVarint(buf, int64(diff.LinesAdded))
+		buf = binary.AppendVarint(buf, int64(diff.LinesRemoved))
+	}
+
+	return buf, nil
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+func appendBool(buf []byte, b bool) []byte {
+	if b {
+		return append(buf, 1)
+	}
+
+	return append(buf, 0)
+}
+
+var errBadRecord = errors.New("malformed commit record")
+
+// Reads the fields of a record in order, remembering the first error.
+type recordReader struct {
+	data []byte
+	err  error
+}
+
+func (r *recordReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.err = errBadRecord
+		return 0
+	}
+
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *recordReader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, n := binary.Varint(r.data)
+	if n <= 0 {
+		r.err = errBadRecord
+		return 0
+	}
+
+	r.data = r.data[n:]
+	return v
+}
+
+// Reads a count of things that each take at least one byte, so that a corrupt
+// count can't make us allocate a huge slice.
+func (r *recordReader) count() int {
+	n := r.uvarint()
+	if n > uint64(len(r.data)) {
+		r.err = errBadRecord
+		return 0
+	}
+
+	return int(n)
+}
+
+func (r *recordReader) string() string {
+	n := r.count()
+	if r.err != nil {
+		return ""
+	}
+
+	s := string(r.data[:n])
+	r.data = r.data[n:]
+	return s
+}
+
+func (r *recordReader) bool() bool {
+	if r.err != nil {
+		return false
+	}
+
+	if len(r.data) == 0 {
+		r.err = errBadRecord
+		return false
+	}
+
+	b := r.data[0] != 0
+	r.data = r.data[1:]
+	return b
+}
+
+func (r *recordReader) time() time.Time {
+	var t time.Time
+
+	data := r.string()
+	if r.err != nil {
+		return t
+	}
+
+	err := t.UnmarshalBinary([]byte(data))
+	if err != nil {
+		r.err = err
+	}
+
+	return t
+}
+
+// Decodes a record written by encodeCommit().
+func decodeCommit(data []byte) (_ git.Commit, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("could not decode commit: %w", err)
+		}
+	}()
+
+	r := recordReader{data: data}
+
+	c := git.Commit{
+		Hash:           r.string(),
+		ShortHash:      r.string(),
+		IsMerge:        r.bool(),
+		AuthorName:     r.string(),
+		AuthorEmail:    r.string(),
+		Date:           r.time(),
+		CommitterName:  r.string(),
+		CommitterEmail: r.string(),
+		CommitDate:     r.time(),
+	}
+
+	if n := r.count(); n > 0 {
+		c.CoAuthors = make([]git.CoAuthor, 0, n)
+		for range n {
+			c.CoAuthors = append(c.CoAuthors, git.CoAuthor{
+				Name:  r.string(),
+				Email: r.string(),
+			})
+		}
+	}
+
+	if n := r.count(); n > 0 {
+		c.FileDiffs = make([]git.FileDiff, 0, n)
+		for range n {
+			c.FileDiffs = append(c.FileDiffs, git.FileDiff{
+				Path:         r.string(),
+				PrevPath:     r.string(),
+				LinesAdded:   int(r.varint()),
+				LinesRemoved: int(r.varint()),
+			})
+		}
+	}
+
+	if r.err != nil {
+		return git.Commit{}, r.err
+	}
+
+	if len(r.data) > 0 {
+		return git.Commit{}, errBadRecord
+	}
+
+	return c, nil
+}
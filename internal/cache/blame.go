@@ -0,0 +1,247 @@
+package cache
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sinclairtarget/git-who/internal/cache/backends"
+	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/git/config"
+)
+
+// Cache for the results of running git blame on individual files.
+//
+// Results are keyed by path and blob hash, so a file only needs to be blamed
+// again once its contents change. The whole cache is read into memory when
+// opened and written back to disk (gzipped) when closed, if anything was added.
+type BlameCache struct {
+	Dir     string // Empty if caching is disabled
+	Path    string
+	blames  map[string]git.FileBlame
+	isDirty bool
+
+	gitRootPath string // Set if we should record use of the cache
+}
+
+func blameKey(path string, blob string) string {
+	return blob + ":" + path
+}
+
+func (c *BlameCache) Open() (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error opening blame cache: %w", err)
+		}
+	}()
+
+	start := time.Now()
+
+	c.blames = map[string]git.FileBlame{}
+	if c.Path == "" {
+		return nil
+	}
+
+	f, err := os.Open(c.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(bufio.NewReader(f))
+	if err != nil {
+		return err
+	}
+
+	err = gob.NewDecoder(zr).Decode(&c.blames)
+	if err != nil {
+		return err
+	}
+
+	elapsed := time.Now().Sub(start)
+	logger().Debug(
+		"blame cache open",
+		"duration_ms",
+		elapsed.Milliseconds(),
+		"entries",
+		len(c.blames),
+	)
+
+	return nil
+}
+
+func (c *BlameCache) Get(path string, blob string) (git.FileBlame, bool) {
+	blame, ok := c.blames[blameKey(path, blob)]
+	return blame, ok
+}
+
+func (c *BlameCache) Add(blame git.FileBlame) {
+	c.blames[blameKey(blame.Path, blame.Blob)] = blame
+	c.isDirty = true
+}
+
+func (c *BlameCache) Close() (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error closing blame cache: %w", err)
+		}
+	}()
+
+	if c.Path == "" {
+		return nil
+	}
+
+	if len(c.gitRootPath) > 0 {
+		defer recordUse(c.gitRootPath)
+	}
+
+	if !c.isDirty {
+		return nil
+	}
+
+	start := time.Now()
+
+	// Write to a temporary file first so we never leave a partial cache behind
+	tmp, err := os.CreateTemp(c.Dir, "tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	zw, err := gzip.NewWriterLevel(w, gzip.BestSpeed)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	err = gob.NewEncoder(zw).Encode(c.blames)
+	if err == nil {
+		err = zw.Close()
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	err = errors.Join(err, tmp.Close())
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmp.Name(), c.Path)
+	if err != nil {
+		return err
+	}
+
+	// Remove any caches written for an older repo state
+	matches, err := filepath.Glob(filepath.Join(c.Dir, "*"))
+	if err != nil {
+		panic(err) // Bad pattern
+	}
+
+	for _, match := range matches {
+		if match == c.Path {
+			continue
+		}
+
+		err := os.Remove(match)
+		if err != nil {
+			logger().Warn(
+				fmt.Sprintf("failed to delete old blame cache file: %v", err),
+			)
+		}
+	}
+
+	c.isDirty = false
+
+	elapsed := time.Now().Sub(start)
+	logger().Debug(
+		"blame cache close",
+		"duration_ms",
+		elapsed.Milliseconds(),
+	)
+
+	return nil
+}
+
+func (c *BlameCache) Clear() error {
+	c.blames = map[string]git.FileBlame{}
+	c.isDirty = false
+
+	if c.Dir == "" {
+		return nil
+	}
+
+	err := os.RemoveAll(c.Dir)
+	if err != nil {
+		return err
+	}
+
+	logger().Debug("blame cache clear")
+	return nil
+}
+
+// Blame results depend on which revisions are ignored. They don't depend on the
+// mailmap, since we store each author as they appear in the commit.
+func blameStateHash(sf config.SupplementalFiles) (string, error) {
+	h := fnv.New32()
+	fmt.Fprintf(h, "format:%d\n", formatVersion)
+
+	ignoreRevs, err := sf.IgnoreRevs()
+	if err != nil {
+		return "", err
+	}
+
+	for _, rev := range ignoreRevs {
+		fmt.Fprintln(h, rev)
+	}
+
+	return fmt.Sprintf("%x", h.Sum32()), nil
+}
+
+func GetBlameCache(
+	gitRootPath string,
+	configFiles config.SupplementalFiles,
+) *BlameCache {
+	disabled := &BlameCache{}
+
+	if !IsCachingEnabled() {
+		return disabled
+	}
+
+	warnFail := func(err error) *BlameCache {
+		logger().Warn(
+			fmt.Sprintf("failed to initialize blame cache: %v", err),
+		)
+		logger().Warn("disabling blame caching")
+		return disabled
+	}
+
+	cacheStorageDir, err := cacheStorageDir("blame")
+	if err != nil {
+		return warnFail(err)
+	}
+
+	dirname := backends.GobCacheDir(cacheStorageDir, gitRootPath)
+	err = os.MkdirAll(dirname, 0o700)
+	if err != nil {
+		return warnFail(err)
+	}
+
+	stateHash, err := blameStateHash(configFiles)
+	if err != nil {
+		return warnFail(err)
+	}
+
+	p := filepath.Join(dirname, stateHash+".gob.gz")
+	logger().Debug("blame cache initialized", "path", p)
+	return &BlameCache{Dir: dirname, Path: p, gitRootPath: gitRootPath}
+}
@@ -0,0 +1,78 @@
+package cache_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sinclairtarget/git-who/internal/cache"
+	"github.com/sinclairtarget/git-who/internal/git"
+)
+
+func TestBlameCacheRoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "blame", "test-1234")
+	err := os.MkdirAll(dir, 0o700)
+	if err != nil {
+		t.Fatalf("could not create cache dir: %v", err)
+	}
+
+	stale := filepath.Join(dir, "old.gob.gz")
+	err = os.WriteFile(stale, []byte{}, 0o644)
+	if err != nil {
+		t.Fatalf("could not create stale cache file: %v", err)
+	}
+
+	blame := git.FileBlame{
+		Path: "foo/bar.txt",
+		Blob: "3b18e512dba79e4c8300dd08aeb37f8e728b8dad",
+		Commits: []git.BlameCommit{
+			{
+				Hash:        "9e9ea7662b1001d860471a4cece5e2f1de8062fb",
+				AuthorName:  "Bob",
+				AuthorEmail: "bob@work.com",
+				Date:        time.Date(2025, 1, 31, 16, 35, 26, 0, time.UTC),
+				Lines:       12,
+			},
+		},
+	}
+
+	c := cache.BlameCache{Dir: dir, Path: filepath.Join(dir, "blame.gob.gz")}
+	err = c.Open()
+	if err != nil {
+		t.Fatalf("could not open cache: %v", err)
+	}
+
+	c.Add(blame)
+
+	err = c.Close()
+	if err != nil {
+		t.Fatalf("could not close cache: %v", err)
+	}
+
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("expected stale cache file to be removed")
+	}
+
+	reopened := cache.BlameCache{Dir: dir, Path: c.Path}
+	err = reopened.Open()
+	if err != nil {
+		t.Fatalf("could not reopen cache: %v", err)
+	}
+
+	got, ok := reopened.Get(blame.Path, blame.Blob)
+	if !ok {
+		t.Fatalf("expected blame to be in cache")
+	}
+
+	if diff := cmp.Diff(blame, got); diff != "" {
+		t.Errorf("cached blame is wrong:\n%s", diff)
+	}
+
+	_, ok = reopened.Get(blame.Path, "a0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9")
+	if ok {
+		t.Errorf("expected blame for different blob to be missing")
+	}
+}
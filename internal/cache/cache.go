@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"hash/fnv"
+	"io"
 	"iter"
 	"os"
 	"os/user"
@@ -13,7 +14,6 @@ import (
 
 	"github.com/sinclairtarget/git-who/internal/cache/backends"
 	"github.com/sinclairtarget/git-who/internal/git"
-	"github.com/sinclairtarget/git-who/internal/git/config"
 )
 
 func IsCachingEnabled() bool {
@@ -34,7 +34,9 @@ type Backend interface {
 }
 
 type Cache struct {
-	backend Backend
+	backend     Backend
+	stateHash   string // See repoStateHash()
+	gitRootPath string // Set if we should record use of the cache
 }
 
 func NewCache(backend Backend) Cache {
@@ -92,6 +94,10 @@ func (c *Cache) Close() (err error) {
 		elapsed.Milliseconds(),
 	)
 
+	if len(c.gitRootPath) > 0 {
+		recordUse(c.gitRootPath)
+	}
+
 	return nil
 }
 
@@ -145,6 +151,181 @@ func (c *Cache) Clear() error {
 	return nil
 }
 
+// Backends that can be inspected and maintained using "git who cache".
+type MaintainableBackend interface {
+	Backend
+	Stats() (backends.Stats, error)
+	All() (iter.Seq[git.Commit], func() error)
+	Prune(keep func(hash string) bool) (int, error)
+	Verify() (int, []backends.CorruptRecord)
+}
+
+// Number of commits to add to the cache at once when importing
+const importChunkSize = 1000
+
+func (c *Cache) maintainable() (MaintainableBackend, error) {
+	mb, ok := c.backend.(MaintainableBackend)
+	if !ok {
+		return nil, fmt.Errorf(
+			"%s cache backend does not support maintenance",
+			c.Name(),
+		)
+	}
+
+	return mb, nil
+}
+
+func (c *Cache) Stats() (backends.Stats, error) {
+	mb, err := c.maintainable()
+	if err != nil {
+		return backends.Stats{}, err
+	}
+
+	stats, err := mb.Stats()
+	if err != nil || len(c.gitRootPath) == 0 {
+		return stats, err
+	}
+
+	// The manifest knows when we last used any of the repo's caches
+	root, err := cacheStorageDir("")
+	if err != nil {
+		return stats, err
+	}
+
+	m, err := readManifest(root)
+	if err != nil {
+		return stats, err
+	}
+
+	name := filepath.Base(backends.GobCacheDir("", c.gitRootPath))
+	stats.LastUsed = m.Repos[name].LastUsed
+	return stats, nil
+}
+
+// Removes every cached commit for which keep returns false. Returns the number
+// of commits removed.
+func (c *Cache) Prune(keep func(hash string) bool) (int, error) {
+	mb, err := c.maintainable()
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+
+	n, err := mb.Prune(keep)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune cache: %w", err)
+	}
+
+	elapsed := time.Now().Sub(start)
+	logger().Debug(
+		"cache prune",
+		"duration_ms",
+		elapsed.Milliseconds(),
+		"pruned",
+		n,
+	)
+
+	return n, nil
+}
+
+// Reads back every cached commit. Returns the number of commits checked and
+// the ones that could not be read.
+func (c *Cache) Verify() (int, []backends.CorruptRecord, error) {
+	mb, err := c.maintainable()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	n, corrupt := mb.Verify()
+	return n, corrupt, nil
+}
+
+// Writes every cached commit to w as an archive that can be imported
+// elsewhere. Returns the number of commits written.
+func (c *Cache) Export(w io.Writer) (_ int, err error) {
+	mb, err := c.maintainable()
+	if err != nil {
+		return 0, err
+	}
+
+	commits, finish := mb.All()
+	defer func() {
+		finishErr := finish()
+		if err == nil && finishErr != nil {
+			err = fmt.Errorf("failed to retrieve from cache: %w", finishErr)
+		}
+	}()
+
+	return backends.WriteArchive(w, c.stateHash, commits)
+}
+
+// Adds the commits in an archive written by Export() to the cache. Returns the
+// number of commits read from the archive.
+//
+// Archives written with a different cache format are rejected.
+func (c *Cache) Import(r io.Reader) (_ int, err error) {
+	stateHash, commits, finish, err := backends.ReadArchive(r)
+	if err != nil {
+		return 0, err
+	}
+
+	if stateHash != c.stateHash {
+		return 0, fmt.Errorf(
+			"archive was made by a different version of git-who "+
+				"(archive state hash is %s, expected %s)",
+			stateHash,
+			c.stateHash,
+		)
+	}
+
+	n := 0
+	chunk := []git.Commit{}
+	for commit := range commits {
+		chunk = append(chunk, commit)
+		n += 1
+
+		if len(chunk) >= importChunkSize {
+			err = c.Add(chunk)
+			if err != nil {
+				return n, err
+			}
+
+			chunk = []git.Commit{}
+		}
+	}
+
+	err = finish()
+	if err != nil {
+		return n, err
+	}
+
+	err = c.Add(chunk)
+	if err != nil {
+		return n, err
+	}
+
+	return n, nil
+}
+
+// Deletes the caches for every repository.
+//
+// Returns the directory that was removed.
+func ClearAll() (string, error) {
+	dir, err := cacheStorageDir("")
+	if err != nil {
+		return "", err
+	}
+
+	err = os.RemoveAll(dir)
+	if err != nil {
+		return "", err
+	}
+
+	logger().Debug("cache clear all", "dir", dir)
+	return dir, nil
+}
+
 // Returns the absolute path at which we should store data for a given cache
 // backend.
 //
@@ -175,15 +356,18 @@ func cacheStorageDir(name string) (_ string, err error) {
 	return absP, nil
 }
 
-// Hash of all the state in the repo that affects the validity of our cache
-func repoStateHash(sf config.SupplementalFiles) (string, error) {
-	h := fnv.New32()
-	err := sf.MailmapHash(h)
-	if err != nil {
-		return "", err
-	}
+// Bump this whenever the fields stored for each commit change, so that caches
+// written by older versions of git-who are thrown away.
+const formatVersion = 4
 
-	return hex.EncodeToString(h.Sum(nil)), nil
+// Hash of all the state that affects the validity of our cache.
+//
+// The mailmap isn't part of it: we cache identities as they appear in each
+// commit and apply the mailmap when tallying.
+func repoStateHash() string {
+	h := fnv.New32()
+	fmt.Fprintf(h, "format:%d\n", formatVersion)
+	return hex.EncodeToString(h.Sum(nil))
 }
 
 func warnFail(cb Backend, err error) Cache {
@@ -194,33 +378,42 @@ func warnFail(cb Backend, err error) Cache {
 	return NewCache(cb)
 }
 
-func GetCache(gitRootPath string, configFiles config.SupplementalFiles) Cache {
+func GetCache(gitRootPath string) Cache {
 	var fallback Backend = backends.NoopBackend{}
 
 	if !IsCachingEnabled() {
 		return NewCache(fallback)
 	}
 
-	cacheStorageDir, err := cacheStorageDir(
-		backends.GobBackendName,
-	)
+	stateHash := repoStateHash()
+
+	// Caches written by the old gob backend have the mailmap applied to them,
+	// so they're no use to us
+	gobStorageDir, err := cacheStorageDir(backends.GobBackendName)
+	if err == nil {
+		err = os.RemoveAll(backends.GobCacheDir(gobStorageDir, gitRootPath))
+	}
 	if err != nil {
-		return warnFail(fallback, err)
+		logger().Warn(fmt.Sprintf("failed to delete old cache: %v", err))
 	}
 
-	dirname := backends.GobCacheDir(cacheStorageDir, gitRootPath)
-	err = os.MkdirAll(dirname, 0o700)
+	cacheStorageDir, err := cacheStorageDir(backends.IndexBackendName)
 	if err != nil {
 		return warnFail(fallback, err)
 	}
 
-	stateHash, err := repoStateHash(configFiles)
+	dirname := backends.IndexCacheDir(cacheStorageDir, gitRootPath)
+	err = os.MkdirAll(dirname, 0o700)
 	if err != nil {
 		return warnFail(fallback, err)
 	}
 
-	filename := backends.GobCacheFilename(stateHash)
-	p := filepath.Join(dirname, filename)
-	logger().Debug("cache initialized", "path", p)
-	return NewCache(&backends.GobBackend{Path: p, Dir: dirname})
+	logger().Debug("cache initialized", "dir", dirname, "prefix", stateHash)
+	c := NewCache(&backends.IndexBackend{
+		Dir:    dirname,
+		Prefix: stateHash,
+	})
+	c.stateHash = stateHash
+	c.gitRootPath = gitRootPath
+	return c
 }
@@ -0,0 +1,479 @@
+package cache
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/sinclairtarget/git-who/internal/cache/backends"
+	"github.com/sinclairtarget/git-who/internal/git/config"
+	"github.com/sinclairtarget/git-who/internal/utils/fileutils"
+)
+
+// The caches for all repos share one budget for disk space, and a repo's caches
+// are deleted once they go unused for too long or once the repo itself is gone.
+//
+// To decide what to delete, we keep a manifest at the root of the cache
+// directory recording where each repo lives and when its caches were last
+// used. The manifest is updated every time a cache is closed.
+const manifestFilename = "manifest.json"
+
+// Held while housekeeping, so that only one process at a time rewrites the
+// manifest and deletes caches.
+const lockFilename = "housekeeping.lock"
+
+// Used unless the limits are set in the git config
+const (
+	defaultMaxSize    = 2 * 1024 * 1024 * 1024
+	defaultMaxAgeDays = 90
+)
+
+// Kinds of cache we store for each repo. Each kind has its own directory under
+// the cache root, which contains one directory per repo named using
+// backends.GobCacheDir().
+var repoCacheKinds = []string{
+	backends.IndexBackendName,
+	"blame",
+	backends.GobBackendName,
+}
+
+// Limits on the caches for all repos.
+type Limits struct {
+	MaxSize int64         // Bytes used by all repos together. Zero for no limit
+	MaxAge  time.Duration // Time since a repo was last used. Zero for no limit
+}
+
+type manifestEntry struct {
+	RepoPath string    `json:"repoPath"` // Empty if we don't know it
+	LastUsed time.Time `json:"lastUsed"`
+	Size     int64     `json:"size"`
+}
+
+type manifest struct {
+	Repos map[string]manifestEntry `json:"repos"` // Keyed by repo dir name
+}
+
+func readManifest(root string) (manifest, error) {
+	m := manifest{Repos: map[string]manifestEntry{}}
+
+	data, err := os.ReadFile(filepath.Join(root, manifestFilename))
+	if errors.Is(err, fs.ErrNotExist) {
+		return m, nil
+	} else if err != nil {
+		return m, err
+	}
+
+	err = json.Unmarshal(data, &m)
+	if err != nil || m.Repos == nil {
+		// We can rebuild most of it from what is on disk
+		logger().Warn("cache manifest is corrupt; starting a new one")
+		return manifest{Repos: map[string]manifestEntry{}}, nil
+	}
+
+	return m, nil
+}
+
+func writeManifest(root string, m manifest) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(root, 0o700)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so we never leave a partial manifest
+	tmp, err := os.CreateTemp(root, ".manifest-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	err = errors.Join(err, tmp.Close())
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(root, manifestFilename))
+}
+
+// Finds the cache directories for every repo under root. Returns the total
+// size of each repo's directories and when they were last modified.
+func scanRepoDirs(root string) (map[string]manifestEntry, error) {
+	found := map[string]manifestEntry{}
+
+	for _, kind := range repoCacheKinds {
+		entries, err := os.ReadDir(filepath.Join(root, kind))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+
+			name := entry.Name()
+			e := found[name]
+
+			p := filepath.Join(root, kind, name)
+			err := filepath.WalkDir(p, func(
+				_ string,
+				d fs.DirEntry,
+				err error,
+			) error {
+				if err != nil {
+					return err
+				}
+
+				info, err := d.Info()
+				if err != nil {
+					return err
+				}
+
+				if !d.IsDir() {
+					e.Size += info.Size()
+				}
+				if info.ModTime().After(e.LastUsed) {
+					e.LastUsed = info.ModTime()
+				}
+
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			found[name] = e
+		}
+	}
+
+	return found, nil
+}
+
+// Records that the caches for the repo at gitRootPath were just used, then
+// deletes the caches of other repos under root that are past the limits.
+//
+// Caches are deleted if their repo no longer exists or if they haven't been
+// used within limits.MaxAge. Then, while the caches together take up more than
+// limits.MaxSize, we delete the least recently used ones. The caches for the
+// repo at gitRootPath are never deleted.
+//
+// Other processes may be using the caches of other repos right now. We never
+// delete caches that were written to since their use was last recorded, nor
+// commit caches that another process has open.
+//
+// Returns the names of the repo directories that were deleted.
+func Housekeep(
+	root string,
+	gitRootPath string,
+	now time.Time,
+	limits Limits,
+) (_ []string, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("failed to clean up cache: %w", err)
+		}
+	}()
+
+	unlock, err := lockRoot(root)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = errors.Join(err, unlock())
+	}()
+
+	m, err := readManifest(root)
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := scanRepoDirs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sizes come from what is on disk. Anything we don't have in the manifest
+	// was last used when it was last modified
+	repos := map[string]manifestEntry{}
+	inUse := map[string]bool{}
+	for name, e := range found {
+		if prev, ok := m.Repos[name]; ok {
+			inUse[name] = e.LastUsed.After(prev.LastUsed)
+			e.RepoPath = prev.RepoPath
+			e.LastUsed = prev.LastUsed
+		}
+
+		repos[name] = e
+	}
+
+	current := filepath.Base(backends.GobCacheDir("", gitRootPath))
+	repos[current] = manifestEntry{
+		RepoPath: gitRootPath,
+		LastUsed: now,
+		Size:     found[current].Size,
+	}
+
+	// -- Evict --
+	evicted := []string{}
+	evict := func(name string) (bool, error) {
+		ok, err := evictRepo(root, name)
+		if ok {
+			delete(repos, name)
+			evicted = append(evicted, name)
+		}
+		return ok, err
+	}
+
+	candidates := []string{}
+	var total int64
+	for name, e := range repos {
+		if name == current || inUse[name] {
+			total += e.Size
+			continue
+		}
+
+		expired := limits.MaxAge > 0 && now.Sub(e.LastUsed) > limits.MaxAge
+		if len(e.RepoPath) > 0 {
+			_, err := os.Stat(e.RepoPath)
+			if errors.Is(err, fs.ErrNotExist) {
+				expired = true
+			}
+		}
+
+		if expired {
+			ok, err := evict(name)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				total += e.Size
+			}
+			continue
+		}
+
+		total += e.Size
+		candidates = append(candidates, name)
+	}
+
+	if limits.MaxSize > 0 {
+		slices.SortFunc(candidates, func(a, b string) int {
+			return cmp.Or(
+				repos[a].LastUsed.Compare(repos[b].LastUsed),
+				cmp.Compare(a, b),
+			)
+		})
+
+		for _, name := range candidates {
+			if total <= limits.MaxSize {
+				break
+			}
+
+			size := repos[name].Size
+			ok, err := evict(name)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				total -= size
+			}
+		}
+	}
+
+	err = writeManifest(root, manifest{Repos: repos})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Sort(evicted)
+	return evicted, nil
+}
+
+// Creates the cache root if need be and takes the housekeeping lock in it.
+// Returns a function that releases the lock.
+func lockRoot(root string) (func() error, error) {
+	err := os.MkdirAll(root, 0o700)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(
+		filepath.Join(root, lockFilename),
+		os.O_RDWR|os.O_CREATE,
+		0o644,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = fileutils.Lock(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("could not lock cache: %w", err)
+	}
+
+	return func() error {
+		return errors.Join(fileutils.Unlock(f), f.Close())
+	}, nil
+}
+
+// Deletes all the caches for the repo with the given dir name, unless another
+// process has them open. Returns whether they were deleted.
+func evictRepo(root string, name string) (bool, error) {
+	ok, unlock, err := lockIndexes(
+		filepath.Join(root, backends.IndexBackendName, name),
+	)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		logger().Debug("not evicting cache that is open", "repo", name)
+		return false, nil
+	}
+
+	errs := []error{}
+	for _, kind := range repoCacheKinds {
+		errs = append(errs, os.RemoveAll(filepath.Join(root, kind, name)))
+	}
+	errs = append(errs, unlock())
+
+	err = errors.Join(errs...)
+	if err != nil {
+		logger().Warn(
+			fmt.Sprintf("failed to delete cache for %s: %v", name, err),
+		)
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// Tries to take an exclusive lock on every index file in dir, which fails if
+// another process has one of those caches open. Returns whether we got all the
+// locks and a function that releases them.
+func lockIndexes(dir string) (_ bool, _ func() error, err error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.idx"))
+	if err != nil {
+		return false, nil, err
+	}
+
+	locked := []*os.File{}
+	unlock := func() error {
+		var errs []error
+		for _, f := range locked {
+			errs = append(errs, fileutils.Unlock(f), f.Close())
+		}
+		return errors.Join(errs...)
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Join(err, unlock())
+		}
+	}()
+
+	for _, p := range paths {
+		f, err := os.OpenFile(p, os.O_RDWR, 0)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return false, nil, err
+		}
+
+		ok, err := fileutils.TryLock(f)
+		if err != nil {
+			f.Close()
+			return false, nil, err
+		}
+		if !ok {
+			f.Close()
+			return false, nil, unlock()
+		}
+
+		locked = append(locked, f)
+	}
+
+	return true, unlock, nil
+}
+
+// Reads the limits from the git config, falling back to the defaults.
+func getLimits() Limits {
+	limits := Limits{
+		MaxSize: defaultMaxSize,
+		MaxAge:  defaultMaxAgeDays * 24 * time.Hour,
+	}
+
+	parse := func(s string, err error) (int64, bool) {
+		if err != nil {
+			logger().Warn(fmt.Sprintf("failed to read cache limit: %v", err))
+			return 0, false
+		}
+
+		if len(s) == 0 {
+			return 0, false
+		}
+
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || n < 0 {
+			logger().Warn(fmt.Sprintf("ignoring bad cache limit %q", s))
+			return 0, false
+		}
+
+		return n, true
+	}
+
+	if n, ok := parse(config.CacheMaxSize()); ok {
+		limits.MaxSize = n
+	}
+
+	if n, ok := parse(config.CacheMaxAge()); ok {
+		limits.MaxAge = time.Duration(n) * 24 * time.Hour
+	}
+
+	return limits
+}
+
+// Runs Housekeep() for the repo at gitRootPath with the configured limits.
+//
+// Failing here shouldn't stop us from doing anything else, so we only warn.
+func recordUse(gitRootPath string) {
+	start := time.Now()
+
+	root, err := cacheStorageDir("")
+	if err != nil {
+		logger().Warn(err.Error())
+		return
+	}
+
+	limits := getLimits()
+	evicted, err := Housekeep(root, gitRootPath, time.Now(), limits)
+	if err != nil {
+		logger().Warn(err.Error())
+		return
+	}
+
+	elapsed := time.Now().Sub(start)
+	logger().Debug(
+		"cache housekeeping",
+		"duration_ms",
+		elapsed.Milliseconds(),
+		"maxSize",
+		limits.MaxSize,
+		"maxAge",
+		limits.MaxAge,
+		"evicted",
+		evicted,
+	)
+}
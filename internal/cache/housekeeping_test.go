@@ -0,0 +1,213 @@
+package cache_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sinclairtarget/git-who/internal/cache"
+	"github.com/sinclairtarget/git-who/internal/cache/backends"
+	"github.com/sinclairtarget/git-who/internal/utils/fileutils"
+)
+
+func TestHousekeep(t *testing.T) {
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name     string
+		limits   cache.Limits
+		deleted  []string // Repos that no longer exist
+		written  []string // Repos written to since their use was recorded
+		open     []string // Repos whose caches another process has open
+		expected []string // Repos whose caches are evicted
+	}{
+		{
+			name:     "no_limits",
+			limits:   cache.Limits{},
+			expected: []string{},
+		},
+		{
+			name:     "max_age",
+			limits:   cache.Limits{MaxAge: 35 * day},
+			expected: []string{"one"},
+		},
+		{
+			name:     "max_size",
+			limits:   cache.Limits{MaxSize: 250},
+			expected: []string{"one", "two"},
+		},
+		{
+			name:     "max_size_not_reached",
+			limits:   cache.Limits{MaxSize: 400},
+			expected: []string{},
+		},
+		{
+			name:     "current_repo_over_budget",
+			limits:   cache.Limits{MaxSize: 50},
+			expected: []string{"one", "two", "three"},
+		},
+		{
+			name:     "in_use_too_old",
+			limits:   cache.Limits{MaxAge: 35 * day},
+			written:  []string{"one"},
+			expected: []string{},
+		},
+		{
+			name:     "in_use_over_budget",
+			limits:   cache.Limits{MaxSize: 250},
+			written:  []string{"one"},
+			expected: []string{"two", "three"},
+		},
+		{
+			name:     "open_too_old",
+			limits:   cache.Limits{MaxAge: 35 * day},
+			open:     []string{"one"},
+			expected: []string{},
+		},
+		{
+			name:     "open_over_budget",
+			limits:   cache.Limits{MaxSize: 250},
+			open:     []string{"one"},
+			expected: []string{"two", "three"},
+		},
+		{
+			name:     "repo_deleted",
+			limits:   cache.Limits{},
+			deleted:  []string{"two"},
+			expected: []string{"two"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tmp := t.TempDir()
+			root := filepath.Join(tmp, "git-who")
+
+			repoPath := func(repo string) string {
+				return filepath.Join(tmp, "repos", repo)
+			}
+
+			dirName := func(repo string) string {
+				return filepath.Base(backends.GobCacheDir("", repoPath(repo)))
+			}
+
+			// Each repo has 100 bytes of cache and is used ten days after the
+			// last one
+			repos := []string{"one", "two", "three", "current"}
+			cacheFile := map[string]string{}
+			for i, repo := range repos {
+				used := start.Add(time.Duration(i) * day * 10)
+
+				err := os.MkdirAll(repoPath(repo), 0o700)
+				if err != nil {
+					t.Fatalf("could not create repo dir: %v", err)
+				}
+
+				dir := backends.IndexCacheDir(
+					filepath.Join(root, backends.IndexBackendName),
+					repoPath(repo),
+				)
+				err = os.MkdirAll(dir, 0o700)
+				if err != nil {
+					t.Fatalf("could not create cache dir: %v", err)
+				}
+
+				p := filepath.Join(dir, "abc.idx")
+				err = os.WriteFile(p, make([]byte, 100), 0o644)
+				if err != nil {
+					t.Fatalf("could not create cache file: %v", err)
+				}
+				cacheFile[repo] = p
+
+				for _, f := range []string{p, dir} {
+					err = os.Chtimes(f, used, used)
+					if err != nil {
+						t.Fatalf("could not set cache file time: %v", err)
+					}
+				}
+
+				_, err = cache.Housekeep(
+					root,
+					repoPath(repo),
+					used,
+					cache.Limits{},
+				)
+				if err != nil {
+					t.Fatalf("housekeeping failed with error: %v", err)
+				}
+			}
+
+			for _, repo := range test.deleted {
+				err := os.RemoveAll(repoPath(repo))
+				if err != nil {
+					t.Fatalf("could not delete repo dir: %v", err)
+				}
+			}
+
+			// Say another process is adding to these right now
+			for _, repo := range test.written {
+				written := start.Add(39 * day)
+				err := os.Chtimes(cacheFile[repo], written, written)
+				if err != nil {
+					t.Fatalf("could not set cache file time: %v", err)
+				}
+			}
+
+			// Say another process is reading these right now
+			for _, repo := range test.open {
+				f, err := os.Open(cacheFile[repo])
+				if err != nil {
+					t.Fatalf("could not open cache file: %v", err)
+				}
+				defer f.Close()
+
+				err = fileutils.LockShared(f)
+				if err != nil {
+					t.Fatalf("could not lock cache file: %v", err)
+				}
+			}
+
+			evicted, err := cache.Housekeep(
+				root,
+				repoPath("current"),
+				start.Add(40*day),
+				test.limits,
+			)
+			if err != nil {
+				t.Fatalf("housekeeping failed with error: %v", err)
+			}
+
+			expected := []string{}
+			for _, repo := range test.expected {
+				expected = append(expected, dirName(repo))
+			}
+			slices.Sort(expected)
+
+			if diff := cmp.Diff(expected, evicted); diff != "" {
+				t.Errorf("wrong caches evicted:\n%s", diff)
+			}
+
+			for _, name := range evicted {
+				dir := filepath.Join(root, backends.IndexBackendName, name)
+				_, err := os.Stat(dir)
+				if err == nil {
+					t.Errorf("evicted cache dir %s still exists", name)
+				}
+			}
+
+			_, err = os.Stat(filepath.Join(
+				root,
+				backends.IndexBackendName,
+				dirName("current"),
+			))
+			if err != nil {
+				t.Errorf("cache for current repo was deleted")
+			}
+		})
+	}
+}
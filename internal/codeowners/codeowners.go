@@ -0,0 +1,178 @@
+// Handles the CODEOWNERS file format used by GitHub and GitLab.
+package codeowners
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// A single line in a CODEOWNERS file.
+type Rule struct {
+	Pattern string   // e.g. "/src/core/", with any backslash escapes
+	Owners  []string // Handles or email addresses
+	Section string   // GitLab section, if any
+	Line    int      // Line number in the file the rule was parsed from
+}
+
+// Turns the rule's pattern into globs relative to the repository root. A path
+// matches the rule if it matches any of the globs.
+//
+// A pattern containing a slash anywhere but at the end is anchored to the
+// root. Otherwise it can match at any depth. A pattern ending in a slash
+// matches everything in that directory. Other patterns match everything in a
+// directory too, unless their last part contains a wildcard: "docs/*" only
+// matches the files directly inside docs.
+func (r Rule) globs() []string {
+	p := unescape(r.Pattern)
+
+	anchored := strings.HasPrefix(p, "/") ||
+		strings.Contains(strings.TrimSuffix(p, "/"), "/")
+
+	p = strings.Trim(p, "/")
+	if len(p) == 0 {
+		return []string{"**"} // Just "/", the root directory
+	}
+
+	if !anchored && !strings.HasPrefix(p, "**") {
+		p = "**/" + p
+	}
+
+	if strings.HasSuffix(r.Pattern, "/") {
+		return []string{p + "/**"}
+	}
+
+	if strings.ContainsAny(path.Base(p), "*?[") {
+		return []string{p}
+	}
+
+	return []string{p, p + "/**"}
+}
+
+// Whether the rule applies to the given path, which should be relative to the
+// repository root.
+func (r Rule) Matches(p string) bool {
+	for _, glob := range r.globs() {
+		// Patterns were validated when parsed, so there can't be an error
+		if didMatch, _ := doublestar.Match(glob, p); didMatch {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r Rule) validate() error {
+	for _, glob := range r.globs() {
+		if !doublestar.ValidatePattern(glob) {
+			return fmt.Errorf("invalid pattern: %s", r.Pattern)
+		}
+	}
+
+	return nil
+}
+
+// Returns the rules that apply to the given path.
+//
+// Within each section, the last matching rule wins. There is at most one rule
+// per section, and there is only one section in files using GitHub syntax.
+func (f File) Match(p string) []Rule {
+	bySection := map[string]Rule{}
+	sections := []string{}
+
+	for _, rule := range f.Rules {
+		if !rule.Matches(p) {
+			continue
+		}
+
+		if _, ok := bySection[rule.Section]; !ok {
+			sections = append(sections, rule.Section)
+		}
+		bySection[rule.Section] = rule
+	}
+
+	rules := []Rule{}
+	for _, section := range sections {
+		rules = append(rules, bySection[section])
+	}
+
+	return rules
+}
+
+// Returns a pattern matching everything under the given directory.
+//
+// The directory should be relative to the repository root and use forward
+// slashes. The root directory itself becomes "*".
+func DirPattern(dir string) string {
+	dir = path.Clean(dir)
+	if dir == "." {
+		return "*"
+	}
+
+	return "/" + escape(dir) + "/"
+}
+
+// Backslash-escapes characters that would otherwise end the pattern or start
+// a comment.
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case ' ', '\t', '#', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+func unescape(s string) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range s {
+		if r == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+
+		escaped = false
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// Writes out the rules in CODEOWNERS syntax, preceded by a comment header.
+func Write(w io.Writer, header []string, rules []Rule) error {
+	bw := bufio.NewWriter(w)
+
+	for _, line := range header {
+		fmt.Fprintf(bw, "# %s\n", line)
+	}
+
+	if len(header) > 0 && len(rules) > 0 {
+		fmt.Fprintln(bw)
+	}
+
+	width := 0
+	for _, rule := range rules {
+		width = max(width, len(rule.Pattern))
+	}
+
+	for _, rule := range rules {
+		fmt.Fprintf(
+			bw,
+			"%-*s %s\n",
+			width,
+			rule.Pattern,
+			strings.Join(rule.Owners, " "),
+		)
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,98 @@
+package codeowners_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sinclairtarget/git-who/internal/codeowners"
+)
+
+func TestParseHandles(t *testing.T) {
+	input := `
+# A comment
+alice@example.com = @alice
+Bob Jones = @bjones
+Carol Smith <Carol@Example.com> = @example/carol-team
+`
+
+	handles, err := codeowners.ParseHandles(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseHandles() returned error: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		aname string
+		email string
+		exp   string
+	}{
+		{
+			name:  "by_email",
+			aname: "Alice",
+			email: "alice@example.com",
+			exp:   "@alice",
+		},
+		{
+			name:  "by_name",
+			aname: "Bob Jones",
+			email: "bob@example.com",
+			exp:   "@bjones",
+		},
+		{
+			name:  "email_ignores_case",
+			aname: "Carol",
+			email: "carol@example.com",
+			exp:   "@example/carol-team",
+		},
+		{
+			name:  "falls_back_to_email",
+			aname: "Dave",
+			email: "dave@example.com",
+			exp:   "dave@example.com",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handle := handles.Lookup(test.aname, test.email)
+			if handle != test.exp {
+				t.Errorf("expected \"%s\", but got: \"%s\"", test.exp, handle)
+			}
+		})
+	}
+}
+
+func TestParseHandlesInvalid(t *testing.T) {
+	inputs := []string{
+		"alice@example.com @alice",
+		"alice@example.com =",
+		"= @alice",
+		"alice@example.com = @alice @bob",
+	}
+
+	for _, input := range inputs {
+		_, err := codeowners.ParseHandles(strings.NewReader(input))
+		if err == nil {
+			t.Errorf("expected error parsing \"%s\"", input)
+		}
+	}
+}
+
+func TestDirPattern(t *testing.T) {
+	tests := []struct {
+		dir string
+		exp string
+	}{
+		{dir: ".", exp: "*"},
+		{dir: "src", exp: "/src/"},
+		{dir: "src/core/", exp: "/src/core/"},
+		{dir: "my docs", exp: "/my\\ docs/"},
+	}
+
+	for _, test := range tests {
+		pattern := codeowners.DirPattern(test.dir)
+		if pattern != test.exp {
+			t.Errorf("expected \"%s\", but got: \"%s\"", test.exp, pattern)
+		}
+	}
+}
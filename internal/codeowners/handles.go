@@ -0,0 +1,135 @@
+package codeowners
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Maps Git authors to GitHub or GitLab handles.
+//
+// A handles file has one mapping per line, an author and a handle separated by
+// "=". The author can be given as an email address, a name, or both in the
+// usual "Name <email>" form. Lines starting with "#" are comments:
+//
+//	# Handles for git-who codeowners
+//	alice@example.com = @alice
+//	Bob Jones = @bjones
+//	Carol Smith <carol@example.com> = @example/carol-team
+type Handles struct {
+	byEmail map[string]string
+	byName  map[string]string
+}
+
+func ParseHandles(r io.Reader) (Handles, error) {
+	handles := Handles{
+		byEmail: map[string]string{},
+		byName:  map[string]string{},
+	}
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum += 1
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		author, handle, found := strings.Cut(line, "=")
+		author = strings.TrimSpace(author)
+		handle = strings.TrimSpace(handle)
+		if !found || author == "" || handle == "" {
+			return handles, fmt.Errorf(
+				"line %d: expected \"<author> = <handle>\" but got: %s",
+				lineNum,
+				line,
+			)
+		}
+
+		if strings.ContainsAny(handle, " \t") {
+			return handles, fmt.Errorf(
+				"line %d: handle cannot contain whitespace: %s",
+				lineNum,
+				handle,
+			)
+		}
+
+		name, email := splitAuthor(author)
+		if email != "" {
+			handles.byEmail[strings.ToLower(email)] = handle
+		} else {
+			handles.byName[name] = handle
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return handles, err
+	}
+
+	return handles, nil
+}
+
+func ReadHandlesFile(path string) (_ Handles, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error reading handles file: %w", err)
+		}
+	}()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return Handles{}, err
+	}
+	defer f.Close()
+
+	return ParseHandles(f)
+}
+
+// Splits "Name <email>", "email", or "Name" into name and email.
+func splitAuthor(author string) (name string, email string) {
+	before, after, found := strings.Cut(author, "<")
+	if found {
+		return strings.TrimSpace(before), strings.TrimSuffix(after, ">")
+	}
+
+	if strings.Contains(author, "@") {
+		return "", author
+	}
+
+	return author, ""
+}
+
+// Whether any author is mapped to the given handle. Handles are compared
+// case-insensitively, the same way GitHub and GitLab compare them.
+func (h Handles) Contains(handle string) bool {
+	for _, m := range []map[string]string{h.byEmail, h.byName} {
+		for _, v := range m {
+			if strings.EqualFold(v, handle) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Returns the handle for the given author.
+//
+// Email matches take precedence over name matches. Email addresses are valid
+// owners in CODEOWNERS files, so an author without a handle falls back to their
+// email.
+func (h Handles) Lookup(name string, email string) string {
+	if handle, ok := h.byEmail[strings.ToLower(email)]; ok {
+		return handle
+	}
+
+	if handle, ok := h.byName[name]; ok {
+		return handle
+	}
+
+	return email
+}
@@ -0,0 +1,155 @@
+package codeowners
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Where GitHub and GitLab look for a CODEOWNERS file, in the order they look.
+var conventionalPaths = []string{
+	".github/CODEOWNERS",
+	".gitlab/CODEOWNERS",
+	"CODEOWNERS",
+	"docs/CODEOWNERS",
+}
+
+// GitLab section headers look like "[Section]", "^[Optional Section]", or
+// "[Section][2]", optionally followed by default owners.
+var sectionRegexp = regexp.MustCompile(`^\^?\[([^\]]+)\](?:\[\d+\])?(.*)$`)
+
+// A parsed CODEOWNERS file.
+type File struct {
+	Rules []Rule
+}
+
+// Parses a CODEOWNERS file in either GitHub or GitLab syntax.
+//
+// In GitLab syntax, rules with no owners get the default owners of their
+// section, if the section has any.
+func Parse(r io.Reader) (File, error) {
+	var file File
+
+	section := ""
+	sectionOwners := []string{}
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum += 1
+
+		line := strings.TrimSpace(scanner.Text())
+		if match := sectionRegexp.FindStringSubmatch(line); match != nil {
+			section = match[1]
+			sectionOwners = splitFields(match[2])
+			continue
+		}
+
+		fields := splitFields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		owners := fields[1:]
+		if len(owners) == 0 {
+			owners = sectionOwners
+		}
+
+		rule := Rule{
+			Pattern: fields[0],
+			Owners:  owners,
+			Section: section,
+			Line:    lineNum,
+		}
+		if err := rule.validate(); err != nil {
+			return file, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+
+		file.Rules = append(file.Rules, rule)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return file, err
+	}
+
+	return file, nil
+}
+
+func ReadFile(path string) (_ File, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error reading CODEOWNERS file: %w", err)
+		}
+	}()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return File{}, err
+	}
+	defer f.Close()
+
+	return Parse(f)
+}
+
+// Returns the path to the repository's CODEOWNERS file, looking in the same
+// places GitHub and GitLab do.
+func Find(gitRootPath string) (string, error) {
+	for _, p := range conventionalPaths {
+		fullPath := filepath.Join(gitRootPath, filepath.FromSlash(p))
+
+		_, err := os.Stat(fullPath)
+		if err == nil {
+			return fullPath, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+	}
+
+	return "", errors.New("could not find a CODEOWNERS file in repository")
+}
+
+// Splits a line into whitespace-separated fields, respecting backslash escapes
+// and dropping anything after an unescaped "#".
+func splitFields(line string) []string {
+	fields := []string{}
+
+	var field strings.Builder
+	escaped := false
+	for _, r := range line {
+		if escaped {
+			field.WriteRune('\\')
+			field.WriteRune(r)
+			escaped = false
+			continue
+		}
+
+		switch r {
+		case '\\':
+			escaped = true
+		case ' ', '\t':
+			if field.Len() > 0 {
+				fields = append(fields, field.String())
+				field.Reset()
+			}
+		case '#':
+			if field.Len() == 0 {
+				return fields // Rest of line is a comment
+			}
+			field.WriteRune(r)
+		default:
+			field.WriteRune(r)
+		}
+	}
+
+	if field.Len() > 0 {
+		fields = append(fields, field.String())
+	}
+
+	return fields
+}
@@ -0,0 +1,180 @@
+package codeowners_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sinclairtarget/git-who/internal/codeowners"
+)
+
+const codeownersFile = `
+# Default owners
+*                @alice
+/docs/           @bob docs@example.com # Inline comment
+*.go             @carol
+/my\ dir/        @dave
+
+[Frontend] @frontend-team
+/web/
+/web/legacy/     @erin
+
+^[Optional][2]
+web/             @frank
+`
+
+func TestParse(t *testing.T) {
+	file, err := codeowners.Parse(strings.NewReader(codeownersFile))
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+
+	expected := []codeowners.Rule{
+		{Pattern: "*", Owners: []string{"@alice"}, Line: 3},
+		{
+			Pattern: "/docs/",
+			Owners:  []string{"@bob", "docs@example.com"},
+			Line:    4,
+		},
+		{Pattern: "*.go", Owners: []string{"@carol"}, Line: 5},
+		{Pattern: "/my\\ dir/", Owners: []string{"@dave"}, Line: 6},
+		{
+			Pattern: "/web/",
+			Owners:  []string{"@frontend-team"},
+			Section: "Frontend",
+			Line:    9,
+		},
+		{
+			Pattern: "/web/legacy/",
+			Owners:  []string{"@erin"},
+			Section: "Frontend",
+			Line:    10,
+		},
+		{
+			Pattern: "web/",
+			Owners:  []string{"@frank"},
+			Section: "Optional",
+			Line:    13,
+		},
+	}
+
+	if diff := cmp.Diff(expected, file.Rules); diff != "" {
+		t.Errorf("parsed rules are wrong:\n%s", diff)
+	}
+}
+
+func TestFileMatch(t *testing.T) {
+	file, err := codeowners.Parse(strings.NewReader(codeownersFile))
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		path  string
+		lines []int
+	}{
+		{
+			name:  "root_file",
+			path:  "README.md",
+			lines: []int{3},
+		},
+		{
+			name:  "anchored_dir",
+			path:  "docs/guide/intro.md",
+			lines: []int{4},
+		},
+		{
+			name:  "extension_at_any_depth",
+			path:  "docs/main.go",
+			lines: []int{5},
+		},
+		{
+			name:  "escaped_space",
+			path:  "my dir/notes.txt",
+			lines: []int{6},
+		},
+		{
+			name:  "sections",
+			path:  "web/index.html",
+			lines: []int{3, 9, 13},
+		},
+		{
+			name:  "last_match_in_section_wins",
+			path:  "web/legacy/app.js",
+			lines: []int{3, 10, 13},
+		},
+		{
+			name:  "unanchored_dir_at_any_depth",
+			path:  "src/web/app.js",
+			lines: []int{3, 13},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lines := []int{}
+			for _, rule := range file.Match(test.path) {
+				lines = append(lines, rule.Line)
+			}
+
+			if diff := cmp.Diff(test.lines, lines); diff != "" {
+				t.Errorf("wrong rules matched:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRuleMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		exp     bool
+	}{
+		{pattern: "/", path: "src/main.go", exp: true},
+		{pattern: "docs/*", path: "docs/intro.md", exp: true},
+		{pattern: "docs/*", path: "docs/guide/intro.md", exp: false},
+		{pattern: "docs/*.md", path: "docs/guide/intro.md", exp: false},
+		{pattern: "/docs", path: "docs/guide/intro.md", exp: true},
+		{pattern: "/docs", path: "src/docs/intro.md", exp: false},
+		{pattern: "docs/", path: "src/docs/intro.md", exp: true},
+		{pattern: "**/logs", path: "build/logs/out.txt", exp: true},
+		{pattern: "*.go", path: "src/core/main.go", exp: true},
+		{pattern: "/build/logs/", path: "build/logs/deep/out.txt", exp: true},
+		{pattern: "/build/logs/", path: "src/build/logs/out.txt", exp: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern+" "+test.path, func(t *testing.T) {
+			rule := codeowners.Rule{Pattern: test.pattern}
+			if rule.Matches(test.path) != test.exp {
+				t.Errorf(
+					"expected match of \"%s\" against \"%s\" to be %v",
+					test.pattern,
+					test.path,
+					test.exp,
+				)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		input string
+		line  string
+	}{
+		{input: "src/[ @alice", line: "line 1"},
+		{input: "*.go @alice\n{a,b @bob", line: "line 2"},
+	}
+
+	for _, test := range tests {
+		_, err := codeowners.Parse(strings.NewReader(test.input))
+		if err == nil {
+			t.Errorf("expected error parsing \"%s\"", test.input)
+		} else if !strings.Contains(err.Error(), test.line) {
+			t.Errorf("expected error to mention %s, but got: %v", test.line, err)
+		}
+	}
+}
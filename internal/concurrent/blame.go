@@ -0,0 +1,201 @@
+package concurrent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/sinclairtarget/git-who/internal/cache"
+	"github.com/sinclairtarget/git-who/internal/format"
+	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/git/config"
+	"github.com/sinclairtarget/git-who/internal/pretty"
+	"github.com/sinclairtarget/git-who/internal/tally"
+)
+
+// A blame worker that runs git blame for each file it is sent.
+func runBlameWorker(
+	ctx context.Context,
+	rev string,
+	gitRootPath string,
+	blobs map[string]string,
+	ignoreRevsPath string,
+	in <-chan string,
+	results chan<- git.FileBlame,
+) error {
+	for path := range in {
+		blame, err := git.Blame(
+			ctx,
+			rev,
+			gitRootPath,
+			path,
+			blobs[path],
+			ignoreRevsPath,
+		)
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.New("blame worker cancelled")
+		case results <- blame:
+		}
+	}
+
+	return nil
+}
+
+/*
+* TallyBlames() runs git blame on each of the given files (a map of path to
+* blob hash) as of the given revision, spreading the work over all our CPUs,
+* and tallies the surviving lines.
+*
+* Files whose blob hash is already in the cache are not blamed again. The cache
+* stores authors as they appear in each commit; we apply the mailmap when
+* tallying.
+ */
+func TallyBlames(
+	ctx context.Context,
+	rev string,
+	blobs map[string]string,
+	gitRootPath string,
+	configFiles config.SupplementalFiles,
+	opts tally.TallyOpts,
+	blameCache *cache.BlameCache,
+	allowProgressBar bool,
+) (_ tally.TalliesByPath, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error running concurrent blame: %w", err)
+		}
+	}()
+
+	err = blameCache.Open()
+	if err != nil {
+		logger().Warn(fmt.Sprintf(
+			"error reading from blame cache (maybe corrupt?): %v",
+			err,
+		))
+		logger().Warn("wiping blame cache and moving on")
+
+		err = blameCache.Clear()
+		if err != nil {
+			return nil, err
+		}
+	}
+	defer func() {
+		err = errors.Join(err, blameCache.Close())
+	}()
+
+	mm, err := configFiles.Mailmap()
+	if err != nil {
+		return nil, err
+	}
+
+	blames := []git.FileBlame{}
+	remaining := []string{}
+	for _, path := range slices.Sorted(maps.Keys(blobs)) {
+		if blame, ok := blameCache.Get(path, blobs[path]); ok {
+			blames = append(blames, blame)
+		} else {
+			remaining = append(remaining, path)
+		}
+	}
+
+	logger().Debug(
+		"running concurrent blame",
+		"cached",
+		len(blames),
+		"remaining",
+		len(remaining),
+		"nCPU",
+		nCPU,
+	)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	q := make(chan string)
+	go func() {
+		defer close(q)
+
+		for _, path := range remaining {
+			select {
+			case <-ctx.Done():
+				return
+			case q <- path:
+			}
+		}
+	}()
+
+	results := make(chan git.FileBlame)
+	errs := make(chan error, nCPU)
+	nWorkers := min(nCPU, len(remaining))
+	for _ = range nWorkers {
+		go func() {
+			errs <- runBlameWorker(
+				ctx,
+				rev,
+				gitRootPath,
+				blobs,
+				configFiles.IgnoreRevsPath,
+				q,
+				results,
+			)
+		}()
+	}
+
+	showProgress := allowProgressBar && len(remaining) > nCPU
+	if showProgress {
+		fmt.Printf("  0%% (0/%s files)", format.Number(len(remaining)))
+	}
+
+	fresh := []git.FileBlame{}
+	for nWorkers > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, errors.New("concurrent blame cancelled")
+		case blame := <-results:
+			fresh = append(fresh, blame)
+
+			if showProgress {
+				done := len(fresh)
+				fmt.Printf("%s\r", pretty.EraseLine)
+				fmt.Printf(
+					"%3.0f%% (%s/%s files)",
+					float32(done)/float32(len(remaining))*100,
+					format.Number(done),
+					format.Number(len(remaining)),
+				)
+			}
+		case err := <-errs:
+			if err != nil {
+				return nil, err
+			}
+
+			nWorkers -= 1
+		}
+	}
+
+	if showProgress {
+		fmt.Printf("%s\r", pretty.EraseLine)
+	}
+
+	err = git.UseRawBlameAuthors(ctx, fresh)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, blame := range fresh {
+		blameCache.Add(blame)
+		blames = append(blames, blame)
+	}
+
+	return tally.TallyBlames(
+		git.MapBlameIdentities(slices.Values(blames), mm),
+		opts,
+	)
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/sinclairtarget/git-who/internal/git"
 	"github.com/sinclairtarget/git-who/internal/git/cmd"
 	"github.com/sinclairtarget/git-who/internal/git/config"
+	"github.com/sinclairtarget/git-who/internal/git/mailmap"
 	"github.com/sinclairtarget/git-who/internal/pretty"
 	"github.com/sinclairtarget/git-who/internal/tally"
 )
@@ -59,7 +60,8 @@ type whoperation[T combinable[T]] struct {
 	revspec    []string
 	pathspecs  []string
 	filters    cmd.LogFilters
-	useMailmap bool
+	mailmap    mailmap.Mailmap   // Applied to commits as we tally them
+	renames    git.RenameHistory // Followed as we tally; empty to not follow
 	ignoreRevs []string
 	tally      tallyFunc[T]
 	opts       tally.TallyOpts
@@ -100,13 +102,24 @@ func accumulateCached[T combinable[T]](
 	var none T
 
 	commits, finish := c.Get(revs)
-	commits, err := git.LimitDiffsByPathspec(commits, whop.pathspecs)
+
+	// Commits filtered out below were still found
+	foundRevs := []string{}
+	commits = revTee(commits, &foundRevs)
+
+	commits = git.MapIdentities(commits, whop.mailmap)
+	commits, err := git.FilterAuthors(commits, whop.filters)
 	if err != nil {
 		return none, revs, err
 	}
 
-	foundRevs := []string{}
-	accumulator, err := whop.tally(revTee(commits, &foundRevs), whop.opts)
+	commits, err = git.LimitDiffsByPathspec(commits, whop.pathspecs)
+	if err != nil {
+		return none, revs, err
+	}
+	commits = tally.FollowRenames(commits, whop.renames)
+
+	accumulator, err := whop.tally(commits, whop.opts)
 	if err != nil {
 		return none, revs, err
 	}
@@ -345,11 +358,16 @@ func TallyCommits(
 		return nil, err
 	}
 
+	mm, err := configFiles.Mailmap()
+	if err != nil {
+		return nil, err
+	}
+
 	whop := whoperation[tally.TalliesByPath]{
 		revspec:    revspec,
 		pathspecs:  pathspecs,
 		filters:    filters,
-		useMailmap: configFiles.HasMailmap(),
+		mailmap:    mm,
 		ignoreRevs: ignoreRevs,
 		tally:      tally.TallyCommitsByPath,
 		opts:       opts,
@@ -368,6 +386,46 @@ func TallyCommits(
 	return talliesByPath.Reduce(), nil
 }
 
+func TallyCommitsByPath(
+	ctx context.Context,
+	revspec []string,
+	pathspecs []string,
+	filters cmd.LogFilters,
+	configFiles config.SupplementalFiles,
+	opts tally.TallyOpts,
+	renames git.RenameHistory,
+	cache cache.Cache,
+	allowProgressBar bool,
+) (tally.TalliesByPath, error) {
+	ignoreRevs, err := configFiles.IgnoreRevs()
+	if err != nil {
+		return nil, err
+	}
+
+	mm, err := configFiles.Mailmap()
+	if err != nil {
+		return nil, err
+	}
+
+	whop := whoperation[tally.TalliesByPath]{
+		revspec:    revspec,
+		pathspecs:  pathspecs,
+		filters:    filters,
+		mailmap:    mm,
+		renames:    renames,
+		ignoreRevs: ignoreRevs,
+		tally:      tally.TallyCommitsByPath,
+		opts:       opts,
+	}
+
+	return tallyFanOutFanIn[tally.TalliesByPath](
+		ctx,
+		whop,
+		cache,
+		allowProgressBar,
+	)
+}
+
 func TallyCommitsTree(
 	ctx context.Context,
 	revspec []string,
@@ -377,6 +435,7 @@ func TallyCommitsTree(
 	opts tally.TallyOpts,
 	worktreePaths map[string]bool,
 	gitRootPath string,
+	renames git.RenameHistory,
 	cache cache.Cache,
 	allowProgressBar bool,
 ) (*tally.TreeNode, error) {
@@ -385,11 +444,17 @@ func TallyCommitsTree(
 		return nil, err
 	}
 
+	mm, err := configFiles.Mailmap()
+	if err != nil {
+		return nil, err
+	}
+
 	whop := whoperation[tally.TalliesByPath]{
 		revspec:    revspec,
 		pathspecs:  pathspecs,
 		filters:    filters,
-		useMailmap: configFiles.HasMailmap(),
+		mailmap:    mm,
+		renames:    renames,
 		ignoreRevs: ignoreRevs,
 		tally:      tally.TallyCommitsByPath,
 		opts:       opts,
@@ -428,6 +493,11 @@ func TallyCommitsTimeline(
 		return nil, err
 	}
 
+	mm, err := configFiles.Mailmap()
+	if err != nil {
+		return nil, err
+	}
+
 	f := func(
 		commits iter.Seq[git.Commit],
 		opts tally.TallyOpts,
@@ -439,7 +509,7 @@ func TallyCommitsTimeline(
 		revspec:    revspec,
 		pathspecs:  pathspecs,
 		filters:    filters,
-		useMailmap: configFiles.HasMailmap(),
+		mailmap:    mm,
 		ignoreRevs: ignoreRevs,
 		tally:      f,
 		opts:       opts,
@@ -462,3 +532,58 @@ func TallyCommitsTimeline(
 	rebuckets := tally.Rebucket(buckets, resolution, end)
 	return rebuckets, nil
 }
+
+// Number of commits seen, for when we only want to fill the cache.
+type commitCount int
+
+func (n commitCount) Combine(other commitCount) commitCount {
+	return n + other
+}
+
+// Parses every commit in the given revs and adds it to the cache without
+// tallying anything. Returns the number of commits, cached or not.
+func WarmCache(
+	ctx context.Context,
+	revspec []string,
+	pathspecs []string,
+	configFiles config.SupplementalFiles,
+	cache cache.Cache,
+	allowProgressBar bool,
+) (int, error) {
+	ignoreRevs, err := configFiles.IgnoreRevs()
+	if err != nil {
+		return 0, err
+	}
+
+	mm, err := configFiles.Mailmap()
+	if err != nil {
+		return 0, err
+	}
+
+	count := func(
+		commits iter.Seq[git.Commit],
+		opts tally.TallyOpts,
+	) (commitCount, error) {
+		n := 0
+		for range commits {
+			n += 1
+		}
+
+		return commitCount(n), nil
+	}
+
+	whop := whoperation[commitCount]{
+		revspec:    revspec,
+		pathspecs:  pathspecs,
+		mailmap:    mm,
+		ignoreRevs: ignoreRevs,
+		tally:      count,
+	}
+
+	n, err := tallyFanOutFanIn[commitCount](ctx, whop, cache, allowProgressBar)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
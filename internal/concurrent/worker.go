@@ -8,6 +8,7 @@ import (
 	"github.com/sinclairtarget/git-who/internal/cache"
 	"github.com/sinclairtarget/git-who/internal/git"
 	"github.com/sinclairtarget/git-who/internal/git/cmd"
+	"github.com/sinclairtarget/git-who/internal/tally"
 )
 
 type worker struct {
@@ -188,12 +189,7 @@ loop:
 			// commit. Otherwise when we cache the commits we would be caching
 			// only a part of the commit
 			nopaths := []string{}
-			subprocess, err := cmd.RunStdinLog(
-				ctx,
-				nopaths,
-				true,
-				whop.useMailmap,
-			)
+			subprocess, err := cmd.RunStdinLog(ctx, nopaths, true)
 			if err != nil {
 				return err
 			}
@@ -221,7 +217,14 @@ loop:
 				commits, finish := git.ParseCommits(lines)
 				defer func() { err = errors.Join(err, finish()) }()
 
+				// Cache identities as they appear in each commit, so that the
+				// cache stays valid when the mailmap changes
 				commits = cacheTee(commits, toCache)
+				commits = git.MapIdentities(commits, whop.mailmap)
+				commits, err = git.FilterAuthors(commits, whop.filters)
+				if err != nil {
+					return result, err
+				}
 
 				// Now that we're tallying, we DO care to only look at the file
 				// diffs under the given paths
@@ -229,6 +232,7 @@ loop:
 				if err != nil {
 					return result, err
 				}
+				commits = tally.FollowRenames(commits, whop.renames)
 
 				return whop.tally(commits, whop.opts)
 			}()
@@ -5,6 +5,7 @@ package format
 
 import (
 	"fmt"
+	"math"
 	"time"
 	"unicode/utf8"
 
@@ -104,3 +105,54 @@ func Number(num int) string {
 
 	return fmt.Sprintf("%d", num)
 }
+
+// Formats a fractional score, keeping one decimal place for small scores
+func Score(score float64) string {
+	if score < 0 {
+		panic("cannot format negative score")
+	}
+
+	if score > 0 && score < 0.05 {
+		return "<0.1" // Would otherwise round to zero
+	}
+
+	if score < 100 {
+		return fmt.Sprintf("%.1f", score)
+	}
+
+	return Number(int(math.Round(score)))
+}
+
+// Formats a fraction between 0 and 1 as a whole-number percentage
+func Percent(frac float64) string {
+	if frac < 0 {
+		panic("cannot format negative fraction")
+	}
+
+	if frac > 0 && frac < 0.005 {
+		return "<1%" // Would otherwise round to zero
+	}
+
+	return fmt.Sprintf("%.0f%%", frac*100)
+}
+
+// Formats a size in bytes using binary units
+func Bytes(n int64) string {
+	if n < 0 {
+		panic("cannot format negative size")
+	}
+
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	size := float64(n)
+	for _, unit := range []string{"KiB", "MiB", "GiB"} {
+		size /= 1024
+		if size < 1024 {
+			return fmt.Sprintf("%.1f %s", size, unit)
+		}
+	}
+
+	return fmt.Sprintf("%.1f TiB", size/1024)
+}
@@ -91,3 +91,137 @@ func TestNumberNegativeError(t *testing.T) {
 
 	format.Number(-1)
 }
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name  string
+		score float64
+		exp   string
+	}{
+		{
+			name:  "zero",
+			score: 0,
+			exp:   "0.0",
+		},
+		{
+			name:  "tiny",
+			score: 0.0001,
+			exp:   "<0.1",
+		},
+		{
+			name:  "fraction",
+			score: 0.26,
+			exp:   "0.3",
+		},
+		{
+			name:  "tens",
+			score: 42.04,
+			exp:   "42.0",
+		},
+		{
+			name:  "hundreds",
+			score: 123.5,
+			exp:   "124",
+		},
+		{
+			name:  "thousands",
+			score: 4321.2,
+			exp:   "4,321",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ans := format.Score(test.score)
+			if ans != test.exp {
+				t.Errorf("expected %s but got %s", test.exp, ans)
+			}
+		})
+	}
+}
+
+func TestPercent(t *testing.T) {
+	tests := []struct {
+		name string
+		frac float64
+		exp  string
+	}{
+		{
+			name: "zero",
+			frac: 0,
+			exp:  "0%",
+		},
+		{
+			name: "tiny",
+			frac: 0.001,
+			exp:  "<1%",
+		},
+		{
+			name: "half",
+			frac: 0.516,
+			exp:  "52%",
+		},
+		{
+			name: "whole",
+			frac: 1,
+			exp:  "100%",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ans := format.Percent(test.frac)
+			if ans != test.exp {
+				t.Errorf("expected %s but got %s", test.exp, ans)
+			}
+		})
+	}
+}
+
+func TestBytes(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int64
+		exp  string
+	}{
+		{
+			name: "zero",
+			n:    0,
+			exp:  "0 B",
+		},
+		{
+			name: "bytes",
+			n:    1023,
+			exp:  "1023 B",
+		},
+		{
+			name: "kibibytes",
+			n:    1536,
+			exp:  "1.5 KiB",
+		},
+		{
+			name: "mebibytes",
+			n:    64 * 1024 * 1024,
+			exp:  "64.0 MiB",
+		},
+		{
+			name: "gibibytes",
+			n:    3 * 1024 * 1024 * 1024,
+			exp:  "3.0 GiB",
+		},
+		{
+			name: "tebibytes",
+			n:    2048 * 1024 * 1024 * 1024,
+			exp:  "2.0 TiB",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ans := format.Bytes(test.n)
+			if ans != test.exp {
+				t.Errorf("expected %s but got %s", test.exp, ans)
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sinclairtarget/git-who/internal/git/cmd"
+	"github.com/sinclairtarget/git-who/internal/git/mailmap"
+)
+
+// The lines in a file that git blame attributes to a single commit.
+type BlameCommit struct {
+	Hash        string
+	AuthorName  string
+	AuthorEmail string
+	Date        time.Time
+	Lines       int
+}
+
+// The result of running git blame on a file.
+type FileBlame struct {
+	Path    string // Relative to the repository root
+	Blob    string // Hash of the file contents that were blamed
+	Commits []BlameCommit
+}
+
+// Parses the output of git blame --incremental.
+//
+// Each hunk starts with a line giving the commit, the line numbers, and the
+// number of lines in the hunk. Information about the commit follows the first
+// time the commit appears. Every hunk ends with a "filename" line.
+func ParseBlame(lines iter.Seq[string]) (_ []BlameCommit, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error parsing blame: %w", err)
+		}
+	}()
+
+	commits := []BlameCommit{}
+	indices := map[string]int{} // Hash -> index in commits
+
+	current := -1 // Index of the commit for the hunk we're in, if any
+	for line := range lines {
+		if current < 0 {
+			fields := strings.Fields(line)
+			if len(fields) != 4 {
+				return nil, fmt.Errorf("expected hunk header but got: %s", line)
+			}
+
+			n, err := strconv.Atoi(fields[3])
+			if err != nil {
+				return nil, fmt.Errorf("bad line count in hunk header: %w", err)
+			}
+
+			i, ok := indices[fields[0]]
+			if !ok {
+				i = len(commits)
+				indices[fields[0]] = i
+				commits = append(commits, BlameCommit{Hash: fields[0]})
+			}
+
+			current = i
+			commits[i].Lines += n
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "author":
+			commits[current].AuthorName = value
+		case "author-mail":
+			commits[current].AuthorEmail = strings.Trim(value, "<>")
+		case "author-time":
+			i, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"error parsing date from commit %s: %w",
+					commits[current].Hash,
+					err,
+				)
+			}
+
+			commits[current].Date = time.Unix(int64(i), 0)
+		case "filename":
+			current = -1 // End of hunk
+		}
+	}
+
+	if current >= 0 {
+		return nil, errors.New("blame output ended in the middle of a hunk")
+	}
+
+	return commits, nil
+}
+
+// Runs git blame on the file at the given path (relative to the repository
+// root) as of the given revision.
+func Blame(
+	ctx context.Context,
+	rev string,
+	gitRootPath string,
+	path string,
+	blob string,
+	ignoreRevsPath string,
+) (_ FileBlame, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error blaming %s: %w", path, err)
+		}
+	}()
+
+	fileBlame := FileBlame{Path: path, Blob: blob}
+
+	subprocess, err := cmd.RunBlame(
+		ctx,
+		rev,
+		filepath.Join(gitRootPath, filepath.FromSlash(path)),
+		ignoreRevsPath,
+	)
+	if err != nil {
+		return fileBlame, err
+	}
+
+	lines, finish := subprocess.StdoutLines()
+	commits, err := ParseBlame(lines)
+	if err != nil {
+		return fileBlame, err
+	}
+
+	err = finish()
+	if err != nil {
+		return fileBlame, err
+	}
+
+	err = subprocess.Wait()
+	if err != nil {
+		return fileBlame, err
+	}
+
+	fileBlame.Commits = commits
+	return fileBlame, nil
+}
+
+/*
+* UseRawBlameAuthors() replaces the author of each blamed commit with the
+* author as it appears in the commit itself.
+*
+* git blame always applies the mailmap. We want identities that stay valid in
+* the blame cache when the mailmap changes, so instead we look up each commit
+* and apply the mailmap ourselves (see MapBlameIdentities()).
+ */
+func UseRawBlameAuthors(ctx context.Context, blames []FileBlame) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error getting blame authors: %w", err)
+		}
+	}()
+
+	hashes := map[string]bool{}
+	for _, blame := range blames {
+		for _, bc := range blame.Commits {
+			hashes[bc.Hash] = true
+		}
+	}
+
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	nopaths := []string{}
+	subprocess, err := cmd.RunStdinLog(ctx, nopaths, false)
+	if err != nil {
+		return err
+	}
+
+	w, stdinCloser := subprocess.StdinWriter()
+	for hash := range hashes {
+		fmt.Fprintln(w, hash)
+	}
+	w.Flush()
+
+	err = stdinCloser()
+	if err != nil {
+		return err
+	}
+
+	lines, finishLines := subprocess.StdoutNullDelimitedLines()
+	commits, finishCommits := ParseCommits(lines)
+
+	authors := map[string]Commit{}
+	for commit := range commits {
+		authors[commit.Hash] = commit
+	}
+
+	err = errors.Join(finishCommits(), finishLines())
+	if err != nil {
+		return err
+	}
+
+	err = subprocess.Wait()
+	if err != nil {
+		return err
+	}
+
+	for _, blame := range blames {
+		for i, bc := range blame.Commits {
+			commit, ok := authors[bc.Hash]
+			if !ok {
+				continue // Keep what git blame told us
+			}
+
+			blame.Commits[i].AuthorName = commit.AuthorName
+			blame.Commits[i].AuthorEmail = commit.AuthorEmail
+		}
+	}
+
+	return nil
+}
+
+// Applies the mailmap to the author of each blamed commit.
+//
+// Yields mapped copies, so that we never modify blames someone else (like the
+// cache) might be holding on to.
+func MapBlameIdentities(
+	blames iter.Seq[FileBlame],
+	mm mailmap.Mailmap,
+) iter.Seq[FileBlame] {
+	return func(yield func(FileBlame) bool) {
+		for blame := range blames {
+			commits := make([]BlameCommit, len(blame.Commits))
+			for i, bc := range blame.Commits {
+				bc.AuthorName, bc.AuthorEmail = mm.Map(
+					bc.AuthorName,
+					bc.AuthorEmail,
+				)
+				commits[i] = bc
+			}
+			blame.Commits = commits
+
+			if !yield(blame) {
+				break
+			}
+		}
+	}
+}
+
+// Returns a map of path to blob hash for every file in the tree of the given
+// revision. Paths are relative to the repository root.
+func TreeBlobs(
+	ctx context.Context,
+	rev string,
+) (_ map[string]string, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error listing tree blobs: %w", err)
+		}
+	}()
+
+	blobs := map[string]string{}
+
+	subprocess, err := cmd.RunLsTree(ctx, rev)
+	if err != nil {
+		return blobs, err
+	}
+
+	lines, finish := subprocess.StdoutNullDelimitedLines()
+	for line := range lines {
+		if line == "" {
+			continue
+		}
+
+		// <mode> SP <type> SP <object> TAB <file>
+		info, path, found := strings.Cut(line, "\t")
+		fields := strings.Fields(info)
+		if !found || len(fields) != 3 {
+			return blobs, fmt.Errorf("unexpected ls-tree output: %s", line)
+		}
+
+		if fields[1] != "blob" {
+			continue // Skip submodules
+		}
+
+		blobs[path] = fields[2]
+	}
+
+	err = finish()
+	if err != nil {
+		return blobs, err
+	}
+
+	err = subprocess.Wait()
+	if err != nil {
+		return blobs, err
+	}
+
+	return blobs, nil
+}
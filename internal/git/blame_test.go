@@ -0,0 +1,133 @@
+package git_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/git/mailmap"
+)
+
+const blameDump = `f636d2667506d5164d5d28f8a7ece3eac631c71d 2 2 3
+author Alice
+author-mail <alice@example.com>
+author-time 1706868000
+author-tz +0000
+committer Alice
+committer-mail <alice@example.com>
+committer-time 1706868000
+committer-tz +0000
+summary readme
+previous 3aba504c6cab416ec73f4fdf6b04a37195b10c66 README
+filename README
+f66f5a59b996e2af73012428160d7fea88f534e9 1 1 1
+author Bob
+author-mail <bob@example.com>
+author-time 1641031200
+author-tz +0000
+committer Bob
+committer-mail <bob@example.com>
+committer-time 1641031200
+committer-tz +0000
+summary initial
+boundary
+filename README
+f636d2667506d5164d5d28f8a7ece3eac631c71d 7 7 2
+filename README`
+
+func TestParseBlame(t *testing.T) {
+	commits, err := git.ParseBlame(readDump(blameDump))
+	if err != nil {
+		t.Fatalf("error parsing blame: %v", err)
+	}
+
+	expected := []git.BlameCommit{
+		git.BlameCommit{
+			Hash:        "f636d2667506d5164d5d28f8a7ece3eac631c71d",
+			AuthorName:  "Alice",
+			AuthorEmail: "alice@example.com",
+			Date:        time.Unix(1706868000, 0),
+			Lines:       5,
+		},
+		git.BlameCommit{
+			Hash:        "f66f5a59b996e2af73012428160d7fea88f534e9",
+			AuthorName:  "Bob",
+			AuthorEmail: "bob@example.com",
+			Date:        time.Unix(1641031200, 0),
+			Lines:       1,
+		},
+	}
+	if diff := cmp.Diff(expected, commits); diff != "" {
+		t.Errorf("blame is wrong:\n%s", diff)
+	}
+}
+
+func TestParseBlameTruncated(t *testing.T) {
+	dump := "f636d2667506d5164d5d28f8a7ece3eac631c71d 2 2 3\nauthor Alice"
+
+	_, err := git.ParseBlame(readDump(dump))
+	if err == nil {
+		t.Errorf("expected error parsing truncated blame")
+	}
+}
+
+func TestMapBlameIdentities(t *testing.T) {
+	mm, err := mailmap.Parse(strings.NewReader(
+		"Bob Jones <bob@example.com> <bobby@old.example.com>",
+	))
+	if err != nil {
+		t.Fatalf("error parsing mailmap: %v", err)
+	}
+
+	blame := git.FileBlame{
+		Path: "README",
+		Commits: []git.BlameCommit{
+			{
+				Hash:        "a",
+				AuthorName:  "Bobby",
+				AuthorEmail: "bobby@old.example.com",
+			},
+			{
+				Hash:        "b",
+				AuthorName:  "Carol",
+				AuthorEmail: "carol@example.com",
+			},
+		},
+	}
+
+	// The original blame might be cached, so it should be left alone
+	original := blame
+	original.Commits = slices.Clone(blame.Commits)
+
+	expected := git.FileBlame{
+		Path: "README",
+		Commits: []git.BlameCommit{
+			{
+				Hash:        "a",
+				AuthorName:  "Bob Jones",
+				AuthorEmail: "bob@example.com",
+			},
+			{
+				Hash:        "b",
+				AuthorName:  "Carol",
+				AuthorEmail: "carol@example.com",
+			},
+		},
+	}
+
+	mapped := slices.Collect(
+		git.MapBlameIdentities(slices.Values([]git.FileBlame{blame}), mm),
+	)
+
+	if diff := cmp.Diff([]git.FileBlame{expected}, mapped); diff != "" {
+		t.Errorf("mapped blame is wrong:\n%s", diff)
+	}
+
+	if diff := cmp.Diff(original, blame); diff != "" {
+		t.Errorf("original blame was modified:\n%s", diff)
+	}
+}
@@ -10,41 +10,32 @@ import (
 	"slices"
 )
 
-const (
-	logFormat        = "--pretty=format:%H%x00%h%x00%p%x00%an%x00%ae%x00%ad%x00"
-	mailmapLogFormat = "--pretty=format:%H%x00%h%x00%p%x00%aN%x00%aE%x00%ad%x00"
-)
+// Co-authors are separated from each other by the ASCII unit separator.
+const coAuthorsFormat = "%(trailers:key=Co-authored-by,valueonly,separator=%x1f)"
+
+const logFormat = "--pretty=format:%H%x00%h%x00%p%x00" +
+	"%an%x00%ae%x00%ad%x00" +
+	"%cn%x00%ce%x00%cd%x00" +
+	coAuthorsFormat + "%x00"
 
 // Runs git log
+//
+// The mailmap is not applied; see git.MapIdentities().
 func RunLog(
 	ctx context.Context,
 	revs []string,
 	pathspecs []string,
 	filters LogFilters,
 	needDiffs bool,
-	useMailmap bool,
 ) (*Subprocess, error) {
-	var baseArgs []string
-
-	if useMailmap {
-		baseArgs = []string{
-			"log",
-			mailmapLogFormat,
-			"-z",
-			"--date=unix",
-			"--reverse",
-			"--no-show-signature",
-		}
-	} else {
-		baseArgs = []string{
-			"log",
-			logFormat,
-			"-z",
-			"--date=unix",
-			"--reverse",
-			"--no-show-signature",
-			"--no-mailmap",
-		}
+	baseArgs := []string{
+		"log",
+		logFormat,
+		"-z",
+		"--date=unix",
+		"--reverse",
+		"--no-show-signature",
+		"--no-mailmap",
 	}
 
 	if needDiffs {
@@ -75,38 +66,51 @@ func RunLog(
 	return subprocess, nil
 }
 
+// Runs git log, listing only the files each commit renamed
+func RunLogRenames(ctx context.Context, revs []string) (*Subprocess, error) {
+	baseArgs := []string{
+		"log",
+		logFormat,
+		"-z",
+		"--date=unix",
+		"--reverse",
+		"--no-show-signature",
+		"--no-mailmap",
+		"--numstat",
+		"--find-renames",
+		"--diff-filter=R",
+	}
+
+	args := slices.Concat(baseArgs, revs)
+
+	needStdin := false
+	subprocess, err := run(ctx, args, needStdin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run git log: %w", err)
+	}
+
+	return subprocess, nil
+}
+
 // Runs git log --stdin
+//
+// The mailmap is not applied, so that the commits can be cached no matter what
+// the mailmap says.
 func RunStdinLog(
 	ctx context.Context,
 	pathspecs []string, // Doesn't limit commits, but limits diffs!
 	needDiffs bool,
-	useMailmap bool,
 ) (*Subprocess, error) {
-	var baseArgs []string
-
-	if useMailmap {
-		baseArgs = []string{
-			"log",
-			mailmapLogFormat,
-			"-z",
-			"--date=unix",
-			"--reverse",
-			"--no-show-signature",
-			"--stdin",
-			"--no-walk",
-		}
-	} else {
-		baseArgs = []string{
-			"log",
-			logFormat,
-			"-z",
-			"--date=unix",
-			"--reverse",
-			"--no-show-signature",
-			"--stdin",
-			"--no-walk",
-			"--no-mailmap",
-		}
+	baseArgs := []string{
+		"log",
+		logFormat,
+		"-z",
+		"--date=unix",
+		"--reverse",
+		"--no-show-signature",
+		"--stdin",
+		"--no-walk",
+		"--no-mailmap",
 	}
 
 	if needDiffs {
@@ -157,6 +161,20 @@ func RunRevParseTopLevel(ctx context.Context) (*Subprocess, error) {
 	return subprocess, nil
 }
 
+// Has git rev-parse turn a date into a Unix timestamp. Accepts any date format
+// that "git log --since" does.
+func RunRevParseDate(ctx context.Context, date string) (*Subprocess, error) {
+	var args = []string{"rev-parse", "--since=" + date}
+
+	needStdin := false
+	subprocess, err := run(ctx, args, needStdin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run git rev-parse: %w", err)
+	}
+
+	return subprocess, nil
+}
+
 // Runs git rev-list. When countOnly is true, passes --count, which is much
 // faster than printing then getting all the revisions when all you need is the
 // count.
@@ -222,6 +240,45 @@ func RunLsFiles(ctx context.Context, pathspecs []string) (*Subprocess, error) {
 	return subprocess, nil
 }
 
+// Lists every blob in the tree of the given revision. Paths are relative to the
+// repository root.
+func RunLsTree(ctx context.Context, rev string) (*Subprocess, error) {
+	var args = []string{"ls-tree", "-r", "-z", "--full-tree", rev}
+
+	needStdin := false
+	subprocess, err := run(ctx, args, needStdin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run git ls-tree: %w", err)
+	}
+
+	return subprocess, nil
+}
+
+// Runs git blame on a single file as of the given revision.
+//
+// We use the incremental format because it only prints each hunk once, and
+// never prints the contents of the file.
+func RunBlame(
+	ctx context.Context,
+	rev string,
+	path string,
+	ignoreRevsPath string,
+) (*Subprocess, error) {
+	args := []string{"blame", "--incremental"}
+	if ignoreRevsPath != "" {
+		args = append(args, "--ignore-revs-file", ignoreRevsPath)
+	}
+	args = append(args, rev, "--", path)
+
+	needStdin := false
+	subprocess, err := run(ctx, args, needStdin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run git blame: %w", err)
+	}
+
+	return subprocess, nil
+}
+
 func RunConfigGet(ctx context.Context, args []string) (*Subprocess, error) {
 	baseArgs := []string{"config", "--get"}
 
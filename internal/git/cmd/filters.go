@@ -1,18 +1,19 @@
 package cmd
 
-import (
-	"fmt"
-	"strings"
-)
-
 type LogFilters struct {
 	Since    string
 	Until    string
 	Authors  []string
 	Nauthors []string
+
+	Committer bool // Match Authors and Nauthors against the committer instead
 }
 
 // Turn into CLI args we can pass to `git log`
+//
+// Authors and Nauthors have to match identities after the mailmap is applied,
+// which Git doesn't do for us (see RunLog()), so they aren't passed to Git.
+// See git.FilterAuthors() instead.
 func (f LogFilters) ToArgs() []string {
 	args := []string{}
 
@@ -24,25 +25,5 @@ func (f LogFilters) ToArgs() []string {
 		args = append(args, "--until", f.Until)
 	}
 
-	for _, author := range f.Authors {
-		args = append(args, "--author", author)
-	}
-
-	if len(f.Nauthors) > 0 {
-		args = append(args, "--perl-regexp")
-
-		// Build regex pattern OR-ing together all the nauthors
-		var b strings.Builder
-		for i, nauthor := range f.Nauthors {
-			b.WriteString(nauthor)
-			if i < len(f.Nauthors)-1 {
-				b.WriteString("|")
-			}
-		}
-
-		regex := fmt.Sprintf(`^((?!%s).*)$`, b.String())
-		args = append(args, "--author", regex)
-	}
-
 	return args
 }
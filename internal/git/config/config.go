@@ -18,20 +18,18 @@ func repoMailmapPath(gitRootPath string) string {
 	return path
 }
 
-// Looks up a file pointed to by the mailmap.file setting in the git config.
-func globalMailmapPath() (string, error) {
+// Looks up a setting in the git config. Returns an empty string if the setting
+// is not present.
+func get(args []string) (string, error) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	subprocess, err := cmd.RunConfigGet(
-		ctx,
-		[]string{"--type=path", "mailmap.file"},
-	)
+	subprocess, err := cmd.RunConfigGet(ctx, args)
 	if err != nil {
 		return "", err
 	}
 
-	p, err := subprocess.StdoutText()
+	v, err := subprocess.StdoutText()
 	if err != nil {
 		return "", err
 	}
@@ -41,18 +39,44 @@ func globalMailmapPath() (string, error) {
 		var subprocessErr *cmd.SubprocessErr
 		if errors.As(err, &subprocessErr) {
 			logger().Debug(
-				"failed to get mailmap path from config or value not present",
+				"failed to get value from config or value not present",
+				"args",
+				args,
 				"exitcode",
 				subprocessErr.ExitCode,
 			)
-			p = ""
+			v = ""
 		} else {
 			logger().Debug("got unknown error")
 			return "", err
 		}
 	}
 
-	return p, nil
+	return v, nil
+}
+
+// Looks up a file pointed to by the mailmap.file setting in the git config.
+func globalMailmapPath() (string, error) {
+	return get([]string{"--type=path", "mailmap.file"})
+}
+
+// Looks up the git-who.weights setting in the git config, which gives the
+// default weights for composite ranking (e.g. "commits=1,lines=2").
+func CompositeWeights() (string, error) {
+	return get([]string{"git-who.weights"})
+}
+
+// Looks up the git-who.cacheMaxSize setting in the git config, which gives the
+// most disk space in bytes that the caches for all repos should use together.
+// Git converts suffixes like "512m" or "2g" for us.
+func CacheMaxSize() (string, error) {
+	return get([]string{"--type=int", "git-who.cacheMaxSize"})
+}
+
+// Looks up the git-who.cacheMaxAge setting in the git config, which gives the
+// number of days a repo's cache can go unused before it is deleted.
+func CacheMaxAge() (string, error) {
+	return get([]string{"--type=int", "git-who.cacheMaxAge"})
 }
 
 // NOTE: We do NOT respect the blame.ignoreRevsFile option in the git config
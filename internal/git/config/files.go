@@ -4,12 +4,12 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
-	"hash"
 	"io"
 	"io/fs"
 	"os"
 	"strings"
 
+	"github.com/sinclairtarget/git-who/internal/git/mailmap"
 	rev "github.com/sinclairtarget/git-who/internal/git/revision"
 )
 
@@ -20,46 +20,36 @@ type SupplementalFiles struct {
 	IgnoreRevsPath    string
 }
 
-func (sf SupplementalFiles) HasMailmap() bool {
-	return len(sf.RepoMailmapPath) > 0 || len(sf.GlobalMailmapPath) > 0
-}
-
 func (sf SupplementalFiles) HasIgnoreRevs() bool {
 	return len(sf.IgnoreRevsPath) > 0
 }
 
-func (sf SupplementalFiles) MailmapHash(h hash.Hash32) error {
-	if len(sf.RepoMailmapPath) > 0 {
-		f, err := os.Open(sf.RepoMailmapPath)
-		if !errors.Is(err, fs.ErrNotExist) {
-			if err != nil {
-				return fmt.Errorf("could not read repo mailmap file: %v", err)
-			}
-			defer f.Close()
-
-			_, err = io.Copy(h, f)
-			if err != nil {
-				return fmt.Errorf("error hashing repo mailmap file: %v", err)
-			}
+// Parse the mailmap files, reading them in the same order Git does.
+func (sf SupplementalFiles) Mailmap() (_ mailmap.Mailmap, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error reading mailmap: %w", err)
+		}
+	}()
+
+	readers := []io.Reader{}
+	for _, p := range []string{sf.RepoMailmapPath, sf.GlobalMailmapPath} {
+		if len(p) == 0 {
+			continue
 		}
-	}
 
-	if len(sf.GlobalMailmapPath) > 0 {
-		f, err := os.Open(sf.GlobalMailmapPath)
-		if !errors.Is(err, fs.ErrNotExist) {
-			if err != nil {
-				return fmt.Errorf("could not read global mailmap file: %v", err)
-			}
-			defer f.Close()
-
-			_, err = io.Copy(h, f)
-			if err != nil {
-				return fmt.Errorf("error hashing global mailmap file: %v", err)
-			}
+		f, err := os.Open(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return mailmap.Mailmap{}, err
 		}
+		defer f.Close()
+
+		readers = append(readers, f)
 	}
 
-	return nil
+	return mailmap.Parse(readers...)
 }
 
 // Get git blame ignored revisions
@@ -11,21 +11,49 @@ import (
 	"errors"
 	"fmt"
 	"iter"
+	"regexp"
 	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/sinclairtarget/git-who/internal/git/cmd"
 	"github.com/sinclairtarget/git-who/internal/git/config"
+	"github.com/sinclairtarget/git-who/internal/git/mailmap"
 )
 
 type Commit struct {
-	Hash        string
-	ShortHash   string
-	IsMerge     bool
-	AuthorName  string
-	AuthorEmail string
-	Date        time.Time
-	FileDiffs   []FileDiff
+	Hash           string
+	ShortHash      string
+	IsMerge        bool
+	AuthorName     string
+	AuthorEmail    string
+	Date           time.Time
+	CommitterName  string // Who applied the commit, not always the author
+	CommitterEmail string
+	CommitDate     time.Time
+	CoAuthors      []CoAuthor // From Co-authored-by trailers
+	FileDiffs      []FileDiff
+}
+
+// Someone credited with a "Co-authored-by" trailer in the commit message.
+type CoAuthor struct {
+	Name  string
+	Email string
+}
+
+// Parses the value of a trailer like "Jane Doe <jane@example.com>".
+func parseCoAuthor(s string) CoAuthor {
+	name, email, found := strings.Cut(s, "<")
+	if !found {
+		return CoAuthor{Name: strings.TrimSpace(s)}
+	}
+
+	email, _, _ = strings.Cut(email, ">")
+	return CoAuthor{
+		Name:  strings.TrimSpace(name),
+		Email: strings.TrimSpace(email),
+	}
 }
 
 func (c Commit) Name() string {
@@ -52,6 +80,7 @@ func (c Commit) String() string {
 // A file that was changed in a Commit.
 type FileDiff struct {
 	Path         string
+	PrevPath     string // Path before this commit, if the file was renamed
 	LinesAdded   int
 	LinesRemoved int
 }
@@ -85,13 +114,17 @@ func CommitsWithOpts(
 		return empty, func() error { return err }
 	}
 
+	mm, err := configFiles.Mailmap()
+	if err != nil {
+		return empty, func() error { return err }
+	}
+
 	subprocess, err := cmd.RunLog(
 		ctx,
 		revs,
 		pathspecs,
 		filters,
 		populateDiffs,
-		configFiles.HasMailmap(),
 	)
 	if err != nil {
 		return empty, func() error { return err }
@@ -100,6 +133,11 @@ func CommitsWithOpts(
 	lines, finishLines := subprocess.StdoutNullDelimitedLines()
 	commits, finishCommits := ParseCommits(lines)
 	commits = SkipIgnored(commits, ignoreRevs)
+	commits = MapIdentities(commits, mm)
+	commits, err = FilterAuthors(commits, filters)
+	if err != nil {
+		return empty, func() error { return err }
+	}
 
 	finish := func() error {
 		iterErr := finishCommits()
@@ -179,6 +217,45 @@ func GetRoot() (_ string, err error) {
 	return root, nil
 }
 
+// Parses a date the way Git would parse it for "git log --since".
+func ParseDate(date string) (_ time.Time, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("failed to parse date \"%s\": %w", date, err)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	subprocess, err := cmd.RunRevParseDate(ctx, date)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	out, err := subprocess.StdoutText()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	err = subprocess.Wait()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	s, ok := strings.CutPrefix(out, "--max-age=")
+	if !ok {
+		return time.Time{}, fmt.Errorf("unexpected output from Git: %s", out)
+	}
+
+	timestamp, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(timestamp, 0), nil
+}
+
 // Returns all paths in the working tree under the given pathspecs.
 func WorkingTreeFiles(pathspecs []string) (_ map[string]bool, err error) {
 	defer func() {
@@ -292,3 +369,118 @@ func SkipIgnored(
 		}
 	}
 }
+
+// Applies the mailmap to the author, committer, and co-authors of each commit.
+//
+// We always read commits with Git's own mailmap handling turned off, so that
+// commits look the same whether they come from Git or from the cache, which
+// stores identities as they appear in the commit.
+func MapIdentities(
+	commits iter.Seq[Commit],
+	mm mailmap.Mailmap,
+) iter.Seq[Commit] {
+	return func(yield func(Commit) bool) {
+		for commit := range commits {
+			commit.AuthorName, commit.AuthorEmail = mm.Map(
+				commit.AuthorName,
+				commit.AuthorEmail,
+			)
+			commit.CommitterName, commit.CommitterEmail = mm.Map(
+				commit.CommitterName,
+				commit.CommitterEmail,
+			)
+			commit.CoAuthors = mapCoAuthors(commit.CoAuthors, mm)
+
+			if !yield(commit) {
+				break
+			}
+		}
+	}
+}
+
+// Returns a mapped copy, so that we never modify commits someone else (like
+// the cache) might be holding on to.
+func mapCoAuthors(coAuthors []CoAuthor, mm mailmap.Mailmap) []CoAuthor {
+	if len(coAuthors) == 0 {
+		return coAuthors
+	}
+
+	mapped := make([]CoAuthor, 0, len(coAuthors))
+	for _, coAuthor := range coAuthors {
+		name, email := mm.Map(coAuthor.Name, coAuthor.Email)
+		mapped = append(mapped, CoAuthor{Name: name, Email: email})
+	}
+
+	return mapped
+}
+
+/*
+* FilterAuthors() returns an iterator over the commits that pass the author
+* filters: those by any of filters.Authors, if there are any, and by none of
+* filters.Nauthors. With filters.Committer set, the filters apply to the
+* committer instead.
+*
+* As with `git log --author`, each filter is a regular expression matched
+* against "Name <email>". A commit is excluded if its identity starts with a
+* match for any of the nauthors.
+*
+* This must come after MapIdentities(), so that we match the identities we
+* report rather than the ones recorded in each commit.
+ */
+func FilterAuthors(
+	commits iter.Seq[Commit],
+	filters cmd.LogFilters,
+) (iter.Seq[Commit], error) {
+	if len(filters.Authors) == 0 && len(filters.Nauthors) == 0 {
+		return commits, nil
+	}
+
+	authors := []*regexp.Regexp{}
+	for _, author := range filters.Authors {
+		re, err := regexp.Compile(author)
+		if err != nil {
+			return commits, fmt.Errorf("bad author \"%s\": %w", author, err)
+		}
+
+		authors = append(authors, re)
+	}
+
+	var nauthors *regexp.Regexp
+	if len(filters.Nauthors) > 0 {
+		pattern := fmt.Sprintf("^(?:%s)", strings.Join(filters.Nauthors, "|"))
+
+		var err error
+		nauthors, err = regexp.Compile(pattern)
+		if err != nil {
+			return commits, fmt.Errorf("bad nauthor: %w", err)
+		}
+	}
+
+	return func(yield func(Commit) bool) {
+		for commit := range commits {
+			name, email := commit.AuthorName, commit.AuthorEmail
+			if filters.Committer {
+				name, email = commit.CommitterName, commit.CommitterEmail
+			}
+			identity := fmt.Sprintf("%s <%s>", name, email)
+
+			matchesAuthor := len(authors) == 0 || slices.ContainsFunc(
+				authors,
+				func(re *regexp.Regexp) bool {
+					return re.MatchString(identity)
+				},
+			)
+			if !matchesAuthor {
+				continue
+			}
+
+			if nauthors != nil && nauthors.MatchString(identity) {
+				continue
+			}
+
+			if !yield(commit) {
+				break
+			}
+		}
+	}, nil
+}
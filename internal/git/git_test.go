@@ -2,11 +2,14 @@ package git_test
 
 import (
 	"slices"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 
 	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/git/cmd"
+	"github.com/sinclairtarget/git-who/internal/git/mailmap"
 )
 
 func TestLimitDiffsByPathspec(t *testing.T) {
@@ -102,3 +105,156 @@ func TestLimitDiffsByPathspec(t *testing.T) {
 		})
 	}
 }
+
+func TestMapIdentities(t *testing.T) {
+	mm, err := mailmap.Parse(strings.NewReader(strings.TrimSpace(`
+Alice Smith <alice@example.com>
+Bob Jones <bob@example.com> <bobby@old.example.com>
+	`)))
+	if err != nil {
+		t.Fatalf("error parsing mailmap: %v", err)
+	}
+
+	commit := git.Commit{
+		Hash:           "abc123",
+		AuthorName:     "alice",
+		AuthorEmail:    "alice@example.com",
+		CommitterName:  "Bobby",
+		CommitterEmail: "bobby@old.example.com",
+		CoAuthors: []git.CoAuthor{
+			git.CoAuthor{Name: "Bobby", Email: "bobby@old.example.com"},
+			git.CoAuthor{Name: "Carol", Email: "carol@example.com"},
+		},
+	}
+
+	// The original commit might be cached, so it should be left alone
+	original := commit
+	original.CoAuthors = slices.Clone(commit.CoAuthors)
+
+	expected := git.Commit{
+		Hash:           "abc123",
+		AuthorName:     "Alice Smith",
+		AuthorEmail:    "alice@example.com",
+		CommitterName:  "Bob Jones",
+		CommitterEmail: "bob@example.com",
+		CoAuthors: []git.CoAuthor{
+			git.CoAuthor{Name: "Bob Jones", Email: "bob@example.com"},
+			git.CoAuthor{Name: "Carol", Email: "carol@example.com"},
+		},
+	}
+
+	mapped := slices.Collect(
+		git.MapIdentities(slices.Values([]git.Commit{commit}), mm),
+	)
+
+	if diff := cmp.Diff([]git.Commit{expected}, mapped); diff != "" {
+		t.Errorf("mapped commit is wrong:\n%s", diff)
+	}
+
+	if diff := cmp.Diff(original, commit); diff != "" {
+		t.Errorf("original commit was modified:\n%s", diff)
+	}
+}
+
+func TestFilterAuthors(t *testing.T) {
+	commits := []git.Commit{
+		git.Commit{
+			Hash:           "a",
+			AuthorName:     "Alice Smith",
+			AuthorEmail:    "alice@example.com",
+			CommitterName:  "Bob Jones",
+			CommitterEmail: "bob@example.com",
+		},
+		git.Commit{
+			Hash:           "b",
+			AuthorName:     "Bob Jones",
+			AuthorEmail:    "bob@example.com",
+			CommitterName:  "Bob Jones",
+			CommitterEmail: "bob@example.com",
+		},
+		git.Commit{
+			Hash:           "c",
+			AuthorName:     "Carol",
+			AuthorEmail:    "carol@example.com",
+			CommitterName:  "Alice Smith",
+			CommitterEmail: "alice@example.com",
+		},
+	}
+
+	tests := []struct {
+		name     string
+		filters  cmd.LogFilters
+		expected []string
+	}{
+		{
+			name:     "no_filters",
+			filters:  cmd.LogFilters{},
+			expected: []string{"a", "b", "c"},
+		},
+		{
+			name:     "author_name",
+			filters:  cmd.LogFilters{Authors: []string{"Alice"}},
+			expected: []string{"a"},
+		},
+		{
+			name:     "author_email",
+			filters:  cmd.LogFilters{Authors: []string{"<bob@example"}},
+			expected: []string{"b"},
+		},
+		{
+			name:     "several_authors",
+			filters:  cmd.LogFilters{Authors: []string{"Alice", "^Carol"}},
+			expected: []string{"a", "c"},
+		},
+		{
+			name:     "nauthors",
+			filters:  cmd.LogFilters{Nauthors: []string{"Bob", "Carol"}},
+			expected: []string{"a"},
+		},
+		{
+			name: "authors_and_nauthors",
+			filters: cmd.LogFilters{
+				Authors:  []string{"example.com"},
+				Nauthors: []string{"Alice"},
+			},
+			expected: []string{"b", "c"},
+		},
+		{
+			name: "committer",
+			filters: cmd.LogFilters{
+				Authors:   []string{"Bob"},
+				Committer: true,
+			},
+			expected: []string{"a", "b"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filtered, err := git.FilterAuthors(
+				slices.Values(commits),
+				test.filters,
+			)
+			if err != nil {
+				t.Fatalf("FilterAuthors() returned error: %v", err)
+			}
+
+			hashes := []string{}
+			for commit := range filtered {
+				hashes = append(hashes, commit.Hash)
+			}
+
+			if diff := cmp.Diff(test.expected, hashes); diff != "" {
+				t.Errorf("filtered commits are wrong:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFilterAuthorsBadRegex(t *testing.T) {
+	filters := cmd.LogFilters{Authors: []string{"(unclosed"}}
+	_, err := git.FilterAuthors(slices.Values([]git.Commit{}), filters)
+	if err == nil {
+		t.Errorf("expected FilterAuthors() to return error for bad regex")
+	}
+}
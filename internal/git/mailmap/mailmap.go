@@ -0,0 +1,133 @@
+// Resolves author identities the same way Git does using a .mailmap file.
+//
+// See gitmailmap(5). Each line of a mailmap file takes one of four forms:
+//
+//	Proper Name <commit@email.xx>
+//	<proper@email.xx> <commit@email.xx>
+//	Proper Name <proper@email.xx> <commit@email.xx>
+//	Proper Name <proper@email.xx> Commit Name <commit@email.xx>
+//
+// Both names and emails are matched case-insensitively.
+package mailmap
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+type identity struct {
+	name  string
+	email string
+}
+
+// Everything that replaces a single commit email.
+type entry struct {
+	identity                     // Used when no name-specific entry matches
+	byName   map[string]identity // Keyed by lowercase commit name
+}
+
+type Mailmap struct {
+	entries map[string]*entry // Keyed by lowercase commit email
+}
+
+// Parses mailmap files. Entries read later override those read earlier, so
+// the files should be given in the order Git reads them.
+func Parse(rs ...io.Reader) (Mailmap, error) {
+	m := Mailmap{entries: map[string]*entry{}}
+
+	for _, r := range rs {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			m.addLine(scanner.Text())
+		}
+
+		if err := scanner.Err(); err != nil {
+			return m, err
+		}
+	}
+
+	return m, nil
+}
+
+// Returns the canonical name and email for the given identity.
+func (m Mailmap) Map(name string, email string) (string, string) {
+	e, ok := m.entries[strings.ToLower(email)]
+	if !ok {
+		return name, email
+	}
+
+	id := e.identity
+	if byName, ok := e.byName[strings.ToLower(name)]; ok {
+		id = byName
+	}
+
+	if id.name != "" {
+		name = id.name
+	}
+
+	if id.email != "" {
+		email = id.email
+	}
+
+	return name, email
+}
+
+// Like Git, we ignore lines we can't make sense of instead of failing.
+func (m Mailmap) addLine(line string) {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "#") {
+		return
+	}
+
+	name1, email1, rest, ok := nextIdentity(line)
+	if !ok {
+		return
+	}
+
+	name2, email2, _, ok := nextIdentity(rest)
+	if !ok {
+		// Only one email, so it must be the commit email
+		m.add(identity{name: name1}, "", email1)
+		return
+	}
+
+	m.add(identity{name: name1, email: email1}, name2, email2)
+}
+
+func (m Mailmap) add(proper identity, commitName string, commitEmail string) {
+	key := strings.ToLower(commitEmail)
+	e, ok := m.entries[key]
+	if !ok {
+		e = &entry{byName: map[string]identity{}}
+		m.entries[key] = e
+	}
+
+	if commitName != "" {
+		e.byName[strings.ToLower(commitName)] = proper
+		return
+	}
+
+	if proper.name != "" {
+		e.name = proper.name
+	}
+
+	if proper.email != "" {
+		e.email = proper.email
+	}
+}
+
+// Reads an optional name followed by an email in angle brackets.
+func nextIdentity(s string) (name string, email string, rest string, ok bool) {
+	name, after, found := strings.Cut(s, "<")
+	if !found {
+		return "", "", s, false
+	}
+
+	email, rest, found = strings.Cut(after, ">")
+	if !found {
+		return "", "", s, false
+	}
+
+	return strings.TrimSpace(name), strings.TrimSpace(email), rest, true
+}
@@ -0,0 +1,111 @@
+package mailmap_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sinclairtarget/git-who/internal/git/mailmap"
+)
+
+const mailmapFile = `# Comments are ignored
+Alice Smith <alice@example.com>
+<bob@example.com> <bobby@old.example.com>
+Carol Jones <carol@example.com> <cj@old.example.com>
+Dave Brown <dave@example.com> Dave <shared@example.com>
+Erin White <erin@example.com> erin <shared@example.com>
+`
+
+func TestMap(t *testing.T) {
+	m, err := mailmap.Parse(strings.NewReader(mailmapFile))
+	if err != nil {
+		t.Fatalf("error parsing mailmap: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		commitName    string
+		commitEmail   string
+		expectedName  string
+		expectedEmail string
+	}{
+		{
+			name:          "replace_name",
+			commitName:    "alice",
+			commitEmail:   "alice@example.com",
+			expectedName:  "Alice Smith",
+			expectedEmail: "alice@example.com",
+		},
+		{
+			name:          "replace_email",
+			commitName:    "Bob",
+			commitEmail:   "bobby@old.example.com",
+			expectedName:  "Bob",
+			expectedEmail: "bob@example.com",
+		},
+		{
+			name:          "replace_both",
+			commitName:    "CJ",
+			commitEmail:   "CJ@Old.Example.com",
+			expectedName:  "Carol Jones",
+			expectedEmail: "carol@example.com",
+		},
+		{
+			name:          "replace_both_by_name_and_email",
+			commitName:    "dave",
+			commitEmail:   "shared@example.com",
+			expectedName:  "Dave Brown",
+			expectedEmail: "dave@example.com",
+		},
+		{
+			name:          "other_name_same_email",
+			commitName:    "Erin",
+			commitEmail:   "shared@example.com",
+			expectedName:  "Erin White",
+			expectedEmail: "erin@example.com",
+		},
+		{
+			name:          "unknown_name_same_email",
+			commitName:    "Frank",
+			commitEmail:   "shared@example.com",
+			expectedName:  "Frank",
+			expectedEmail: "shared@example.com",
+		},
+		{
+			name:          "not_mapped",
+			commitName:    "Gina",
+			commitEmail:   "gina@example.com",
+			expectedName:  "Gina",
+			expectedEmail: "gina@example.com",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			name, email := m.Map(test.commitName, test.commitEmail)
+			if name != test.expectedName || email != test.expectedEmail {
+				t.Errorf(
+					"expected %s <%s> but got %s <%s>",
+					test.expectedName,
+					test.expectedEmail,
+					name,
+					email,
+				)
+			}
+		})
+	}
+}
+
+func TestParseLaterOverrides(t *testing.T) {
+	m, err := mailmap.Parse(
+		strings.NewReader("Alice <alice@example.com>\n"),
+		strings.NewReader("Alice Smith <alice@example.com>\n"),
+	)
+	if err != nil {
+		t.Fatalf("error parsing mailmap: %v", err)
+	}
+
+	name, _ := m.Map("alice", "alice@example.com")
+	if name != "Alice Smith" {
+		t.Errorf("expected name to be Alice Smith but got %s", name)
+	}
+}
@@ -3,7 +3,6 @@ package git
 import (
 	"fmt"
 	"iter"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -11,12 +10,6 @@ import (
 	rev "github.com/sinclairtarget/git-who/internal/git/revision"
 )
 
-var fileRenameRegexp *regexp.Regexp
-
-func init() {
-	fileRenameRegexp = regexp.MustCompile(`{(.*) => (.*)}`)
-}
-
 func parseLinesChanged(s string, line string) (int, error) {
 	changed, err := strconv.Atoi(s)
 	if err != nil {
@@ -65,7 +58,7 @@ func ParseCommits(lines iter.Seq[string]) (iter.Seq[Commit], func() error) {
 		linesThisCommit := 0
 
 		for line := range lines {
-			done := linesThisCommit >= 6 && (len(line) == 0 || rev.IsFullHash(line))
+			done := linesThisCommit >= 10 && (len(line) == 0 || rev.IsFullHash(line))
 			if done {
 				if allowCommit(commit, now) {
 					if !yield(commit) {
@@ -106,6 +99,29 @@ func ParseCommits(lines iter.Seq[string]) (iter.Seq[Commit], func() error) {
 				}
 
 				commit.Date = time.Unix(int64(i), 0)
+			case linesThisCommit == 6:
+				commit.CommitterName = line
+			case linesThisCommit == 7:
+				commit.CommitterEmail = line
+			case linesThisCommit == 8:
+				i, err := strconv.Atoi(line)
+				if err != nil {
+					iterErr = fmt.Errorf(
+						"error parsing commit date from commit %s: %w",
+						commit.Name(),
+						err,
+					)
+					return
+				}
+
+				commit.CommitDate = time.Unix(int64(i), 0)
+			case linesThisCommit == 9:
+				for _, trailer := range strings.Split(line, "\x1f") {
+					coAuthor := parseCoAuthor(trailer)
+					if coAuthor.Name != "" || coAuthor.Email != "" {
+						commit.CoAuthors = append(commit.CoAuthors, coAuthor)
+					}
+				}
 			default:
 				var err error
 
@@ -173,14 +189,14 @@ func ParseCommits(lines iter.Seq[string]) (iter.Seq[Commit], func() error) {
 						)
 					}
 				} else {
-					if len(diff.Path) > 0 {
+					// A renamed file. The old path comes first, then the new
+					// path
+					if len(diff.PrevPath) > 0 {
 						diff.Path = line
 						commit.FileDiffs = append(commit.FileDiffs, *diff)
 						diff = nil
 					} else {
-						// Used to handle moved files specially. For now, just
-						// mark as path until we overwrite it with next line
-						diff.Path = line
+						diff.PrevPath = line
 					}
 				}
 
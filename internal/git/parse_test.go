@@ -6,6 +6,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+
 	"github.com/sinclairtarget/git-who/internal/git"
 )
 
@@ -15,6 +17,10 @@ bf4136d
 Sinclair Target
 sinclairtarget@gmail.com
 1735304504
+Sinclair Target
+sinclairtarget@gmail.com
+1735304504
+
 9	0	file-rename/foo.go
 
 879e94bbbcbbec348ba1df332dd46e7314c62df1
@@ -23,6 +29,10 @@ bf4136d
 Sinclair Target
 sinclairtarget@gmail.com
 1735304522
+Sinclair Target
+sinclairtarget@gmail.com
+1735304522
+
 0	0
 file-rename/foo.go
 file-rename/bim.go
@@ -33,6 +43,10 @@ ad6d378
 Sinclair Target
 sinclairtarget@gmail.com
 1735304546
+Sinclair Target
+sinclairtarget@gmail.com
+1735304546
+
 1	1	file-rename/bim.go
 
 `
@@ -43,6 +57,10 @@ e4b688d
 Sinclair Target
 sinclairtarget@gmail.com
 1735487061
+Sinclair Target
+sinclairtarget@gmail.com
+1735487061
+
 1	0	rename-new-dir/hello.txt
 
 13b6f4f70c682ab06da9ef433cdb4fcbf65d78c3
@@ -51,6 +69,10 @@ sinclairtarget@gmail.com
 Sinclair Target
 sinclairtarget@gmail.com
 1735487089
+Sinclair Target
+sinclairtarget@gmail.com
+1735487089
+
 0	0
 rename-new-dir/hello.txt
 rename-new-dir/foo/hello.txt
@@ -63,6 +85,10 @@ const renameDeepDirDump = `5def9bfbddde001f6f324f2b781b6f2144bc3662
 Sinclair Target
 sinclairtarget@gmail.com
 1735507602
+Sinclair Target
+sinclairtarget@gmail.com
+1735507602
+
 1	0	rename-across-deep-dirs/foo/bar/hello.txt
 
 b9acb309a2c20ab6b93549bc7468b3e3ae5fc05e
@@ -71,12 +97,29 @@ b9acb30
 Sinclair Target
 sinclairtarget@gmail.com
 1735507662
+Sinclair Target
+sinclairtarget@gmail.com
+1735507662
+
 0	0
 rename-across-deep-dirs/foo/bar/hello.txt
 rename-across-deep-dirs/zim/zam/hello.txt
 
 `
 
+const pairDump = "3aba504d8c1b2f0e6a7d9c4b5e8f1a2b3c4d5e6f\n" +
+	"3aba504\n" +
+	"9c0e1d2\n" +
+	"Bob\n" +
+	"bob@example.com\n" +
+	"1735600000\n" +
+	"Carol\n" +
+	"carol@example.com\n" +
+	"1735700000\n" +
+	"Erin <erin@example.com>\x1fAlice <alice@example.com>\n" +
+	"2\t1\tpair.go\n" +
+	"\n"
+
 func readDump(dump string) iter.Seq[string] {
 	return slices.Values(strings.Split(dump, "\n"))
 }
@@ -119,6 +162,14 @@ func TestParseFileRename(t *testing.T) {
 			diff.Path,
 		)
 	}
+
+	if diff.PrevPath != "file-rename/foo.go" {
+		t.Errorf(
+			"expected diff prev path to be %s but got \"%s\"",
+			"file-rename/foo.go",
+			diff.PrevPath,
+		)
+	}
 }
 
 // Test moving a file into a new directory
@@ -160,6 +211,14 @@ func TestCommitsFileRenameNewDir(t *testing.T) {
 			diff.Path,
 		)
 	}
+
+	if diff.PrevPath != "rename-new-dir/hello.txt" {
+		t.Errorf(
+			"expected diff prev path to be %s but got \"%s\"",
+			"rename-new-dir/hello.txt",
+			diff.PrevPath,
+		)
+	}
 }
 
 // Test moving where change will look like /foo/{bim/bar => baz/biz}/hello.txt
@@ -201,4 +260,100 @@ func TestCommitsRenameDeepDir(t *testing.T) {
 			diff.Path,
 		)
 	}
+
+	if diff.PrevPath != "rename-across-deep-dirs/foo/bar/hello.txt" {
+		t.Errorf(
+			"expected diff prev path to be %s but got \"%s\"",
+			"rename-across-deep-dirs/foo/bar/hello.txt",
+			diff.PrevPath,
+		)
+	}
+}
+
+func TestParseCoAuthors(t *testing.T) {
+	lines := readDump(pairDump)
+
+	seq, finish := git.ParseCommits(lines)
+	commits := slices.Collect(seq)
+	err := finish()
+	if err != nil {
+		t.Fatalf("error iterating commits: %v", err)
+	}
+
+	if len(commits) != 1 {
+		t.Fatalf("expected 1 commit but found %d", len(commits))
+	}
+
+	commit := commits[0]
+	expected := []git.CoAuthor{
+		git.CoAuthor{Name: "Erin", Email: "erin@example.com"},
+		git.CoAuthor{Name: "Alice", Email: "alice@example.com"},
+	}
+	if diff := cmp.Diff(expected, commit.CoAuthors); diff != "" {
+		t.Errorf("co-authors are wrong:\n%s", diff)
+	}
+
+	if len(commit.FileDiffs) != 1 {
+		t.Errorf(
+			"len of commit file diffs should be 1, but got %d",
+			len(commit.FileDiffs),
+		)
+	}
+}
+
+func TestParseCommitter(t *testing.T) {
+	lines := readDump(pairDump)
+
+	seq, finish := git.ParseCommits(lines)
+	commits := slices.Collect(seq)
+	err := finish()
+	if err != nil {
+		t.Fatalf("error iterating commits: %v", err)
+	}
+
+	if len(commits) != 1 {
+		t.Fatalf("expected 1 commit but found %d", len(commits))
+	}
+
+	commit := commits[0]
+	if commit.AuthorName != "Bob" || commit.CommitterName != "Carol" {
+		t.Errorf(
+			"expected author Bob and committer Carol but got %s and %s",
+			commit.AuthorName,
+			commit.CommitterName,
+		)
+	}
+
+	if commit.CommitterEmail != "carol@example.com" {
+		t.Errorf(
+			"expected committer email to be %s but got %s",
+			"carol@example.com",
+			commit.CommitterEmail,
+		)
+	}
+
+	if commit.CommitDate.Unix() != 1735700000 {
+		t.Errorf(
+			"expected commit date to be %d but got %d",
+			1735700000,
+			commit.CommitDate.Unix(),
+		)
+	}
+}
+
+func TestParseKeepsFutureCommitDate(t *testing.T) {
+	// Authored in the past, but committed by someone with a broken clock. We
+	// only skip it when tallying by committer
+	lines := readDump(strings.Replace(pairDump, "1735700000", "9999999999", 1))
+
+	seq, finish := git.ParseCommits(lines)
+	commits := slices.Collect(seq)
+	err := finish()
+	if err != nil {
+		t.Fatalf("error iterating commits: %v", err)
+	}
+
+	if len(commits) != 1 {
+		t.Errorf("expected 1 commit but found %d", len(commits))
+	}
 }
@@ -0,0 +1,183 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	rev "github.com/sinclairtarget/git-who/internal/git/revision"
+)
+
+// Strips the "a/" or "b/" prefix from a path in a diff header. Returns the
+// empty string for /dev/null.
+func patchPath(s string) string {
+	s = strings.TrimSpace(s)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+
+	if s == "/dev/null" {
+		return ""
+	}
+
+	if strings.HasPrefix(s, "a/") || strings.HasPrefix(s, "b/") {
+		return s[2:]
+	}
+
+	return s
+}
+
+// Whether the line is the first line of a patch made by git format-patch, e.g.
+// "From <hash> Mon Sep 17 00:00:00 2001".
+func isPatchStart(line string) bool {
+	fields := strings.Fields(line)
+	return len(fields) > 1 && fields[0] == "From" && rev.IsFullHash(fields[1])
+}
+
+// Parses the line counts out of a hunk header like "@@ -1,5 +1,7 @@".
+func parseHunkHeader(line string) (removed int, added int, err error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return 0, 0, fmt.Errorf("bad hunk header: \"%s\"", line)
+	}
+
+	count := func(field string) (int, error) {
+		_, n, found := strings.Cut(field[1:], ",")
+		if !found {
+			return 1, nil // Count is omitted when it is one
+		}
+
+		return strconv.Atoi(n)
+	}
+
+	removed, err = count(fields[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("bad hunk header: \"%s\": %w", line, err)
+	}
+
+	added, err = count(fields[2])
+	if err != nil {
+		return 0, 0, fmt.Errorf("bad hunk header: \"%s\": %w", line, err)
+	}
+
+	return removed, added, nil
+}
+
+/*
+* ParsePatch() reads a patch in unified diff format, as made by git diff or git
+* format-patch, and returns a commit for each patch it contains.
+*
+* Only the author and the file diffs of each commit are filled in. The author
+* comes from the "From:" header written by git format-patch; a plain diff
+* results in a single commit with no author.
+ */
+func ParsePatch(r io.Reader) (_ []Commit, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error parsing patch: %w", err)
+		}
+	}()
+
+	commits := []Commit{}
+	var commit *Commit
+	var diff *FileDiff
+	var oldPath string
+	var sawNewPath bool            // Whether we've seen the "+++" line for the diff
+	var removedLeft, addedLeft int // Lines left in the current hunk
+
+	newCommit := func() {
+		commits = append(commits, Commit{})
+		commit = &commits[len(commits)-1]
+		diff = nil
+	}
+
+	newDiff := func() {
+		if commit == nil {
+			newCommit()
+		}
+
+		commit.FileDiffs = append(commit.FileDiffs, FileDiff{})
+		diff = &commit.FileDiffs[len(commit.FileDiffs)-1]
+		oldPath = ""
+		sawNewPath = false
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// Within a hunk, lines are content no matter what they look like
+		if removedLeft > 0 || addedLeft > 0 {
+			switch {
+			case strings.HasPrefix(line, "-"):
+				diff.LinesRemoved += 1
+				removedLeft -= 1
+			case strings.HasPrefix(line, "+"):
+				diff.LinesAdded += 1
+				addedLeft -= 1
+			case strings.HasPrefix(line, "\\"):
+				// "\ No newline at end of file"
+			default:
+				removedLeft -= 1
+				addedLeft -= 1
+			}
+
+			continue
+		}
+
+		switch {
+		case isPatchStart(line):
+			newCommit()
+		case strings.HasPrefix(line, "From: ") && commit != nil && diff == nil:
+			author := parseCoAuthor(strings.TrimPrefix(line, "From: "))
+			commit.AuthorName = author.Name
+			commit.AuthorEmail = author.Email
+		case strings.HasPrefix(line, "diff --git "):
+			newDiff()
+		case strings.HasPrefix(line, "--- "):
+			if diff == nil || sawNewPath {
+				newDiff() // Plain unified diff without "diff --git" lines
+			}
+
+			oldPath = patchPath(strings.TrimPrefix(line, "--- "))
+		case strings.HasPrefix(line, "+++ ") && diff != nil:
+			sawNewPath = true
+			diff.Path = patchPath(strings.TrimPrefix(line, "+++ "))
+			if diff.Path == "" {
+				diff.Path = oldPath // File was deleted
+			} else if oldPath != "" && oldPath != diff.Path {
+				diff.PrevPath = oldPath
+			}
+		case strings.HasPrefix(line, "rename from ") && diff != nil:
+			diff.PrevPath = strings.TrimPrefix(line, "rename from ")
+		case strings.HasPrefix(line, "rename to ") && diff != nil:
+			diff.Path = strings.TrimPrefix(line, "rename to ")
+		case strings.HasPrefix(line, "@@ ") && diff != nil:
+			removedLeft, addedLeft, err = parseHunkHeader(line)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Drop diffs we couldn't find a path for
+	for i := range commits {
+		diffs := []FileDiff{}
+		for _, d := range commits[i].FileDiffs {
+			if d.Path != "" {
+				diffs = append(diffs, d)
+			}
+		}
+
+		commits[i].FileDiffs = diffs
+	}
+
+	return commits, nil
+}
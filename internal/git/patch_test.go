@@ -0,0 +1,143 @@
+package git_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sinclairtarget/git-who/internal/git"
+)
+
+const formatPatch = `From 0123456789abcdef0123456789abcdef01234567 Mon Sep 17 00:00:00 2001
+From: Bob Smith <bob@mail.com>
+Date: Fri, 3 Jan 2025 10:00:00 +0000
+Subject: [PATCH 1/2] Update foo
+
+---
+ foo.go | 3 ++-
+ 1 file changed, 2 insertions(+), 1 deletion(-)
+
+diff --git a/foo.go b/foo.go
+index 1111111..2222222 100644
+--- a/foo.go
++++ b/foo.go
+@@ -1,3 +1,4 @@
+ package foo
+--- old comment
++++ new comment
++// More
+ func Foo() {}
+-- 
+2.40.0
+
+From 89abcdef0123456789abcdef0123456789abcdef Mon Sep 17 00:00:00 2001
+From: Jim <jim@mail.com>
+Date: Sat, 4 Jan 2025 10:00:00 +0000
+Subject: [PATCH 2/2] Move and delete
+
+---
+diff --git a/old/bar.go b/new/bar.go
+similarity index 90%
+rename from old/bar.go
+rename to new/bar.go
+index 3333333..4444444 100644
+--- a/old/bar.go
++++ b/new/bar.go
+@@ -2 +2 @@ package bar
+-var x = 1
++var x = 2
+diff --git a/gone.txt b/gone.txt
+deleted file mode 100644
+index 5555555..0000000
+--- a/gone.txt
++++ /dev/null
+@@ -1,2 +0,0 @@
+-a
+-b
+-- 
+2.40.0
+`
+
+const plainDiff = `--- a/foo.go
++++ b/foo.go
+@@ -1 +1,2 @@
+ package foo
++// More
+--- /dev/null
++++ b/baz.go
+@@ -0,0 +1 @@
++package baz
+`
+
+func TestParsePatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		patch    string
+		expected []git.Commit
+	}{
+		{
+			name:  "format_patch",
+			patch: formatPatch,
+			expected: []git.Commit{
+				git.Commit{
+					AuthorName:  "Bob Smith",
+					AuthorEmail: "bob@mail.com",
+					FileDiffs: []git.FileDiff{
+						git.FileDiff{
+							Path:         "foo.go",
+							LinesAdded:   2,
+							LinesRemoved: 1,
+						},
+					},
+				},
+				git.Commit{
+					AuthorName:  "Jim",
+					AuthorEmail: "jim@mail.com",
+					FileDiffs: []git.FileDiff{
+						git.FileDiff{
+							Path:         "new/bar.go",
+							PrevPath:     "old/bar.go",
+							LinesAdded:   1,
+							LinesRemoved: 1,
+						},
+						git.FileDiff{
+							Path:         "gone.txt",
+							LinesRemoved: 2,
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "plain_diff",
+			patch: plainDiff,
+			expected: []git.Commit{
+				git.Commit{
+					FileDiffs: []git.FileDiff{
+						git.FileDiff{Path: "foo.go", LinesAdded: 1},
+						git.FileDiff{Path: "baz.go", LinesAdded: 1},
+					},
+				},
+			},
+		},
+		{
+			name:     "empty",
+			patch:    "",
+			expected: []git.Commit{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			commits, err := git.ParsePatch(strings.NewReader(test.patch))
+			if err != nil {
+				t.Fatalf("ParsePatch() returned error: %v", err)
+			}
+
+			if diff := cmp.Diff(test.expected, commits); diff != "" {
+				t.Errorf("commits are wrong:\n%s", diff)
+			}
+		})
+	}
+}
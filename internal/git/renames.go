@@ -0,0 +1,181 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"time"
+
+	"github.com/sinclairtarget/git-who/internal/git/cmd"
+)
+
+// A file rename made by a commit.
+type rename struct {
+	to   string
+	hash string    // Commit that made the rename
+	date time.Time // Commit date of that commit
+	seq  int       // Position among all renames, oldest first
+}
+
+/*
+* RenameHistory records the renames made by a series of commits, so that we
+* can tell where the file edited by some other commit ended up.
+*
+* The same path can be renamed away from more than once if a new file was
+* created there after the first rename. So which rename applies depends on when
+* the file was edited: history from before a rename follows the file to its
+* new path, while history from after it stays with the path.
+*
+* The zero value has no renames.
+ */
+type RenameHistory struct {
+	byPath map[string][]rename // Keyed by the path renamed away from
+	order  map[string]int      // Position of each commit hash, oldest first
+}
+
+// Records the renames made by the given commits, which must be in
+// chronological order.
+//
+// order lists the hashes of every commit that might be followed, oldest first,
+// in the same order as commits. It tells us which of two commits came first.
+// For commits not in order, we go by commit date instead.
+func NewRenameHistory(commits iter.Seq[Commit], order []string) RenameHistory {
+	h := RenameHistory{
+		byPath: map[string][]rename{},
+		order:  make(map[string]int, len(order)),
+	}
+
+	for i, hash := range order {
+		h.order[hash] = i
+	}
+
+	seq := 0
+	for commit := range commits {
+		for _, diff := range commit.FileDiffs {
+			if diff.PrevPath == "" || diff.PrevPath == diff.Path {
+				continue
+			}
+
+			h.byPath[diff.PrevPath] = append(h.byPath[diff.PrevPath], rename{
+				to:   diff.Path,
+				hash: commit.Hash,
+				date: commit.CommitDate,
+				seq:  seq,
+			})
+			seq += 1
+		}
+	}
+
+	return h
+}
+
+// Number of paths that files were renamed away from.
+func (h RenameHistory) Len() int {
+	return len(h.byPath)
+}
+
+// Returns the path that the file at p, as edited by the given commit, ends up
+// at after all the renames made since, or p if it was never renamed again.
+func (h RenameHistory) Follow(commit Commit, p string) string {
+	rs := h.byPath[p]
+	i := slices.IndexFunc(rs, func(r rename) bool {
+		return h.isBefore(commit, r)
+	})
+	if i < 0 {
+		return p
+	}
+
+	return h.followFrom(rs[i])
+}
+
+// Whether the commit was made before the rename.
+//
+// Without knowing the order, we only know when commits were made to the
+// second. A commit made in the same second as a rename (other than the renaming
+// commit itself) is then taken to come before it.
+func (h RenameHistory) isBefore(commit Commit, r rename) bool {
+	if commit.Hash == r.hash {
+		return false
+	}
+
+	pos, ok := h.order[commit.Hash]
+	renamePos, renameOk := h.order[r.hash]
+	if ok && renameOk {
+		return pos < renamePos
+	}
+
+	return !r.date.Before(commit.CommitDate)
+}
+
+// Follows a rename through any later renames of the path it renamed to.
+func (h RenameHistory) followFrom(r rename) string {
+	for {
+		rs := h.byPath[r.to]
+		i := slices.IndexFunc(rs, func(next rename) bool {
+			return next.seq > r.seq
+		})
+		if i < 0 {
+			return r.to
+		}
+
+		r = rs[i]
+	}
+}
+
+// Yields every path a file was renamed away from along with the path that file
+// ends up at. The same path may be yielded more than once.
+func (h RenameHistory) All() iter.Seq2[string, string] {
+	return func(yield func(string, string) bool) {
+		for from, rs := range h.byPath {
+			for _, r := range rs {
+				to := h.followFrom(r)
+				if to == from {
+					continue // Renamed back
+				}
+
+				if !yield(from, to) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Returns the rename history for the commits reachable from the given
+// revisions. Paths are relative to the repository root.
+func Renames(ctx context.Context, revs []string) (_ RenameHistory, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error finding renames: %w", err)
+		}
+	}()
+
+	// Rev list is in the same order as git log
+	order, err := RevList(ctx, revs, []string{}, cmd.LogFilters{})
+	if err != nil {
+		return RenameHistory{}, err
+	}
+
+	subprocess, err := cmd.RunLogRenames(ctx, revs)
+	if err != nil {
+		return RenameHistory{}, err
+	}
+
+	lines, finishLines := subprocess.StdoutNullDelimitedLines()
+	commits, finishCommits := ParseCommits(lines)
+	renames := NewRenameHistory(commits, order)
+
+	err = errors.Join(finishCommits(), finishLines())
+	if err != nil {
+		return RenameHistory{}, err
+	}
+
+	err = subprocess.Wait()
+	if err != nil {
+		return RenameHistory{}, err
+	}
+
+	return renames, nil
+}
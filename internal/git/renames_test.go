@@ -0,0 +1,207 @@
+package git_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sinclairtarget/git-who/internal/git"
+)
+
+// A commit at the given time renaming each pair of paths.
+func renameCommit(at int64, pairs ...string) git.Commit {
+	commit := git.Commit{
+		Hash:       fmt.Sprintf("rename%d", at),
+		CommitDate: time.Unix(at, 0),
+	}
+	for i := 0; i < len(pairs); i += 2 {
+		commit.FileDiffs = append(commit.FileDiffs, git.FileDiff{
+			PrevPath: pairs[i],
+			Path:     pairs[i+1],
+		})
+	}
+
+	return commit
+}
+
+func TestRenameHistoryFollow(t *testing.T) {
+	tests := []struct {
+		name     string
+		commits  []git.Commit
+		path     string
+		at       int64 // When the path was edited
+		expected string
+	}{
+		{
+			name:     "no_renames",
+			commits:  []git.Commit{{FileDiffs: []git.FileDiff{{Path: "foo"}}}},
+			path:     "foo",
+			at:       100,
+			expected: "foo",
+		},
+		{
+			name: "single_rename",
+			commits: []git.Commit{
+				renameCommit(200, "old/foo.go", "src/foo.go"),
+			},
+			path:     "old/foo.go",
+			at:       100,
+			expected: "src/foo.go",
+		},
+		{
+			name:     "edited_after_rename",
+			commits:  []git.Commit{renameCommit(200, "a", "b")},
+			path:     "a",
+			at:       300,
+			expected: "a",
+		},
+		{
+			name:     "edited_same_second",
+			commits:  []git.Commit{renameCommit(200, "a", "b")},
+			path:     "a",
+			at:       200,
+			expected: "b",
+		},
+		{
+			name: "chain",
+			commits: []git.Commit{
+				renameCommit(200, "a", "b"),
+				renameCommit(300, "b", "c"),
+				renameCommit(400, "c", "d"),
+			},
+			path:     "a",
+			at:       100,
+			expected: "d",
+		},
+		{
+			name: "chain_joined_late",
+			commits: []git.Commit{
+				renameCommit(200, "a", "b"),
+				renameCommit(300, "b", "c"),
+			},
+			path:     "b",
+			at:       250,
+			expected: "c",
+		},
+		{
+			name: "path_reused_before_first_rename",
+			commits: []git.Commit{
+				renameCommit(200, "a", "b"),
+				renameCommit(400, "a", "c"),
+			},
+			path:     "a",
+			at:       100,
+			expected: "b",
+		},
+		{
+			name: "path_reused_before_second_rename",
+			commits: []git.Commit{
+				renameCommit(200, "a", "b"),
+				renameCommit(400, "a", "c"),
+			},
+			path:     "a",
+			at:       300,
+			expected: "c",
+		},
+		{
+			name: "renamed_back",
+			commits: []git.Commit{
+				renameCommit(200, "a", "b"),
+				renameCommit(300, "b", "a"),
+			},
+			path:     "a",
+			at:       100,
+			expected: "a",
+		},
+		{
+			name: "renamed_back_same_second",
+			commits: []git.Commit{
+				renameCommit(200, "a", "b"),
+				renameCommit(200, "b", "a"),
+			},
+			path:     "a",
+			at:       100,
+			expected: "a",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			renames := git.NewRenameHistory(slices.Values(test.commits), nil)
+			commit := git.Commit{Hash: "edit", CommitDate: time.Unix(test.at, 0)}
+			p := renames.Follow(commit, test.path)
+			if p != test.expected {
+				t.Errorf("expected %s to end up at %s but got %s",
+					test.path,
+					test.expected,
+					p,
+				)
+			}
+		})
+	}
+}
+
+func TestRenameHistoryRenamingCommit(t *testing.T) {
+	// A new file is created at the old path in the same commit that renames
+	// the old file away
+	commit := renameCommit(200, "a", "b")
+	commit.FileDiffs = append(commit.FileDiffs, git.FileDiff{Path: "a"})
+
+	renames := git.NewRenameHistory(slices.Values([]git.Commit{commit}), nil)
+	p := renames.Follow(commit, "a")
+	if p != "a" {
+		t.Errorf("expected new file to stay at a but got %s", p)
+	}
+}
+
+// Commits made in the same second, where only the order tells us which came
+// first
+func TestRenameHistoryOrder(t *testing.T) {
+	commits := []git.Commit{renameCommit(200, "a", "b")}
+	order := []string{"before", "rename200", "after"}
+	renames := git.NewRenameHistory(slices.Values(commits), order)
+
+	for hash, expected := range map[string]string{"before": "b", "after": "a"} {
+		commit := git.Commit{Hash: hash, CommitDate: time.Unix(200, 0)}
+		p := renames.Follow(commit, "a")
+		if p != expected {
+			t.Errorf(
+				"expected commit %s to edit %s but got %s",
+				hash,
+				expected,
+				p,
+			)
+		}
+	}
+}
+
+func TestRenameHistoryAll(t *testing.T) {
+	commits := []git.Commit{
+		renameCommit(200, "lib/x.go", "src/x.go", "lib/y.go", "src/y.go"),
+		renameCommit(300, "src/x.go", "pkg/x.go"),
+		renameCommit(400, "lib/x.go", "old/x.go"),
+		renameCommit(500, "old/x.go", "lib/x.go"),
+	}
+	renames := git.NewRenameHistory(slices.Values(commits), nil)
+
+	all := map[string][]string{}
+	for from, to := range renames.All() {
+		all[from] = append(all[from], to)
+	}
+	for _, tos := range all {
+		slices.Sort(tos)
+	}
+
+	expected := map[string][]string{
+		"lib/x.go": {"pkg/x.go"},
+		"lib/y.go": {"src/y.go"},
+		"old/x.go": {"lib/x.go"},
+		"src/x.go": {"pkg/x.go"},
+	}
+	if diff := cmp.Diff(expected, all); diff != "" {
+		t.Errorf("renames are wrong:\n%s", diff)
+	}
+}
@@ -0,0 +1,481 @@
+package subcommands
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	runewidth "github.com/mattn/go-runewidth"
+
+	"github.com/sinclairtarget/git-who/internal/format"
+	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/git/cmd"
+	"github.com/sinclairtarget/git-who/internal/pretty"
+	"github.com/sinclairtarget/git-who/internal/tally"
+)
+
+// A directory in which the profiled author is the top contributor.
+type authorDir struct {
+	path  string
+	tally tally.FinalTally // The author's tally for the directory
+	share float64          // The author's share of the directory's total
+}
+
+// One bucket of the profiled author's activity timeline.
+type authorActivity struct {
+	name  string
+	value int // The author's contributions in the bucket
+	total int // Everyone's contributions in the bucket
+}
+
+// The "author" subcommand prints a profile of a single author: when they were
+// active, how much they contributed, which directories they lead, and who else
+// works on the same files.
+func Author(
+	who string,
+	revs []string,
+	pathspecs []string,
+	mode tally.TallyMode,
+	limit int,
+	countMerges bool,
+	since string,
+	until string,
+	authors []string,
+	nauthors []string,
+) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error running \"author\": %w", err)
+		}
+	}()
+
+	logger().Debug(
+		"called author()",
+		"who",
+		who,
+		"revs",
+		revs,
+		"pathspecs",
+		pathspecs,
+		"mode",
+		mode,
+		"limit",
+		limit,
+		"countMerges",
+		countMerges,
+		"since",
+		since,
+		"until",
+		until,
+		"authors",
+		authors,
+		"nauthors",
+		nauthors,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	filters := cmd.LogFilters{
+		Since:    since,
+		Until:    until,
+		Authors:  authors,
+		Nauthors: nauthors,
+	}
+
+	// Identify the author by email if given an email, otherwise by name
+	tallyOpts := tally.TallyOpts{Mode: mode, CountMerges: countMerges}
+	showEmail := strings.Contains(who, "@")
+	key := func(t tally.FinalTally) string { return t.AuthorName }
+	if showEmail {
+		tallyOpts.Key = func(c git.Commit) string { return c.AuthorEmail }
+		key = func(t tally.FinalTally) string { return t.AuthorEmail }
+	} else {
+		tallyOpts.Key = func(c git.Commit) string { return c.AuthorName }
+	}
+
+	notFoundErr := fmt.Errorf("no commits found for author \"%s\"", who)
+
+	followRenames := false
+	root, err := tallyTree(
+		ctx,
+		revs,
+		pathspecs,
+		filters,
+		tallyOpts,
+		followRenames,
+	)
+	if err == tally.EmptyTreeErr {
+		return notFoundErr
+	} else if err != nil {
+		return err
+	}
+
+	root = root.Rank(tallyOpts)
+
+	rank := slices.IndexFunc(root.Ranked, func(t tally.FinalTally) bool {
+		return strings.EqualFold(key(t), who)
+	})
+	if rank < 0 {
+		return notFoundErr
+	}
+
+	profile := root.Ranked[rank]
+	authorKey := key(profile)
+
+	// -- Directories --
+	dirs := []authorDir{}
+	for _, childPath := range sortedChildPaths(root) {
+		dirs = authorDirs(
+			root.Children[childPath],
+			childPath,
+			authorKey,
+			key,
+			mode,
+			dirs,
+		)
+	}
+
+	slices.SortStableFunc(dirs, func(a, b authorDir) int {
+		return cmp.Or(
+			-a.tally.Compare(b.tally, mode),
+			strings.Compare(a.path, b.path),
+		)
+	})
+
+	dirsFilteredOut := 0
+	if limit > 0 && limit < len(dirs) {
+		dirsFilteredOut = len(dirs) - limit
+		dirs = dirs[:limit]
+	}
+
+	// -- Timeline --
+	var end time.Time // Zero time, meaning use last commit
+	buckets, err := tallyTimeline(ctx, revs, pathspecs, filters, tallyOpts, end)
+	if err != nil {
+		return err
+	}
+
+	activity := []authorActivity{}
+	for _, bucket := range buckets {
+		value := 0
+		total := 0
+		for _, t := range bucket.Rank(tallyOpts).Ranked {
+			if key(t) == authorKey {
+				value = tally.TimelineValue(t, mode)
+			}
+
+			total += tally.TimelineValue(t, mode)
+		}
+
+		// Start at the author's first contribution
+		if value == 0 && len(activity) == 0 {
+			continue
+		}
+
+		activity = append(activity, authorActivity{
+			name:  bucket.Name,
+			value: value,
+			total: total,
+		})
+	}
+
+	// ...and end at their last
+	for len(activity) > 0 && activity[len(activity)-1].value == 0 {
+		activity = activity[:len(activity)-1]
+	}
+
+	// -- Co-contributors --
+	overlaps := tally.Overlaps(root, authorKey, mode)
+
+	overlapsFilteredOut := 0
+	if limit > 0 && limit < len(overlaps) {
+		overlapsFilteredOut = len(overlaps) - limit
+		overlaps = overlaps[:limit]
+	}
+
+	writeAuthorSummary(profile, rank, root.Ranked, mode, showEmail)
+
+	fmt.Println()
+	writeAuthorDirs(dirs, mode, dirsFilteredOut)
+
+	fmt.Println()
+	fmt.Printf("Activity by %s:\n", mode)
+	drawAuthorPlot(activity)
+
+	fmt.Println()
+	writeAuthorOverlaps(overlaps, mode, showEmail, overlapsFilteredOut)
+	return nil
+}
+
+// Recursively descend tree, collecting the directories in the working tree led
+// by the given author. We don't descend into a directory the author leads,
+// since listing its subdirectories as well would be redundant.
+func authorDirs(
+	node *tally.TreeNode,
+	p string,
+	authorKey string,
+	key func(t tally.FinalTally) string,
+	mode tally.TallyMode,
+	dirs []authorDir,
+) []authorDir {
+	if len(node.Children) == 0 || !node.InWorkTree {
+		return dirs
+	}
+
+	if key(node.Tally) == authorKey {
+		total := 0
+		for _, t := range node.Ranked {
+			total += tally.TimelineValue(t, mode)
+		}
+
+		return append(dirs, authorDir{
+			path:  p,
+			tally: node.Tally,
+			share: share(tally.TimelineValue(node.Tally, mode), total),
+		})
+	}
+
+	for _, childPath := range sortedChildPaths(node) {
+		dirs = authorDirs(
+			node.Children[childPath],
+			path.Join(p, childPath),
+			authorKey,
+			key,
+			mode,
+			dirs,
+		)
+	}
+
+	return dirs
+}
+
+func writeAuthorSummary(
+	profile tally.FinalTally,
+	rank int,
+	ranked []tally.FinalTally,
+	mode tally.TallyMode,
+	showEmail bool,
+) {
+	var totalCommits, totalLines int
+	for _, t := range ranked {
+		totalCommits += t.Commits
+		totalLines += t.LinesAdded + t.LinesRemoved
+	}
+
+	name := profile.AuthorName
+	if showEmail {
+		name = fmt.Sprintf(
+			"%s %s",
+			profile.AuthorName,
+			format.GitEmail(profile.AuthorEmail),
+		)
+	}
+
+	fmt.Println(name)
+	fmt.Printf(
+		"Ranked #%d of %s authors by %s\n",
+		rank+1,
+		format.Number(len(ranked)),
+		mode,
+	)
+	fmt.Println()
+
+	date := func(t time.Time) string {
+		return fmt.Sprintf(
+			"%s (%s)",
+			t.Format(time.DateOnly),
+			format.RelativeTime(progStart, t),
+		)
+	}
+
+	fmt.Printf("%-14s %s\n", "First commit", date(profile.FirstCommitTime))
+	fmt.Printf("%-14s %s\n", "Last commit", date(profile.LastCommitTime))
+	fmt.Printf(
+		"%-14s %s (%s)\n",
+		"Commits",
+		format.Number(profile.Commits),
+		format.Percent(share(profile.Commits, totalCommits)),
+	)
+	fmt.Printf("%-14s %s\n", "Files", format.Number(profile.FileCount))
+	fmt.Printf(
+		"%-14s %s%s%s / %s%s%s (%s)\n",
+		"Lines (+/-)",
+		pretty.Green,
+		format.Number(profile.LinesAdded),
+		pretty.DefaultColor,
+		pretty.Red,
+		format.Number(profile.LinesRemoved),
+		pretty.DefaultColor,
+		format.Percent(share(
+			profile.LinesAdded+profile.LinesRemoved,
+			totalLines,
+		)),
+	)
+}
+
+func metricName(mode tally.TallyMode) string {
+	switch mode {
+	case tally.LinesMode:
+		return "Lines"
+	case tally.FilesMode:
+		return "Files"
+	default:
+		return "Commits"
+	}
+}
+
+func writeAuthorDirs(
+	dirs []authorDir,
+	mode tally.TallyMode,
+	numFilteredOut int,
+) {
+	fmt.Println("Directories led by this author:")
+	if len(dirs) == 0 {
+		fmt.Println("(none)")
+		return
+	}
+
+	colwidth := narrowWidth
+	pathWidth := colwidth - 2 - 7 - 9
+
+	var build strings.Builder
+	for _ = range colwidth - 2 {
+		build.WriteRune('─')
+	}
+	rule := build.String()
+
+	// -- Write header --
+	fmt.Printf("┌%s┐\n", rule)
+	fmt.Printf(
+		"│%-*s %6s %8s│\n",
+		pathWidth,
+		"Directory",
+		"Share",
+		metricName(mode),
+	)
+	fmt.Printf("├%s┤\n", rule)
+
+	// -- Write table rows --
+	for _, dir := range dirs {
+		fmt.Printf(
+			"│%s %6s %8s│\n",
+			runewidth.FillRight(format.Abbrev(dir.path+"/", pathWidth), pathWidth),
+			format.Percent(dir.share),
+			format.Number(tally.TimelineValue(dir.tally, mode)),
+		)
+	}
+
+	if numFilteredOut > 0 {
+		msg := fmt.Sprintf("...%s more...", format.Number(numFilteredOut))
+		fmt.Printf("│%-*s│\n", colwidth-2, msg)
+	}
+
+	fmt.Printf("└%s┘\n", rule)
+}
+
+func drawAuthorPlot(activity []authorActivity) {
+	maxVal := barWidth
+	for _, a := range activity {
+		maxVal = max(maxVal, a.total)
+	}
+
+	for _, a := range activity {
+		clampedValue := int(math.Ceil(
+			(float64(a.value) / float64(maxVal)) * float64(barWidth),
+		))
+		clampedTotal := int(math.Ceil(
+			(float64(a.total) / float64(maxVal)) * float64(barWidth),
+		))
+
+		valueBar := strings.Repeat("#", clampedValue)
+		totalBar := strings.Repeat("-", clampedTotal-clampedValue)
+
+		if a.value > 0 {
+			fmt.Printf(
+				"%s ┤ %s%s%-*s%s  %s (%s)\n",
+				a.name,
+				valueBar,
+				pretty.Dim,
+				barWidth-clampedValue,
+				totalBar,
+				pretty.Reset,
+				format.Number(a.value),
+				format.Percent(share(a.value, a.total)),
+			)
+		} else {
+			fmt.Printf(
+				"%s ┤ %s%s%s\n",
+				a.name,
+				pretty.Dim,
+				totalBar,
+				pretty.Reset,
+			)
+		}
+	}
+}
+
+func writeAuthorOverlaps(
+	overlaps []tally.Overlap,
+	mode tally.TallyMode,
+	showEmail bool,
+	numFilteredOut int,
+) {
+	fmt.Println("Authors who edit the same files:")
+	if len(overlaps) == 0 {
+		fmt.Println("(none)")
+		return
+	}
+
+	colwidth := wideWidth
+	authorWidth := colwidth - 2 - 13 - 9 - 12
+
+	var build strings.Builder
+	for _ = range colwidth - 2 {
+		build.WriteRune('─')
+	}
+	rule := build.String()
+
+	// -- Write header --
+	fmt.Printf("┌%s┐\n", rule)
+	fmt.Printf(
+		"│%-*s %12s %8s %-11s│\n",
+		authorWidth,
+		"Author",
+		"Shared Files",
+		metricName(mode),
+		" Last Edit",
+	)
+	fmt.Printf("├%s┤\n", rule)
+
+	// -- Write table rows --
+	totalRows := len(overlaps)
+	for i, o := range overlaps {
+		alternating := ""
+		if totalRows > maxBeforeColorAlternating && i%2 == 1 {
+			alternating = pretty.Invert
+		}
+
+		fmt.Printf(
+			"│%s%s %12s %8s  %-10s%s│\n",
+			alternating,
+			formatAuthor(o.Tally, showEmail, authorWidth),
+			format.Number(o.SharedFiles),
+			format.Number(tally.TimelineValue(o.Tally, mode)),
+			format.RelativeTime(progStart, o.Tally.LastCommitTime),
+			pretty.Reset,
+		)
+	}
+
+	if numFilteredOut > 0 {
+		msg := fmt.Sprintf("...%s more...", format.Number(numFilteredOut))
+		fmt.Printf("│%-*s│\n", colwidth-2, msg)
+	}
+
+	fmt.Printf("└%s┘\n", rule)
+}
@@ -0,0 +1,317 @@
+package subcommands
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	runewidth "github.com/mattn/go-runewidth"
+
+	"github.com/sinclairtarget/git-who/internal/format"
+	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/git/cmd"
+	"github.com/sinclairtarget/git-who/internal/pretty"
+	"github.com/sinclairtarget/git-who/internal/tally"
+)
+
+// Bus factor for a single directory.
+type busRow struct {
+	path     string
+	covering []tally.FinalTally // Authors who together cover the share
+	total    int                // Total value for all authors
+}
+
+// The "bus" subcommand prints the directories that depend on the fewest
+// authors.
+func Bus(
+	revs []string,
+	pathspecs []string,
+	mode tally.TallyMode,
+	share float64,
+	depth int,
+	limit int,
+	activeSince string,
+	showEmail bool,
+	countMerges bool,
+	since string,
+	until string,
+	authors []string,
+	nauthors []string,
+) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error running \"bus\": %w", err)
+		}
+	}()
+
+	logger().Debug(
+		"called bus()",
+		"revs",
+		revs,
+		"pathspecs",
+		pathspecs,
+		"mode",
+		mode,
+		"share",
+		share,
+		"depth",
+		depth,
+		"limit",
+		limit,
+		"activeSince",
+		activeSince,
+		"showEmail",
+		showEmail,
+		"countMerges",
+		countMerges,
+		"since",
+		since,
+		"until",
+		until,
+		"authors",
+		authors,
+		"nauthors",
+		nauthors,
+	)
+
+	var activeSinceTime time.Time
+	if activeSince != "" {
+		activeSinceTime, err = git.ParseDate(activeSince)
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	filters := cmd.LogFilters{
+		Since:    since,
+		Until:    until,
+		Authors:  authors,
+		Nauthors: nauthors,
+	}
+
+	tallyOpts := tally.TallyOpts{Mode: mode, CountMerges: countMerges}
+	key := func(t tally.FinalTally) string { return t.AuthorName }
+	if showEmail {
+		tallyOpts.Key = func(c git.Commit) string { return c.AuthorEmail }
+		key = func(t tally.FinalTally) string { return t.AuthorEmail }
+	} else {
+		tallyOpts.Key = func(c git.Commit) string { return c.AuthorName }
+	}
+
+	followRenames := false
+	root, err := tallyTree(
+		ctx,
+		revs,
+		pathspecs,
+		filters,
+		tallyOpts,
+		followRenames,
+	)
+	if err == tally.EmptyTreeErr {
+		logger().Debug("Tree was empty.")
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	root = root.Rank(tallyOpts)
+
+	// An author is active if they have committed anywhere since the given
+	// date, not just to the directory in question
+	active := map[string]bool{}
+	for _, t := range root.Ranked {
+		if !t.LastCommitTime.Before(activeSinceTime) {
+			active[key(t)] = true
+		}
+	}
+	isActive := func(t tally.FinalTally) bool { return active[key(t)] }
+
+	maxDepth := depth
+	if depth == 0 {
+		maxDepth = defaultMaxDepth
+	}
+
+	rows := busRows(root, ".", 0, maxDepth, mode, share, isActive, []busRow{})
+
+	// Riskiest first. Among equally risky directories, show the biggest first.
+	slices.SortStableFunc(rows, func(a, b busRow) int {
+		return cmp.Or(
+			cmp.Compare(len(a.covering), len(b.covering)),
+			-cmp.Compare(a.total, b.total),
+			strings.Compare(a.path, b.path),
+		)
+	})
+
+	numFilteredOut := 0
+	if limit > 0 && limit < len(rows) {
+		numFilteredOut = len(rows) - limit
+		rows = rows[:limit]
+	}
+
+	writeBusTable(rows, mode, share, showEmail, numFilteredOut)
+	return nil
+}
+
+// Recursively descend tree, computing the bus factor for every directory in the
+// working tree.
+func busRows(
+	node *tally.TreeNode,
+	p string,
+	depth int,
+	maxDepth int,
+	mode tally.TallyMode,
+	share float64,
+	isActive func(t tally.FinalTally) bool,
+	rows []busRow,
+) []busRow {
+	if depth > maxDepth || len(node.Children) == 0 || !node.InWorkTree {
+		return rows
+	}
+
+	total := 0
+	for _, t := range node.Ranked {
+		total += tally.TimelineValue(t, mode)
+	}
+
+	// Like path ellision in the tree output, skip a directory that just
+	// contains another directory, since the two have the same bus factor
+	onlyChildIsDir := false
+	if len(node.Children) == 1 {
+		for _, child := range node.Children {
+			onlyChildIsDir = len(child.Children) > 0
+		}
+	}
+
+	if total > 0 && !onlyChildIsDir {
+		rows = append(rows, busRow{
+			path:     p,
+			covering: tally.BusFactor(node.Ranked, mode, share, isActive),
+			total:    total,
+		})
+	}
+
+	for _, childPath := range sortedChildPaths(node) {
+		rows = busRows(
+			node.Children[childPath],
+			path.Join(p, childPath),
+			depth+1,
+			maxDepth,
+			mode,
+			share,
+			isActive,
+			rows,
+		)
+	}
+
+	return rows
+}
+
+func writeBusTable(
+	rows []busRow,
+	mode tally.TallyMode,
+	share float64,
+	showEmail bool,
+	numFilteredOut int,
+) {
+	if len(rows) == 0 {
+		return
+	}
+
+	colwidth := wideWidth
+	pathWidth := 30
+	authorsWidth := colwidth - 2 - pathWidth - 4 - 8 - 2
+
+	var build strings.Builder
+	for _ = range colwidth - 2 {
+		build.WriteRune('─')
+	}
+	rule := build.String()
+
+	metric := "Commits"
+	if mode == tally.LinesMode {
+		metric = "Lines"
+	} else if mode == tally.FilesMode {
+		metric = "Files"
+	}
+
+	fmt.Printf(
+		"Fewest authors accounting for %.0f%% of %s by directory:\n",
+		share*100,
+		mode,
+	)
+
+	// -- Write header --
+	fmt.Printf("┌%s┐\n", rule)
+	fmt.Printf(
+		"│%-*s %3s  %-*s %7s│\n",
+		pathWidth,
+		"Directory",
+		"Bus",
+		authorsWidth,
+		"Authors",
+		metric,
+	)
+	fmt.Printf("├%s┤\n", rule)
+
+	// -- Write table rows --
+	totalRows := len(rows)
+	for i, row := range rows {
+		alternating := ""
+		if totalRows > maxBeforeColorAlternating && i%2 == 1 {
+			alternating = pretty.Invert
+		}
+
+		names := []string{}
+		for _, t := range row.covering {
+			if showEmail {
+				names = append(names, format.GitEmail(t.AuthorEmail))
+			} else {
+				names = append(names, t.AuthorName)
+			}
+		}
+
+		authorList := strings.Join(names, ", ")
+		if len(row.covering) == 0 {
+			authorList = "(no active authors)"
+		}
+
+		busColor := ""
+		if len(row.covering) <= 1 {
+			busColor = pretty.Red
+		}
+
+		dir := row.path + "/"
+		if row.path == "." {
+			dir = "."
+		}
+
+		fmt.Printf(
+			"│%s%s %s%3d%s  %s %7s%s│\n",
+			alternating,
+			runewidth.FillRight(format.Abbrev(dir, pathWidth), pathWidth),
+			busColor,
+			len(row.covering),
+			pretty.DefaultColor,
+			runewidth.FillRight(
+				format.Abbrev(authorList, authorsWidth),
+				authorsWidth,
+			),
+			format.Number(row.total),
+			pretty.Reset,
+		)
+	}
+
+	if numFilteredOut > 0 {
+		msg := fmt.Sprintf("...%s more...", format.Number(numFilteredOut))
+		fmt.Printf("│%-*s│\n", colwidth-2, msg)
+	}
+
+	fmt.Printf("└%s┘\n", rule)
+}
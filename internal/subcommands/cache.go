@@ -0,0 +1,358 @@
+package subcommands
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sinclairtarget/git-who/internal/cache"
+	"github.com/sinclairtarget/git-who/internal/concurrent"
+	"github.com/sinclairtarget/git-who/internal/format"
+	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/git/cmd"
+	"github.com/sinclairtarget/git-who/internal/git/config"
+	"github.com/sinclairtarget/git-who/internal/pretty"
+)
+
+var cacheDisabledErr = errors.New(
+	"caching is disabled because GIT_WHO_DISABLE_CACHE is set",
+)
+
+// Returns the commit cache for the current repository, which has to be opened
+// before use.
+func repoCache() (cache.Cache, string, config.SupplementalFiles, error) {
+	var none cache.Cache
+
+	if !cache.IsCachingEnabled() {
+		return none, "", config.SupplementalFiles{}, cacheDisabledErr
+	}
+
+	gitRootPath, err := git.GetRoot()
+	if err != nil {
+		return none, "", config.SupplementalFiles{}, err
+	}
+
+	configFiles, err := config.DetectSupplementalFiles(gitRootPath)
+	if err != nil {
+		return none, "", config.SupplementalFiles{}, err
+	}
+
+	return cache.GetCache(gitRootPath), gitRootPath, configFiles, nil
+}
+
+// Opens the cache, runs f, then closes the cache again.
+func withOpenCache(c cache.Cache, f func() error) (err error) {
+	err = c.Open()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeErr := c.Close()
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	return f()
+}
+
+// The "cache stats" subcommand prints information about the cache for the
+// current repository.
+func CacheStats() (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error running \"cache stats\": %w", err)
+		}
+	}()
+
+	logger().Debug("called cacheStats()")
+
+	c, _, _, err := repoCache()
+	if err != nil {
+		return err
+	}
+
+	return withOpenCache(c, func() error {
+		stats, err := c.Stats()
+		if err != nil {
+			return err
+		}
+
+		lastUsed := "never"
+		if !stats.LastUsed.IsZero() {
+			lastUsed = fmt.Sprintf(
+				"%s (%s)",
+				stats.LastUsed.Format(time.DateTime),
+				format.RelativeTime(time.Now(), stats.LastUsed),
+			)
+		}
+
+		fmt.Printf("Path:       %s\n", stats.Dir)
+		fmt.Printf("Backend:    %s\n", c.Name())
+		fmt.Printf("State hash: %s\n", stats.Prefix)
+		fmt.Printf("Commits:    %s\n", format.Number(stats.Commits))
+		fmt.Printf("Size:       %s\n", format.Bytes(stats.Size))
+		fmt.Printf("Last used:  %s\n", lastUsed)
+		return nil
+	})
+}
+
+// The "cache clear" subcommand deletes the cache for the current repository,
+// or for every repository if all is true.
+func CacheClear(all bool) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error running \"cache clear\": %w", err)
+		}
+	}()
+
+	logger().Debug("called cacheClear()", "all", all)
+
+	if all {
+		dir, err := cache.ClearAll()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Cleared all caches under %s\n", dir)
+		return nil
+	}
+
+	c, gitRootPath, configFiles, err := repoCache()
+	if err != nil {
+		return err
+	}
+
+	err = c.Clear()
+	if err != nil {
+		return err
+	}
+
+	blameCache := cache.GetBlameCache(gitRootPath, configFiles)
+	err = blameCache.Clear()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Cleared cache for %s\n", gitRootPath)
+	return nil
+}
+
+// The "cache warm" subcommand parses the given commits and adds them to the
+// cache without tallying anything.
+func CacheWarm(revs []string, pathspecs []string) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error running \"cache warm\": %w", err)
+		}
+	}()
+
+	logger().Debug("called cacheWarm()", "revs", revs, "pathspecs", pathspecs)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c, _, configFiles, err := repoCache()
+	if err != nil {
+		return err
+	}
+
+	n, err := concurrent.WarmCache(
+		ctx,
+		revs,
+		pathspecs,
+		configFiles,
+		c,
+		pretty.AllowDynamic(os.Stdout),
+	)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Cached %s commits\n", format.Number(n))
+	return nil
+}
+
+// The "cache prune" subcommand removes commits that are no longer reachable
+// from any ref from the cache.
+func CachePrune() (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error running \"cache prune\": %w", err)
+		}
+	}()
+
+	logger().Debug("called cachePrune()")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c, _, _, err := repoCache()
+	if err != nil {
+		return err
+	}
+
+	reachable, err := git.RevList(
+		ctx,
+		[]string{"--all"},
+		[]string{},
+		cmd.LogFilters{},
+	)
+	if err != nil {
+		return err
+	}
+
+	keep := map[string]bool{}
+	for _, rev := range reachable {
+		keep[rev] = true
+	}
+
+	return withOpenCache(c, func() error {
+		n, err := c.Prune(func(hash string) bool { return keep[hash] })
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Pruned %s unreachable commits\n", format.Number(n))
+		return nil
+	})
+}
+
+// The "cache verify" subcommand reads back every commit in the cache and
+// reports any that are corrupt.
+func CacheVerify() (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error running \"cache verify\": %w", err)
+		}
+	}()
+
+	logger().Debug("called cacheVerify()")
+
+	c, _, _, err := repoCache()
+	if err != nil {
+		return err
+	}
+
+	return withOpenCache(c, func() error {
+		n, corrupt, err := c.Verify()
+		if err != nil {
+			return err
+		}
+
+		for _, record := range corrupt {
+			fmt.Printf("%s: %v\n", record.Hash, record.Err)
+		}
+
+		fmt.Printf("Checked %s commits\n", format.Number(n))
+
+		if len(corrupt) > 0 {
+			return fmt.Errorf(
+				"found %d corrupt commits; run \"git who cache clear\" to "+
+					"start over",
+				len(corrupt),
+			)
+		}
+
+		return nil
+	})
+}
+
+// The "cache export" subcommand writes the cache for the current repository to
+// an archive file, or to stdout if path is "-".
+func CacheExport(path string) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error running \"cache export\": %w", err)
+		}
+	}()
+
+	logger().Debug("called cacheExport()", "path", path)
+
+	c, _, _, err := repoCache()
+	if err != nil {
+		return err
+	}
+
+	return withOpenCache(c, func() error {
+		if path == "-" {
+			w := bufio.NewWriter(os.Stdout)
+			_, err := c.Export(w)
+			if err != nil {
+				return err
+			}
+
+			return w.Flush()
+		}
+
+		// Write to a temporary file first so we never leave a partial archive
+		tmp, err := os.CreateTemp(filepath.Dir(path), ".git-who-export-*")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+
+		err = tmp.Chmod(0644) // CreateTemp() makes files only we can read
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+
+		n, err := c.Export(tmp)
+		err = errors.Join(err, tmp.Close())
+		if err != nil {
+			return err
+		}
+
+		err = os.Rename(tmp.Name(), path)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Exported %s commits to %s\n", format.Number(n), path)
+		return nil
+	})
+}
+
+// The "cache import" subcommand adds the commits in an archive file, or read
+// from stdin if path is "-", to the cache for the current repository.
+func CacheImport(path string) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error running \"cache import\": %w", err)
+		}
+	}()
+
+	logger().Debug("called cacheImport()", "path", path)
+
+	c, _, _, err := repoCache()
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		r = f
+	}
+
+	return withOpenCache(c, func() error {
+		n, err := c.Import(bufio.NewReader(r))
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Imported %s commits\n", format.Number(n))
+		return nil
+	})
+}
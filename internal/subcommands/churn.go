@@ -0,0 +1,372 @@
+package subcommands
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	runewidth "github.com/mattn/go-runewidth"
+
+	"github.com/sinclairtarget/git-who/internal/format"
+	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/git/cmd"
+	"github.com/sinclairtarget/git-who/internal/pretty"
+	"github.com/sinclairtarget/git-who/internal/tally"
+)
+
+// Churn for a single file or directory.
+type churnRow struct {
+	path         string
+	isDir        bool
+	commits      int
+	linesAdded   int
+	linesRemoved int
+	authors      int
+}
+
+// How volatile and widely edited the path is: commits, or lines changed if
+// ranking by lines, times the number of authors.
+func (r churnRow) score(byLines bool) int {
+	if byLines {
+		return (r.linesAdded + r.linesRemoved) * r.authors
+	}
+
+	return r.commits * r.authors
+}
+
+// The "churn" subcommand prints the files or directories that change the most
+// and that the most authors have edited.
+func Churn(
+	revs []string,
+	pathspecs []string,
+	byLines bool,
+	dirs bool,
+	outputFormat OutputFormat,
+	limit int,
+	countMerges bool,
+	since string,
+	until string,
+	authors []string,
+	nauthors []string,
+) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error running \"churn\": %w", err)
+		}
+	}()
+
+	logger().Debug(
+		"called churn()",
+		"revs",
+		revs,
+		"pathspecs",
+		pathspecs,
+		"byLines",
+		byLines,
+		"dirs",
+		dirs,
+		"outputFormat",
+		outputFormat,
+		"limit",
+		limit,
+		"countMerges",
+		countMerges,
+		"since",
+		since,
+		"until",
+		until,
+		"authors",
+		authors,
+		"nauthors",
+		nauthors,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	filters := cmd.LogFilters{
+		Since:    since,
+		Until:    until,
+		Authors:  authors,
+		Nauthors: nauthors,
+	}
+
+	mode := tally.CommitMode
+	if byLines {
+		mode = tally.LinesMode
+	}
+
+	tallyOpts := tally.TallyOpts{
+		Mode:        mode,
+		CountMerges: countMerges,
+		Key:         func(c git.Commit) string { return c.AuthorName },
+	}
+
+	rows := []churnRow{}
+
+	followRenames := false
+	root, err := tallyTree(
+		ctx,
+		revs,
+		pathspecs,
+		filters,
+		tallyOpts,
+		followRenames,
+	)
+	if err == tally.EmptyTreeErr {
+		logger().Debug("Tree was empty.")
+	} else if err != nil {
+		return err
+	} else {
+		root = root.Rank(tallyOpts)
+		for _, childPath := range sortedChildPaths(root) {
+			rows = churnRows(root.Children[childPath], childPath, dirs, rows)
+		}
+	}
+
+	// Hottest first
+	slices.SortStableFunc(rows, func(a, b churnRow) int {
+		return cmp.Or(
+			-cmp.Compare(a.score(byLines), b.score(byLines)),
+			strings.Compare(a.path, b.path),
+		)
+	})
+
+	totalPaths := len(rows)
+	numFilteredOut := 0
+	if limit > 0 && limit < len(rows) {
+		numFilteredOut = len(rows) - limit
+		rows = rows[:limit]
+	}
+
+	switch outputFormat {
+	case CsvOutput:
+		return writeChurnCsv(rows, byLines)
+	case JsonOutput, NdjsonOutput:
+		meta := jsonChurnMeta{
+			jsonMeta:       toJsonMeta(revs, pathspecs, filters, mode),
+			TotalPaths:     totalPaths,
+			NumFilteredOut: numFilteredOut,
+		}
+		return writeChurnJson(rows, byLines, meta, outputFormat)
+	default:
+		writeChurnTable(rows, byLines, dirs, numFilteredOut)
+	}
+
+	return nil
+}
+
+// Recursively descend tree, collecting the churn for every file in the working
+// tree, or for every directory if dirs is true.
+func churnRows(
+	node *tally.TreeNode,
+	p string,
+	dirs bool,
+	rows []churnRow,
+) []churnRow {
+	if !node.InWorkTree || path.Base(p) == tally.NoDiffPathname {
+		return rows
+	}
+
+	isDir := len(node.Children) > 0
+	if isDir == dirs {
+		row := churnRow{path: p, isDir: isDir, authors: len(node.Ranked)}
+		for _, t := range node.Ranked {
+			row.commits += t.Commits
+			row.linesAdded += t.LinesAdded
+			row.linesRemoved += t.LinesRemoved
+		}
+
+		rows = append(rows, row)
+	}
+
+	for _, childPath := range sortedChildPaths(node) {
+		rows = churnRows(node.Children[childPath], path.Join(p, childPath), dirs, rows)
+	}
+
+	return rows
+}
+
+func writeChurnTable(
+	rows []churnRow,
+	byLines bool,
+	dirs bool,
+	numFilteredOut int,
+) {
+	if len(rows) == 0 {
+		return
+	}
+
+	colwidth := wideWidth
+	pathWidth := colwidth - 2 - 44
+
+	var build strings.Builder
+	for _ = range colwidth - 2 {
+		build.WriteRune('─')
+	}
+	rule := build.String()
+
+	pathLabel := "File"
+	if dirs {
+		pathLabel = "Directory"
+	}
+
+	// -- Write header --
+	fmt.Printf("┌%s┐\n", rule)
+	fmt.Printf(
+		"│%-*s %7s  %17s %7s %8s│\n",
+		pathWidth,
+		pathLabel,
+		"Commits",
+		"Lines (+/-)",
+		"Authors",
+		"Score",
+	)
+	fmt.Printf("├%s┤\n", rule)
+
+	// -- Write table rows --
+	totalRows := len(rows)
+	for i, row := range rows {
+		alternating := ""
+		if totalRows > maxBeforeColorAlternating && i%2 == 1 {
+			alternating = pretty.Invert
+		}
+
+		p := row.path
+		if row.isDir {
+			p += "/"
+		}
+
+		fmt.Printf(
+			"│%s%s %7s  %s%7s%s / %s%7s%s %7s %8s%s│\n",
+			alternating,
+			runewidth.FillRight(format.Abbrev(p, pathWidth), pathWidth),
+			format.Number(row.commits),
+			pretty.Green,
+			format.Number(row.linesAdded),
+			pretty.DefaultColor,
+			pretty.Red,
+			format.Number(row.linesRemoved),
+			pretty.DefaultColor,
+			format.Number(row.authors),
+			format.Number(row.score(byLines)),
+			pretty.Reset,
+		)
+	}
+
+	if numFilteredOut > 0 {
+		msg := fmt.Sprintf("...%s more...", format.Number(numFilteredOut))
+		fmt.Printf("│%-*s│\n", colwidth-2, msg)
+	}
+
+	fmt.Printf("└%s┘\n", rule)
+}
+
+func writeChurnCsv(rows []churnRow, byLines bool) error {
+	w := csv.NewWriter(os.Stdout)
+
+	w.Write([]string{
+		"path",
+		"directory",
+		"commits",
+		"lines added",
+		"lines removed",
+		"authors",
+		"score",
+	})
+
+	for _, row := range rows {
+		record := []string{
+			row.path,
+			strconv.FormatBool(row.isDir),
+			strconv.Itoa(row.commits),
+			strconv.Itoa(row.linesAdded),
+			strconv.Itoa(row.linesRemoved),
+			strconv.Itoa(row.authors),
+			strconv.Itoa(row.score(byLines)),
+		}
+		if err := w.Write(record); err != nil {
+			return fmt.Errorf("error writing CSV record to stdout: %w", err)
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("error flushing CSV writer: %w", err)
+	}
+
+	return nil
+}
+
+type jsonChurnMeta struct {
+	jsonMeta
+	TotalPaths     int `json:"total_paths"`
+	NumFilteredOut int `json:"num_filtered_out"` // Paths cut off by -n
+}
+
+type jsonChurnRow struct {
+	Path         string `json:"path"`
+	IsDir        bool   `json:"is_dir"`
+	Commits      int    `json:"commits"`
+	LinesAdded   int    `json:"lines_added"`
+	LinesRemoved int    `json:"lines_removed"`
+	Authors      int    `json:"authors"`
+	Score        int    `json:"score"`
+}
+
+type jsonChurn struct {
+	jsonChurnMeta
+	Paths []jsonChurnRow `json:"paths"`
+}
+
+// Writes the rows as a single JSON document, or, for NDJSON, as a metadata line
+// followed by one line per path.
+func writeChurnJson(
+	rows []churnRow,
+	byLines bool,
+	meta jsonChurnMeta,
+	format OutputFormat,
+) error {
+	w := bufio.NewWriter(os.Stdout)
+
+	paths := []jsonChurnRow{}
+	for _, row := range rows {
+		paths = append(paths, jsonChurnRow{
+			Path:         row.path,
+			IsDir:        row.isDir,
+			Commits:      row.commits,
+			LinesAdded:   row.linesAdded,
+			LinesRemoved: row.linesRemoved,
+			Authors:      row.authors,
+			Score:        row.score(byLines),
+		})
+	}
+
+	if format == NdjsonOutput {
+		err := writeJsonValue(w, meta, format)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range paths {
+			err := writeJsonValue(w, p, format)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		err := writeJsonValue(w, jsonChurn{jsonChurnMeta: meta, Paths: paths}, format)
+		if err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,278 @@
+package subcommands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/sinclairtarget/git-who/internal/codeowners"
+	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/git/cmd"
+	"github.com/sinclairtarget/git-who/internal/tally"
+)
+
+type ownersOpts struct {
+	mode        tally.TallyMode
+	maxDepth    int
+	maxOwners   int
+	minShare    float64   // Fraction of the directory's total, 0 to 1
+	activeSince time.Time // Owners must have committed since this time
+	handles     codeowners.Handles
+}
+
+func Codeowners(
+	revs []string,
+	pathspecs []string,
+	mode tally.TallyMode,
+	depth int,
+	maxOwners int,
+	minShare float64,
+	activeSince string,
+	handlesPath string,
+	outputPath string,
+	countMerges bool,
+	since string,
+	until string,
+	authors []string,
+	nauthors []string,
+) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error running \"codeowners\": %w", err)
+		}
+	}()
+
+	logger().Debug(
+		"called codeowners()",
+		"revs",
+		revs,
+		"pathspecs",
+		pathspecs,
+		"mode",
+		mode,
+		"depth",
+		depth,
+		"maxOwners",
+		maxOwners,
+		"minShare",
+		minShare,
+		"activeSince",
+		activeSince,
+		"handlesPath",
+		handlesPath,
+		"outputPath",
+		outputPath,
+		"countMerges",
+		countMerges,
+		"since",
+		since,
+		"until",
+		until,
+		"authors",
+		authors,
+		"nauthors",
+		nauthors,
+	)
+
+	opts := ownersOpts{
+		mode:      mode,
+		maxDepth:  depth,
+		maxOwners: maxOwners,
+		minShare:  minShare,
+	}
+	if depth == 0 {
+		opts.maxDepth = defaultMaxDepth
+	}
+
+	if activeSince != "" {
+		opts.activeSince, err = git.ParseDate(activeSince)
+		if err != nil {
+			return err
+		}
+	}
+
+	if handlesPath != "" {
+		opts.handles, err = codeowners.ReadHandlesFile(handlesPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	filters := cmd.LogFilters{
+		Since:    since,
+		Until:    until,
+		Authors:  authors,
+		Nauthors: nauthors,
+	}
+
+	// Owners are identified by email, since that is what we map to handles
+	tallyOpts := tally.TallyOpts{
+		Mode:        mode,
+		CountMerges: countMerges,
+		Key:         func(c git.Commit) string { return c.AuthorEmail },
+	}
+
+	followRenames := false
+	root, err := tallyTree(
+		ctx,
+		revs,
+		pathspecs,
+		filters,
+		tallyOpts,
+		followRenames,
+	)
+	if err != nil && err != tally.EmptyTreeErr {
+		return err
+	}
+
+	rules := []codeowners.Rule{}
+	if err != tally.EmptyTreeErr {
+		root = root.Rank(tallyOpts)
+
+		// CODEOWNERS paths are relative to the repo root, not the working dir
+		dir, err := workingDirFromRoot()
+		if err != nil {
+			return err
+		}
+
+		rules = toRules(root, dir, 0, []string{}, opts, rules)
+	}
+
+	header := []string{
+		"Generated by git-who from " + mode.String() + " per author.",
+		"Regenerate with \"git who codeowners\" rather than editing by hand.",
+	}
+
+	var w io.Writer = os.Stdout
+	if outputPath != "" && outputPath != "-" {
+		f, err := os.Create(outputPath)
+		if err != nil {
+			return fmt.Errorf("could not create output file: %w", err)
+		}
+		defer f.Close()
+
+		w = f
+	}
+
+	err = codeowners.Write(w, header, rules)
+	if err != nil {
+		return fmt.Errorf("error writing CODEOWNERS: %w", err)
+	}
+
+	return nil
+}
+
+// Returns the working directory as a forward-slash path relative to the root of
+// the repository.
+func workingDirFromRoot() (string, error) {
+	gitRootPath, err := git.GetRoot()
+	if err != nil {
+		return "", err
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(filepath.FromSlash(gitRootPath), wd)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.ToSlash(rel), nil
+}
+
+// Recursively descend tree, emitting a rule for every directory whose owners
+// differ from the owners it would otherwise inherit.
+//
+// Directories with no author qualifying as an owner inherit their parent's
+// owners.
+func toRules(
+	node *tally.TreeNode,
+	dir string,
+	depth int,
+	inherited []string,
+	opts ownersOpts,
+	rules []codeowners.Rule,
+) []codeowners.Rule {
+	if depth > opts.maxDepth || len(node.Children) == 0 || !node.InWorkTree {
+		return rules
+	}
+
+	owners := pickOwners(node, opts)
+	if len(owners) > 0 && !sameOwners(owners, inherited) {
+		rules = append(rules, codeowners.Rule{
+			Pattern: codeowners.DirPattern(dir),
+			Owners:  owners,
+		})
+		inherited = owners
+	}
+
+	for _, childPath := range sortedChildPaths(node) {
+		rules = toRules(
+			node.Children[childPath],
+			path.Join(dir, childPath),
+			depth+1,
+			inherited,
+			opts,
+			rules,
+		)
+	}
+
+	return rules
+}
+
+// Picks up to maxOwners authors with at least minShare of the node's total who
+// have been active recently enough. Returns handles, best first.
+func pickOwners(node *tally.TreeNode, opts ownersOpts) []string {
+	total := 0
+	for _, t := range node.Ranked {
+		total += tally.TimelineValue(t, opts.mode)
+	}
+
+	owners := []string{}
+	if total == 0 {
+		return owners
+	}
+
+	for _, t := range node.Ranked {
+		if len(owners) >= opts.maxOwners {
+			break
+		}
+
+		share := float64(tally.TimelineValue(t, opts.mode)) / float64(total)
+		if share < opts.minShare {
+			break // Ranked best first, so nobody after this qualifies either
+		}
+
+		if t.LastCommitTime.Before(opts.activeSince) {
+			continue
+		}
+
+		handle := opts.handles.Lookup(t.AuthorName, t.AuthorEmail)
+		if handle == "" || slices.Contains(owners, handle) {
+			continue
+		}
+
+		owners = append(owners, handle)
+	}
+
+	return owners
+}
+
+// Whether two lists of owners contain the same owners, ignoring order.
+func sameOwners(a []string, b []string) bool {
+	return slices.Equal(
+		slices.Sorted(slices.Values(a)),
+		slices.Sorted(slices.Values(b)),
+	)
+}
@@ -0,0 +1,437 @@
+package subcommands
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sinclairtarget/git-who/internal/cache"
+	"github.com/sinclairtarget/git-who/internal/codeowners"
+	"github.com/sinclairtarget/git-who/internal/concurrent"
+	"github.com/sinclairtarget/git-who/internal/format"
+	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/git/cmd"
+	"github.com/sinclairtarget/git-who/internal/git/config"
+	"github.com/sinclairtarget/git-who/internal/pretty"
+	"github.com/sinclairtarget/git-who/internal/tally"
+)
+
+// A rule listing owners who haven't committed to the rule's paths recently.
+type staleRule struct {
+	rule   codeowners.Rule
+	owners []string
+}
+
+// A path whose top contributor isn't one of its owners.
+type unlistedContributor struct {
+	path   string
+	top    tally.FinalTally
+	owners []string
+}
+
+func CodeownersCheck(
+	revs []string,
+	pathspecs []string,
+	mode tally.TallyMode,
+	codeownersPath string,
+	activeSince string,
+	handlesPath string,
+	countMerges bool,
+	since string,
+	until string,
+	authors []string,
+	nauthors []string,
+) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error running \"codeowners check\": %w", err)
+		}
+	}()
+
+	logger().Debug(
+		"called codeownersCheck()",
+		"revs",
+		revs,
+		"pathspecs",
+		pathspecs,
+		"mode",
+		mode,
+		"codeownersPath",
+		codeownersPath,
+		"activeSince",
+		activeSince,
+		"handlesPath",
+		handlesPath,
+		"countMerges",
+		countMerges,
+		"since",
+		since,
+		"until",
+		until,
+		"authors",
+		authors,
+		"nauthors",
+		nauthors,
+	)
+
+	gitRootPath, err := git.GetRoot()
+	if err != nil {
+		return err
+	}
+
+	if codeownersPath == "" {
+		codeownersPath, err = codeowners.Find(gitRootPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	file, err := codeowners.ReadFile(codeownersPath)
+	if err != nil {
+		return err
+	}
+
+	var handles codeowners.Handles
+	if handlesPath != "" {
+		handles, err = codeowners.ReadHandlesFile(handlesPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	activeSinceTime, err := git.ParseDate(activeSince)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	filters := cmd.LogFilters{
+		Since:    since,
+		Until:    until,
+		Authors:  authors,
+		Nauthors: nauthors,
+	}
+
+	tallyOpts := tally.TallyOpts{
+		Mode:        mode,
+		CountMerges: countMerges,
+		Key:         func(c git.Commit) string { return c.AuthorEmail },
+	}
+
+	talliesByPath, err := tallyByPath(
+		ctx,
+		revs,
+		pathspecs,
+		filters,
+		tallyOpts,
+		git.RenameHistory{},
+	)
+	if err != nil {
+		return err
+	}
+
+	wtreeset, err := worktreeFromRoot(pathspecs)
+	if err != nil {
+		return err
+	}
+
+	stale, unlisted := checkCodeowners(
+		file,
+		talliesByPath,
+		wtreeset,
+		mode,
+		activeSinceTime,
+		handles,
+	)
+
+	printCodeownersCheck(codeownersPath, stale, unlisted, activeSinceTime, mode)
+
+	// Without a handles file, we can't tell which author an owner's handle
+	// belongs to, so we never report those owners as stale
+	if handlesPath == "" && hasHandleOwners(file) {
+		fmt.Println()
+		fmt.Println(
+			"Note: owners given as handles were not checked for recent " +
+				"activity; use --handles to map authors to handles",
+		)
+	}
+
+	if problems := len(stale) + len(unlisted); problems > 0 {
+		return fmt.Errorf(
+			"%s does not match history (%d problems found)",
+			codeownersPath,
+			problems,
+		)
+	}
+
+	return nil
+}
+
+// Tallies commits by author and then by path, following the given renames
+// (see tally.FollowRenames()).
+//
+// Paths are relative to the repository root.
+func tallyByPath(
+	ctx context.Context,
+	revs []string,
+	pathspecs []string,
+	filters cmd.LogFilters,
+	tallyOpts tally.TallyOpts,
+	renames git.RenameHistory,
+) (tally.TalliesByPath, error) {
+	gitRootPath, err := git.GetRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	configFiles, err := config.DetectSupplementalFiles(gitRootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if runtime.GOMAXPROCS(0) > 1 {
+		return concurrent.TallyCommitsByPath(
+			ctx,
+			revs,
+			pathspecs,
+			filters,
+			configFiles,
+			tallyOpts,
+			renames,
+			cache.GetCache(gitRootPath),
+			pretty.AllowDynamic(os.Stdout),
+		)
+	}
+
+	return func() (_ tally.TalliesByPath, err error) {
+		commits, finish := git.CommitsWithOpts(
+			ctx,
+			revs,
+			pathspecs,
+			filters,
+			true,
+			configFiles,
+		)
+		defer func() { err = finish() }()
+
+		return tally.TallyCommitsByPath(
+			tally.FollowRenames(commits, renames),
+			tallyOpts,
+		)
+	}()
+}
+
+// Returns the working tree files under the given pathspecs, with paths relative
+// to the repository root.
+func worktreeFromRoot(pathspecs []string) (map[string]bool, error) {
+	wtreeset, err := git.WorkingTreeFiles(pathspecs)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := workingDirFromRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	fromRoot := map[string]bool{}
+	for p := range wtreeset {
+		fromRoot[path.Join(dir, p)] = true
+	}
+
+	return fromRoot, nil
+}
+
+// Compares the rules in a CODEOWNERS file against who actually edits the files
+// in the working tree.
+//
+// Owners listed by handle can only be checked for activity if the handles file
+// maps some author to that handle. Other owners (often teams) are never
+// reported as stale.
+func checkCodeowners(
+	file codeowners.File,
+	talliesByPath tally.TalliesByPath,
+	wtreeset map[string]bool,
+	mode tally.TallyMode,
+	activeSince time.Time,
+	handles codeowners.Handles,
+) ([]staleRule, []unlistedContributor) {
+	// path -> author -> tally
+	byAuthor := map[string]map[string]tally.Tally{}
+	for key, pathTallies := range talliesByPath {
+		for p, t := range pathTallies {
+			if !wtreeset[p] {
+				continue
+			}
+
+			if _, ok := byAuthor[p]; !ok {
+				byAuthor[p] = map[string]tally.Tally{}
+			}
+			byAuthor[p][key] = t
+		}
+	}
+
+	// Rule line -> owner -> last commit to a path governed by the rule
+	lastActive := map[int]map[string]time.Time{}
+
+	unlisted := []unlistedContributor{}
+	for _, p := range slices.Sorted(maps.Keys(byAuthor)) {
+		ranked := tally.Rank(byAuthor[p], tally.TallyOpts{Mode: mode})
+
+		rules := file.Match(p)
+		owners := []string{}
+		for _, rule := range rules {
+			if _, ok := lastActive[rule.Line]; !ok {
+				lastActive[rule.Line] = map[string]time.Time{}
+			}
+
+			for _, t := range ranked {
+				handle := strings.ToLower(
+					handles.Lookup(t.AuthorName, t.AuthorEmail),
+				)
+
+				last := lastActive[rule.Line][handle]
+				if t.LastCommitTime.After(last) {
+					lastActive[rule.Line][handle] = t.LastCommitTime
+				}
+			}
+
+			owners = append(owners, rule.Owners...)
+		}
+
+		if len(owners) == 0 {
+			continue // Unowned paths have no owners to disagree with
+		}
+
+		top := ranked[0]
+		handle := handles.Lookup(top.AuthorName, top.AuthorEmail)
+		if !slices.ContainsFunc(owners, func(owner string) bool {
+			return strings.EqualFold(owner, handle)
+		}) {
+			unlisted = append(unlisted, unlistedContributor{
+				path:   p,
+				top:    top,
+				owners: owners,
+			})
+		}
+	}
+
+	stale := []staleRule{}
+	for _, rule := range file.Rules {
+		active, ok := lastActive[rule.Line]
+		if !ok {
+			continue // Rule doesn't govern any paths with history
+		}
+
+		staleOwners := []string{}
+		for _, owner := range rule.Owners {
+			if !isEmail(owner) && !handles.Contains(owner) {
+				continue // Can't tell who this is
+			}
+
+			if active[strings.ToLower(owner)].Before(activeSince) {
+				staleOwners = append(staleOwners, owner)
+			}
+		}
+
+		if len(staleOwners) > 0 {
+			stale = append(stale, staleRule{rule: rule, owners: staleOwners})
+		}
+	}
+
+	return stale, unlisted
+}
+
+// CODEOWNERS files name owners either by "@handle" or by email address.
+func isEmail(owner string) bool {
+	return !strings.HasPrefix(owner, "@") && strings.Contains(owner, "@")
+}
+
+// Whether any rule names an owner by handle rather than by email address.
+func hasHandleOwners(file codeowners.File) bool {
+	for _, rule := range file.Rules {
+		if slices.ContainsFunc(rule.Owners, func(owner string) bool {
+			return !isEmail(owner)
+		}) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func printCodeownersCheck(
+	codeownersPath string,
+	stale []staleRule,
+	unlisted []unlistedContributor,
+	activeSince time.Time,
+	mode tally.TallyMode,
+) {
+	if len(stale) == 0 && len(unlisted) == 0 {
+		fmt.Printf("%s matches history\n", codeownersPath)
+		return
+	}
+
+	if len(stale) > 0 {
+		fmt.Printf(
+			"Owners with no commits since %s:\n",
+			activeSince.Format(time.DateOnly),
+		)
+
+		for _, s := range stale {
+			fmt.Printf(
+				"  line %d: %s %s%s%s\n",
+				s.rule.Line,
+				s.rule.Pattern,
+				pretty.Red,
+				strings.Join(s.owners, " "),
+				pretty.DefaultColor,
+			)
+		}
+	}
+
+	if len(unlisted) > 0 {
+		if len(stale) > 0 {
+			fmt.Println()
+		}
+
+		fmt.Println("Paths whose top contributor is not an owner:")
+
+		for _, u := range unlisted {
+			fmt.Printf(
+				"  %s: %s%s%s (%s) not in %s\n",
+				u.path,
+				pretty.Red,
+				format.GitEmail(u.top.AuthorEmail),
+				pretty.DefaultColor,
+				fmtCheckMetric(u.top, mode),
+				strings.Join(u.owners, " "),
+			)
+		}
+	}
+}
+
+func fmtCheckMetric(t tally.FinalTally, mode tally.TallyMode) string {
+	switch mode {
+	case tally.LinesMode:
+		return fmt.Sprintf(
+			"%s / %s lines",
+			format.Number(t.LinesAdded),
+			format.Number(t.LinesRemoved),
+		)
+	default:
+		if t.Commits == 1 {
+			return "1 commit"
+		}
+
+		return fmt.Sprintf("%s commits", format.Number(t.Commits))
+	}
+}
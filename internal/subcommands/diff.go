@@ -0,0 +1,728 @@
+package subcommands
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sinclairtarget/git-who/internal/format"
+	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/git/cmd"
+	"github.com/sinclairtarget/git-who/internal/pretty"
+	"github.com/sinclairtarget/git-who/internal/tally"
+)
+
+// An author's or directory's metrics in one of the two ranges compared.
+type diffSide struct {
+	commits int
+	lines   int    // Lines added + lines removed
+	rank    int    // Author's rank, starting at 1. Zero for directories
+	owner   string // Top author of a directory. Empty for authors
+}
+
+// Compares an author or directory across the two ranges.
+type diffRow struct {
+	name   string    // Author name or directory path
+	email  string    // Empty for directories
+	before *diffSide // Nil if absent from the first range
+	after  *diffSide // Nil if absent from the second range
+}
+
+func (r diffRow) side(after bool) diffSide {
+	s := r.before
+	if after {
+		s = r.after
+	}
+
+	if s == nil {
+		return diffSide{}
+	}
+
+	return *s
+}
+
+func (r diffRow) commitsChange() int {
+	return r.side(true).commits - r.side(false).commits
+}
+
+func (r diffRow) linesChange() int {
+	return r.side(true).lines - r.side(false).lines
+}
+
+func (r diffRow) change(mode tally.TallyMode) int {
+	if mode == tally.LinesMode {
+		return r.linesChange()
+	}
+
+	return r.commitsChange()
+}
+
+// The "diff" subcommand compares who contributed to two revision ranges, e.g.
+// two releases, by author and by directory.
+func Diff(
+	rangeBefore string,
+	rangeAfter string,
+	revsBefore []string,
+	revsAfter []string,
+	pathspecs []string,
+	mode tally.TallyMode,
+	outputFormat OutputFormat,
+	depth int,
+	limit int,
+	showEmail bool,
+	countMerges bool,
+	since string,
+	until string,
+	authors []string,
+	nauthors []string,
+) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error running \"diff\": %w", err)
+		}
+	}()
+
+	logger().Debug(
+		"called diff()",
+		"rangeBefore",
+		rangeBefore,
+		"rangeAfter",
+		rangeAfter,
+		"revsBefore",
+		revsBefore,
+		"revsAfter",
+		revsAfter,
+		"pathspecs",
+		pathspecs,
+		"mode",
+		mode,
+		"outputFormat",
+		outputFormat,
+		"depth",
+		depth,
+		"limit",
+		limit,
+		"showEmail",
+		showEmail,
+		"countMerges",
+		countMerges,
+		"since",
+		since,
+		"until",
+		until,
+		"authors",
+		authors,
+		"nauthors",
+		nauthors,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	filters := cmd.LogFilters{
+		Since:    since,
+		Until:    until,
+		Authors:  authors,
+		Nauthors: nauthors,
+	}
+
+	tallyOpts := tally.TallyOpts{Mode: mode, CountMerges: countMerges}
+	key := func(t tally.FinalTally) string { return t.AuthorName }
+	if showEmail {
+		tallyOpts.Key = func(c git.Commit) string { return c.AuthorEmail }
+		key = func(t tally.FinalTally) string { return t.AuthorEmail }
+	} else {
+		tallyOpts.Key = func(c git.Commit) string { return c.AuthorName }
+	}
+
+	// Both ranges are tallied the same way, so the second range can make use
+	// of any commits the first range added to the cache
+	rankedTree := func(revs []string) (*tally.TreeNode, error) {
+		followRenames := false
+		root, err := tallyTree(
+			ctx,
+			revs,
+			pathspecs,
+			filters,
+			tallyOpts,
+			followRenames,
+		)
+		if err == tally.EmptyTreeErr {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		return root.Rank(tallyOpts), nil
+	}
+
+	before, err := rankedTree(revsBefore)
+	if err != nil {
+		return err
+	}
+
+	after, err := rankedTree(revsAfter)
+	if err != nil {
+		return err
+	}
+
+	authorRows := diffAuthorRows(before, after, key)
+	dirRows := diffDirRows(before, after, depth, mode, key)
+
+	totalAuthors := len(authorRows)
+	totalDirs := len(dirRows)
+
+	if limit > 0 && limit < len(authorRows) {
+		authorRows = authorRows[:limit]
+	}
+
+	if limit > 0 && limit < len(dirRows) {
+		dirRows = dirRows[:limit]
+	}
+
+	switch outputFormat {
+	case CsvOutput:
+		return writeDiffCsv(authorRows, dirRows, showEmail)
+	case JsonOutput, NdjsonOutput:
+		meta := jsonDiffMeta{
+			Before: toJsonMeta(revsBefore, pathspecs, filters, mode),
+			After:  toJsonMeta(revsAfter, pathspecs, filters, mode),
+
+			TotalAuthors:              totalAuthors,
+			TotalDirectories:          totalDirs,
+			NumAuthorsFilteredOut:     totalAuthors - len(authorRows),
+			NumDirectoriesFilteredOut: totalDirs - len(dirRows),
+		}
+		return writeDiffJson(authorRows, dirRows, meta, outputFormat)
+	}
+
+	fmt.Printf("Change from %s to %s:\n", rangeBefore, rangeAfter)
+	writeDiffAuthors(authorRows, showEmail, totalAuthors-len(authorRows))
+
+	fmt.Println()
+	writeDiffDirs(dirRows, totalDirs-len(dirRows))
+	return nil
+}
+
+// Returns a row for every author in either range. Authors are listed in the
+// order they rank in the second range, followed by authors who only
+// contributed to the first range.
+func diffAuthorRows(
+	before *tally.TreeNode,
+	after *tally.TreeNode,
+	key func(t tally.FinalTally) string,
+) []diffRow {
+	rows := []diffRow{}
+	index := map[string]int{}
+
+	if after != nil {
+		for i, t := range after.Ranked {
+			index[key(t)] = len(rows)
+			rows = append(rows, diffRow{
+				name:  t.AuthorName,
+				email: t.AuthorEmail,
+				after: &diffSide{
+					commits: t.Commits,
+					lines:   t.LinesAdded + t.LinesRemoved,
+					rank:    i + 1,
+				},
+			})
+		}
+	}
+
+	if before != nil {
+		for i, t := range before.Ranked {
+			side := &diffSide{
+				commits: t.Commits,
+				lines:   t.LinesAdded + t.LinesRemoved,
+				rank:    i + 1,
+			}
+
+			if j, ok := index[key(t)]; ok {
+				rows[j].before = side
+			} else {
+				rows = append(rows, diffRow{
+					name:   t.AuthorName,
+					email:  t.AuthorEmail,
+					before: side,
+				})
+			}
+		}
+	}
+
+	return rows
+}
+
+// Returns a row for every directory down to the given depth in either range.
+// Directories that changed the most come first.
+func diffDirRows(
+	before *tally.TreeNode,
+	after *tally.TreeNode,
+	depth int,
+	mode tally.TallyMode,
+	key func(t tally.FinalTally) string,
+) []diffRow {
+	rowsByPath := map[string]*diffRow{}
+
+	var walk func(node *tally.TreeNode, p string, d int, isAfter bool)
+	walk = func(node *tally.TreeNode, p string, d int, isAfter bool) {
+		side := &diffSide{owner: key(node.Tally)}
+		for _, t := range node.Ranked {
+			side.commits += t.Commits
+			side.lines += t.LinesAdded + t.LinesRemoved
+		}
+
+		row, ok := rowsByPath[p]
+		if !ok {
+			row = &diffRow{name: p}
+			rowsByPath[p] = row
+		}
+
+		if isAfter {
+			row.after = side
+		} else {
+			row.before = side
+		}
+
+		if d >= depth {
+			return
+		}
+
+		for childPath, child := range node.Children {
+			if len(child.Children) > 0 {
+				walk(child, path.Join(p, childPath), d+1, isAfter)
+			}
+		}
+	}
+
+	if before != nil {
+		walk(before, ".", 0, false)
+	}
+
+	if after != nil {
+		walk(after, ".", 0, true)
+	}
+
+	rows := []diffRow{}
+	for _, row := range rowsByPath {
+		rows = append(rows, *row)
+	}
+
+	abs := func(n int) int { return max(n, -n) }
+	slices.SortFunc(rows, func(a, b diffRow) int {
+		return cmp.Or(
+			-cmp.Compare(abs(a.change(mode)), abs(b.change(mode))),
+			strings.Compare(a.name, b.name),
+		)
+	})
+
+	return rows
+}
+
+// Formats a change in some metric, e.g. "+12" or "-3".
+func fmtChange(n int) string {
+	if n > 0 {
+		return "+" + format.Number(n)
+	} else if n < 0 {
+		return "-" + format.Number(-n)
+	}
+
+	return "0"
+}
+
+// Colors a formatted change, padded to the given width.
+func colorChange(n int, width int) string {
+	color := ""
+	if n > 0 {
+		color = pretty.Green
+	} else if n < 0 {
+		color = pretty.Red
+	}
+
+	return fmt.Sprintf(
+		"%s%*s%s",
+		color,
+		width,
+		fmtChange(n),
+		pretty.DefaultColor,
+	)
+}
+
+// Describes a before and after value, e.g. "2 → 1". A missing value is shown
+// as a dash.
+func fmtTransition(before string, after string) string {
+	if before == "" {
+		before = "-"
+	}
+
+	if after == "" {
+		after = "-"
+	}
+
+	if before == after {
+		return after
+	}
+
+	return fmt.Sprintf("%s → %s", before, after)
+}
+
+func fmtRank(s *diffSide) string {
+	if s == nil {
+		return ""
+	}
+
+	return strconv.Itoa(s.rank)
+}
+
+func fmtOwner(s *diffSide) string {
+	if s == nil {
+		return ""
+	}
+
+	return s.owner
+}
+
+func writeDiffAuthors(rows []diffRow, showEmail bool, numFilteredOut int) {
+	colwidth := wideWidth
+	authorWidth := colwidth - 2 - 10 - 9 - 8 - 10 - 10
+
+	var build strings.Builder
+	for _ = range colwidth - 2 {
+		build.WriteRune('─')
+	}
+	rule := build.String()
+
+	// -- Write header --
+	fmt.Printf("┌%s┐\n", rule)
+	fmt.Printf(
+		"│%-*s %9s %8s %7s %9s %9s│\n",
+		authorWidth,
+		"Author",
+		"Rank",
+		"Commits",
+		"Change",
+		"Lines",
+		"Change",
+	)
+	fmt.Printf("├%s┤\n", rule)
+
+	// -- Write table rows --
+	totalRows := len(rows)
+	for i, row := range rows {
+		alternating := ""
+		if totalRows > maxBeforeColorAlternating && i%2 == 1 {
+			alternating = pretty.Invert
+		}
+
+		name := row.name
+		if showEmail {
+			name = row.email
+		}
+
+		fmt.Printf(
+			"│%s%-*s %9s %8s %s %9s %s%s│\n",
+			alternating,
+			authorWidth,
+			format.Abbrev(name, authorWidth),
+			fmtTransition(fmtRank(row.before), fmtRank(row.after)),
+			format.Number(row.side(true).commits),
+			colorChange(row.commitsChange(), 7),
+			format.Number(row.side(true).lines),
+			colorChange(row.linesChange(), 9),
+			pretty.Reset,
+		)
+	}
+
+	if numFilteredOut > 0 {
+		msg := fmt.Sprintf("...%s more...", format.Number(numFilteredOut))
+		fmt.Printf("│%-*s│\n", colwidth-2, msg)
+	}
+
+	fmt.Printf("└%s┘\n", rule)
+}
+
+func writeDiffDirs(rows []diffRow, numFilteredOut int) {
+	colwidth := wideWidth
+	pathWidth := 24
+	ownerWidth := colwidth - 2 - pathWidth - 9 - 8 - 10 - 10 - 2
+
+	var build strings.Builder
+	for _ = range colwidth - 2 {
+		build.WriteRune('─')
+	}
+	rule := build.String()
+
+	// -- Write header --
+	fmt.Printf("┌%s┐\n", rule)
+	fmt.Printf(
+		"│%-*s %8s %7s %9s %9s  %-*s│\n",
+		pathWidth,
+		"Directory",
+		"Commits",
+		"Change",
+		"Lines",
+		"Change",
+		ownerWidth,
+		"Top Author",
+	)
+	fmt.Printf("├%s┤\n", rule)
+
+	// -- Write table rows --
+	totalRows := len(rows)
+	for i, row := range rows {
+		alternating := ""
+		if totalRows > maxBeforeColorAlternating && i%2 == 1 {
+			alternating = pretty.Invert
+		}
+
+		dir := row.name + "/"
+		if row.name == "." {
+			dir = "."
+		}
+
+		fmt.Printf(
+			"│%s%-*s %8s %s %9s %s  %-*s%s│\n",
+			alternating,
+			pathWidth,
+			format.Abbrev(dir, pathWidth),
+			format.Number(row.side(true).commits),
+			colorChange(row.commitsChange(), 7),
+			format.Number(row.side(true).lines),
+			colorChange(row.linesChange(), 9),
+			ownerWidth,
+			format.Abbrev(
+				fmtTransition(fmtOwner(row.before), fmtOwner(row.after)),
+				ownerWidth,
+			),
+			pretty.Reset,
+		)
+	}
+
+	if numFilteredOut > 0 {
+		msg := fmt.Sprintf("...%s more...", format.Number(numFilteredOut))
+		fmt.Printf("│%-*s│\n", colwidth-2, msg)
+	}
+
+	fmt.Printf("└%s┘\n", rule)
+}
+
+// Returns the CSV cells for one side of a row. Cells are empty if the author
+// or directory is absent from that range.
+func diffSideRecord(s *diffSide, isAuthor bool) []string {
+	if s == nil {
+		return []string{"", "", ""}
+	}
+
+	extra := s.owner
+	if isAuthor {
+		extra = strconv.Itoa(s.rank)
+	}
+
+	return []string{strconv.Itoa(s.commits), strconv.Itoa(s.lines), extra}
+}
+
+// Writes one row per author and then one row per directory. The "kind" column
+// tells them apart.
+func writeDiffCsv(
+	authorRows []diffRow,
+	dirRows []diffRow,
+	showEmail bool,
+) error {
+	w := csv.NewWriter(os.Stdout)
+
+	columnHeaders := []string{"kind", "name"}
+	if showEmail {
+		columnHeaders = append(columnHeaders, "email")
+	}
+
+	columnHeaders = append(
+		columnHeaders,
+		"commits before",
+		"lines before",
+		"rank before",
+		"top author before",
+		"commits after",
+		"lines after",
+		"rank after",
+		"top author after",
+		"commits change",
+		"lines change",
+	)
+	w.Write(columnHeaders)
+
+	write := func(kind string, row diffRow) error {
+		isAuthor := kind == "author"
+
+		record := []string{kind, row.name}
+		if showEmail {
+			record = append(record, row.email)
+		}
+
+		for _, s := range []*diffSide{row.before, row.after} {
+			cells := diffSideRecord(s, isAuthor)
+			if isAuthor {
+				record = append(record, cells[0], cells[1], cells[2], "")
+			} else {
+				record = append(record, cells[0], cells[1], "", cells[2])
+			}
+		}
+
+		record = append(
+			record,
+			strconv.Itoa(row.commitsChange()),
+			strconv.Itoa(row.linesChange()),
+		)
+
+		if err := w.Write(record); err != nil {
+			return fmt.Errorf("error writing CSV record to stdout: %w", err)
+		}
+
+		return nil
+	}
+
+	for _, row := range authorRows {
+		if err := write("author", row); err != nil {
+			return err
+		}
+	}
+
+	for _, row := range dirRows {
+		if err := write("directory", row); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("error flushing CSV writer: %w", err)
+	}
+
+	return nil
+}
+
+type jsonDiffMeta struct {
+	Before jsonMeta `json:"before"`
+	After  jsonMeta `json:"after"`
+
+	TotalAuthors              int `json:"total_authors"`
+	TotalDirectories          int `json:"total_directories"`
+	NumAuthorsFilteredOut     int `json:"num_authors_filtered_out"`
+	NumDirectoriesFilteredOut int `json:"num_directories_filtered_out"`
+}
+
+// Serialized form of a diffSide. Authors have a rank but no top author, while
+// directories have a top author but no rank.
+type jsonDiffSide struct {
+	Commits   int     `json:"commits"`
+	Lines     int     `json:"lines"`
+	Rank      *int    `json:"rank"`
+	TopAuthor *string `json:"top_author"`
+}
+
+type jsonDiffRow struct {
+	Kind          string        `json:"kind"`
+	Name          string        `json:"name"`
+	Email         *string       `json:"email"`
+	Before        *jsonDiffSide `json:"before"`
+	After         *jsonDiffSide `json:"after"`
+	CommitsChange int           `json:"commits_change"`
+	LinesChange   int           `json:"lines_change"`
+}
+
+type jsonDiff struct {
+	jsonDiffMeta
+	Authors     []jsonDiffRow `json:"authors"`
+	Directories []jsonDiffRow `json:"directories"`
+}
+
+func toJsonDiffRow(kind string, row diffRow) jsonDiffRow {
+	isAuthor := kind == "author"
+
+	toSide := func(s *diffSide) *jsonDiffSide {
+		if s == nil {
+			return nil
+		}
+
+		js := jsonDiffSide{Commits: s.commits, Lines: s.lines}
+		if isAuthor {
+			js.Rank = &s.rank
+		} else {
+			js.TopAuthor = &s.owner
+		}
+
+		return &js
+	}
+
+	jr := jsonDiffRow{
+		Kind:          kind,
+		Name:          row.name,
+		Before:        toSide(row.before),
+		After:         toSide(row.after),
+		CommitsChange: row.commitsChange(),
+		LinesChange:   row.linesChange(),
+	}
+
+	if isAuthor {
+		jr.Email = &row.email
+	}
+
+	return jr
+}
+
+// Writes the comparison as a single JSON document, or, for NDJSON, as a
+// metadata line followed by one line per author and then one line per
+// directory.
+func writeDiffJson(
+	authorRows []diffRow,
+	dirRows []diffRow,
+	meta jsonDiffMeta,
+	format OutputFormat,
+) error {
+	w := bufio.NewWriter(os.Stdout)
+
+	authors := []jsonDiffRow{}
+	for _, row := range authorRows {
+		authors = append(authors, toJsonDiffRow("author", row))
+	}
+
+	dirs := []jsonDiffRow{}
+	for _, row := range dirRows {
+		dirs = append(dirs, toJsonDiffRow("directory", row))
+	}
+
+	if format == NdjsonOutput {
+		err := writeJsonValue(w, meta, format)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range slices.Concat(authors, dirs) {
+			err := writeJsonValue(w, row, format)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		err := writeJsonValue(
+			w,
+			jsonDiff{
+				jsonDiffMeta: meta,
+				Authors:      authors,
+				Directories:  dirs,
+			},
+			format,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
@@ -8,9 +8,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/sinclairtarget/git-who/internal/git"
 	"github.com/sinclairtarget/git-who/internal/git/cmd"
-	"github.com/sinclairtarget/git-who/internal/git/config"
 )
 
 // Just prints out the output of git log as seen by git who.
@@ -20,8 +18,6 @@ func Dump(
 	short bool,
 	since string,
 	until string,
-	authors []string,
-	nauthors []string,
 ) (err error) {
 	defer func() {
 		if err != nil {
@@ -41,10 +37,6 @@ func Dump(
 		since,
 		"until",
 		until,
-		"authors",
-		authors,
-		"nauthors",
-		nauthors,
 	)
 
 	start := time.Now()
@@ -53,20 +45,8 @@ func Dump(
 	defer cancel()
 
 	filters := cmd.LogFilters{
-		Since:    since,
-		Until:    until,
-		Authors:  authors,
-		Nauthors: nauthors,
-	}
-
-	gitRootPath, err := git.GetRoot()
-	if err != nil {
-		return err
-	}
-
-	configFiles, err := config.DetectSupplementalFiles(gitRootPath)
-	if err != nil {
-		return err
+		Since: since,
+		Until: until,
 	}
 
 	subprocess, err := cmd.RunLog(
@@ -75,7 +55,6 @@ func Dump(
 		pathspecs,
 		filters,
 		!short,
-		configFiles.HasMailmap(),
 	)
 	if err != nil {
 		return err
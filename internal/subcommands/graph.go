@@ -0,0 +1,341 @@
+package subcommands
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/git/cmd"
+	"github.com/sinclairtarget/git-who/internal/tally"
+)
+
+// An author in the collaboration graph.
+type graphNode struct {
+	id    string
+	tally tally.FinalTally
+}
+
+// An edge between two authors who edited some of the same files.
+type graphEdge struct {
+	source      string // Node ID
+	target      string // Node ID
+	sharedFiles int
+}
+
+// The "graph" subcommand prints a graph of which authors have worked on the
+// same files as each other.
+func Graph(
+	revs []string,
+	pathspecs []string,
+	outputFormat OutputFormat,
+	minShared int,
+	showEmail bool,
+	countMerges bool,
+	since string,
+	until string,
+	authors []string,
+	nauthors []string,
+) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error running \"graph\": %w", err)
+		}
+	}()
+
+	logger().Debug(
+		"called graph()",
+		"revs",
+		revs,
+		"pathspecs",
+		pathspecs,
+		"outputFormat",
+		outputFormat,
+		"minShared",
+		minShared,
+		"showEmail",
+		showEmail,
+		"countMerges",
+		countMerges,
+		"since",
+		since,
+		"until",
+		until,
+		"authors",
+		authors,
+		"nauthors",
+		nauthors,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	filters := cmd.LogFilters{
+		Since:    since,
+		Until:    until,
+		Authors:  authors,
+		Nauthors: nauthors,
+	}
+
+	tallyOpts := tally.TallyOpts{Mode: tally.FilesMode, CountMerges: countMerges}
+	key := func(t tally.FinalTally) string { return t.AuthorName }
+	if showEmail {
+		tallyOpts.Key = func(c git.Commit) string { return c.AuthorEmail }
+		key = func(t tally.FinalTally) string { return t.AuthorEmail }
+	} else {
+		tallyOpts.Key = func(c git.Commit) string { return c.AuthorName }
+	}
+
+	byPath, err := tallyByPath(
+		ctx,
+		revs,
+		pathspecs,
+		filters,
+		tallyOpts,
+		git.RenameHistory{},
+	)
+	if err != nil {
+		return err
+	}
+
+	// -- Nodes --
+	tallies := []tally.FinalTally{}
+	for _, t := range byPath.Reduce() {
+		tallies = append(tallies, t.Final())
+	}
+
+	slices.SortFunc(tallies, func(a, b tally.FinalTally) int {
+		return cmp.Or(
+			-cmp.Compare(a.Commits, b.Commits),
+			strings.Compare(a.AuthorName, b.AuthorName),
+			strings.Compare(a.AuthorEmail, b.AuthorEmail),
+		)
+	})
+
+	nodes := []graphNode{}
+	ids := map[string]string{} // Key -> node ID
+	for i, t := range tallies {
+		id := fmt.Sprintf("a%d", i)
+		nodes = append(nodes, graphNode{id: id, tally: t})
+		ids[key(t)] = id
+	}
+
+	// -- Edges --
+	edges := []graphEdge{}
+	for _, collab := range tally.Collaborations(byPath, minShared) {
+		edges = append(edges, graphEdge{
+			source:      ids[collab.Keys[0]],
+			target:      ids[collab.Keys[1]],
+			sharedFiles: collab.SharedFiles,
+		})
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	switch outputFormat {
+	case GraphmlOutput:
+		err = writeGraphml(w, nodes, edges)
+	case JsonOutput:
+		meta := toJsonMeta(revs, pathspecs, filters, tallyOpts.Mode)
+		err = writeGraphJson(w, nodes, edges, meta)
+	default:
+		writeDot(w, nodes, edges)
+	}
+	if err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
+
+// Quotes a string for use as a DOT ID.
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+func writeDot(w io.Writer, nodes []graphNode, edges []graphEdge) {
+	fmt.Fprintln(w, "graph \"git-who\" {")
+	fmt.Fprintln(w, "  node [shape=box];")
+
+	for _, n := range nodes {
+		fmt.Fprintf(
+			w,
+			"  %s [label=%s, email=%s, commits=%d, lines_added=%d, "+
+				"lines_removed=%d, files=%d];\n",
+			n.id,
+			dotQuote(n.tally.AuthorName),
+			dotQuote(n.tally.AuthorEmail),
+			n.tally.Commits,
+			n.tally.LinesAdded,
+			n.tally.LinesRemoved,
+			n.tally.FileCount,
+		)
+	}
+
+	for _, e := range edges {
+		fmt.Fprintf(
+			w,
+			"  %s -- %s [weight=%d, label=%d];\n",
+			e.source,
+			e.target,
+			e.sharedFiles,
+			e.sharedFiles,
+		)
+	}
+
+	fmt.Fprintln(w, "}")
+}
+
+type xmlGraphml struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []xmlKey     `xml:"key"`
+	Graph   xmlGraphBody `xml:"graph"`
+}
+
+// Declares an attribute of graph nodes or edges.
+type xmlKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type xmlGraphBody struct {
+	ID          string    `xml:"id,attr"`
+	EdgeDefault string    `xml:"edgedefault,attr"`
+	Nodes       []xmlNode `xml:"node"`
+	Edges       []xmlEdge `xml:"edge"`
+}
+
+type xmlNode struct {
+	ID   string    `xml:"id,attr"`
+	Data []xmlData `xml:"data"`
+}
+
+type xmlEdge struct {
+	Source string    `xml:"source,attr"`
+	Target string    `xml:"target,attr"`
+	Data   []xmlData `xml:"data"`
+}
+
+type xmlData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+func writeGraphml(w io.Writer, nodes []graphNode, edges []graphEdge) error {
+	doc := xmlGraphml{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []xmlKey{
+			xmlKey{"name", "node", "name", "string"},
+			xmlKey{"email", "node", "email", "string"},
+			xmlKey{"commits", "node", "commits", "int"},
+			xmlKey{"lines_added", "node", "lines_added", "int"},
+			xmlKey{"lines_removed", "node", "lines_removed", "int"},
+			xmlKey{"files", "node", "files", "int"},
+			xmlKey{"shared_files", "edge", "shared_files", "int"},
+		},
+		Graph: xmlGraphBody{
+			ID:          "git-who",
+			EdgeDefault: "undirected",
+			Nodes:       []xmlNode{},
+			Edges:       []xmlEdge{},
+		},
+	}
+
+	for _, n := range nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, xmlNode{
+			ID: n.id,
+			Data: []xmlData{
+				xmlData{"name", n.tally.AuthorName},
+				xmlData{"email", n.tally.AuthorEmail},
+				xmlData{"commits", fmt.Sprint(n.tally.Commits)},
+				xmlData{"lines_added", fmt.Sprint(n.tally.LinesAdded)},
+				xmlData{"lines_removed", fmt.Sprint(n.tally.LinesRemoved)},
+				xmlData{"files", fmt.Sprint(n.tally.FileCount)},
+			},
+		})
+	}
+
+	for _, e := range edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, xmlEdge{
+			Source: e.source,
+			Target: e.target,
+			Data: []xmlData{
+				xmlData{"shared_files", fmt.Sprint(e.sharedFiles)},
+			},
+		})
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return fmt.Errorf("error writing GraphML to stdout: %w", err)
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(doc)
+	if err != nil {
+		return fmt.Errorf("error writing GraphML to stdout: %w", err)
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+type jsonGraphNode struct {
+	ID string `json:"id"`
+	jsonTally
+}
+
+type jsonGraphEdge struct {
+	Source      string `json:"source"`
+	Target      string `json:"target"`
+	SharedFiles int    `json:"shared_files"`
+}
+
+type jsonGraph struct {
+	jsonMeta
+	Nodes []jsonGraphNode `json:"nodes"`
+	Edges []jsonGraphEdge `json:"edges"`
+}
+
+func writeGraphJson(
+	w io.Writer,
+	nodes []graphNode,
+	edges []graphEdge,
+	meta jsonMeta,
+) error {
+	fields := tallyFields{diffs: true}
+
+	graph := jsonGraph{
+		jsonMeta: meta,
+		Nodes:    []jsonGraphNode{},
+		Edges:    []jsonGraphEdge{},
+	}
+
+	for _, n := range nodes {
+		graph.Nodes = append(graph.Nodes, jsonGraphNode{
+			ID:        n.id,
+			jsonTally: toJsonTally(n.tally, fields),
+		})
+	}
+
+	for _, e := range edges {
+		graph.Edges = append(graph.Edges, jsonGraphEdge{
+			Source:      e.source,
+			Target:      e.target,
+			SharedFiles: e.sharedFiles,
+		})
+	}
+
+	return writeJsonValue(w, graph, JsonOutput)
+}
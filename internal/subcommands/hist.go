@@ -1,11 +1,15 @@
 package subcommands
 
 import (
+	"bufio"
 	"context"
+	"encoding/csv"
 	"fmt"
 	"math"
 	"os"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -25,8 +29,14 @@ func Hist(
 	revs []string,
 	pathspecs []string,
 	mode tally.TallyMode,
+	outputFormat OutputFormat,
 	showEmail bool,
+	showAllAuthors bool,
 	countMerges bool,
+	coAuthors tally.CoAuthorCredit,
+	committer bool,
+	halfLife time.Duration,
+	weights tally.Weights,
 	since string,
 	until string,
 	authors []string,
@@ -46,10 +56,22 @@ func Hist(
 		pathspecs,
 		"mode",
 		mode,
+		"outputFormat",
+		outputFormat,
 		"showEmail",
 		showEmail,
+		"showAllAuthors",
+		showAllAuthors,
 		"countMerges",
 		countMerges,
+		"coAuthors",
+		coAuthors,
+		"committer",
+		committer,
+		"halfLife",
+		halfLife,
+		"weights",
+		weights,
 		"since",
 		since,
 		"until",
@@ -63,19 +85,27 @@ func Hist(
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	tallyOpts := tally.TallyOpts{Mode: mode, CountMerges: countMerges}
+	tallyOpts := tally.TallyOpts{
+		Mode:        mode,
+		CountMerges: countMerges,
+		CoAuthors:   coAuthors,
+		Committer:   committer,
+		HalfLife:    halfLife,
+		DecayFrom:   progStart,
+		Weights:     weights,
+	}
 	if showEmail {
 		tallyOpts.Key = func(c git.Commit) string { return c.AuthorEmail }
 	} else {
 		tallyOpts.Key = func(c git.Commit) string { return c.AuthorName }
 	}
 
-	populateDiffs := tallyOpts.IsDiffMode()
 	filters := cmd.LogFilters{
-		Since:    since,
-		Until:    until,
-		Authors:  authors,
-		Nauthors: nauthors,
+		Since:     since,
+		Until:     until,
+		Authors:   authors,
+		Nauthors:  nauthors,
+		Committer: committer,
 	}
 
 	var end time.Time // Default is zero time, meaning use last commit
@@ -84,19 +114,59 @@ func Hist(
 		end = time.Now()
 	}
 
-	gitRootPath, err := git.GetRoot()
+	buckets, err := tallyTimeline(ctx, revs, pathspecs, filters, tallyOpts, end)
 	if err != nil {
 		return err
 	}
 
+	// -- Pick winner in each bucket --
+	for i, bucket := range buckets {
+		buckets[i] = bucket.Rank(tallyOpts)
+	}
+
+	switch outputFormat {
+	case CsvOutput:
+		return writeHistCsv(buckets, tallyOpts, showEmail, showAllAuthors)
+	case JsonOutput, NdjsonOutput:
+		meta := toJsonMeta(revs, pathspecs, filters, mode)
+		return writeHistJson(buckets, tallyOpts, meta, showAllAuthors, outputFormat)
+	}
+
+	// -- Draw bar plot --
+	maxVal := barWidth
+	for _, bucket := range buckets {
+		if bucket.TotalValue(mode) > maxVal {
+			maxVal = bucket.TotalValue(mode)
+		}
+	}
+
+	drawPlot(buckets, maxVal, mode, showEmail)
+	return nil
+}
+
+// Tallies commits into time buckets ending at the given time, or at the last
+// commit if end is the zero time.
+func tallyTimeline(
+	ctx context.Context,
+	revs []string,
+	pathspecs []string,
+	filters cmd.LogFilters,
+	tallyOpts tally.TallyOpts,
+	end time.Time,
+) ([]tally.TimeBucket, error) {
+	gitRootPath, err := git.GetRoot()
+	if err != nil {
+		return nil, err
+	}
+
 	configFiles, err := config.DetectSupplementalFiles(gitRootPath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	var buckets []tally.TimeBucket
+	populateDiffs := tallyOpts.IsDiffMode()
 	if populateDiffs && runtime.GOMAXPROCS(0) > 1 {
-		buckets, err = concurrent.TallyCommitsTimeline(
+		return concurrent.TallyCommitsTimeline(
 			ctx,
 			revs,
 			pathspecs,
@@ -104,52 +174,25 @@ func Hist(
 			configFiles,
 			tallyOpts,
 			end,
-			cache.GetCache(gitRootPath, configFiles),
+			cache.GetCache(gitRootPath),
 			pretty.AllowDynamic(os.Stdout),
 		)
-		if err != nil {
-			return err
-		}
-	} else {
-		buckets, err = func() (_ []tally.TimeBucket, err error) {
-			commits, finish := git.CommitsWithOpts(
-				ctx,
-				revs,
-				pathspecs,
-				filters,
-				populateDiffs,
-				configFiles,
-			)
-			defer func() { err = finish() }()
-
-			buckets, err := tally.TallyCommitsTimeline(
-				commits,
-				tallyOpts,
-				end,
-			)
-			return buckets, err
-		}()
-
-		if err != nil {
-			return err
-		}
-	}
-
-	// -- Pick winner in each bucket --
-	for i, bucket := range buckets {
-		buckets[i] = bucket.Rank(mode)
 	}
 
-	// -- Draw bar plot --
-	maxVal := barWidth
-	for _, bucket := range buckets {
-		if bucket.TotalValue(mode) > maxVal {
-			maxVal = bucket.TotalValue(mode)
-		}
-	}
+	return func() (_ []tally.TimeBucket, err error) {
+		commits, finish := git.CommitsWithOpts(
+			ctx,
+			revs,
+			pathspecs,
+			filters,
+			populateDiffs,
+			configFiles,
+		)
+		defer func() { err = finish() }()
 
-	drawPlot(buckets, maxVal, mode, showEmail)
-	return nil
+		buckets, err := tally.TallyCommitsTimeline(commits, tallyOpts, end)
+		return buckets, err
+	}()
 }
 
 func drawPlot(
@@ -198,6 +241,164 @@ func drawPlot(
 	}
 }
 
+// Writes one row per bucket describing the winner, or, if showAllAuthors is
+// true, one row per author per bucket.
+func writeHistCsv(
+	buckets []tally.TimeBucket,
+	opts tally.TallyOpts,
+	showEmail bool,
+	showAllAuthors bool,
+) error {
+	w := csv.NewWriter(os.Stdout)
+
+	if showAllAuthors {
+		columnHeaders := slices.Concat(
+			[]string{"bucket", "start time", "rank", "value", "total value"},
+			recordHeaders(fieldsFor(opts), showEmail),
+		)
+		w.Write(columnHeaders)
+	} else {
+		columnHeaders := []string{"bucket", "start time", "winner name"}
+		if showEmail {
+			columnHeaders = append(columnHeaders, "winner email")
+		}
+		columnHeaders = append(columnHeaders, "winner value", "total value")
+		w.Write(columnHeaders)
+	}
+
+	for _, bucket := range buckets {
+		prefix := []string{bucket.Name, bucket.Time.Format(time.RFC3339)}
+		total := strconv.Itoa(bucket.TotalValue(opts.Mode))
+
+		if showAllAuthors {
+			for i, t := range bucket.Ranked {
+				record := slices.Concat(
+					prefix,
+					[]string{
+						strconv.Itoa(i + 1),
+						strconv.Itoa(tally.TimelineValue(t, opts.Mode)),
+						total,
+					},
+					toRecord(t, fieldsFor(opts), showEmail),
+				)
+				if err := w.Write(record); err != nil {
+					return fmt.Errorf(
+						"error writing CSV record to stdout: %w",
+						err,
+					)
+				}
+			}
+		} else {
+			record := slices.Concat(prefix, []string{bucket.Tally.AuthorName})
+			if showEmail {
+				record = append(record, bucket.Tally.AuthorEmail)
+			}
+			record = append(
+				record,
+				strconv.Itoa(bucket.Value(opts.Mode)),
+				total,
+			)
+
+			if err := w.Write(record); err != nil {
+				return fmt.Errorf("error writing CSV record to stdout: %w", err)
+			}
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("error flushing CSV writer: %w", err)
+	}
+
+	return nil
+}
+
+type jsonBucketAuthor struct {
+	jsonTally
+	Value int `json:"value"`
+}
+
+type jsonBucket struct {
+	Name        string     `json:"name"`
+	StartTime   string     `json:"start_time"`
+	Winner      *jsonTally `json:"winner"` // Null if there were no commits
+	WinnerValue int        `json:"winner_value"`
+	TotalValue  int        `json:"total_value"`
+
+	// Ranked, including winner. Only present if requested.
+	Authors *[]jsonBucketAuthor `json:"authors,omitempty"`
+}
+
+type jsonHist struct {
+	jsonMeta
+	Buckets []jsonBucket `json:"buckets"`
+}
+
+// Writes the timeline as a single JSON document, or, for NDJSON, as a metadata
+// line followed by one line per bucket.
+func writeHistJson(
+	buckets []tally.TimeBucket,
+	opts tally.TallyOpts,
+	meta jsonMeta,
+	showAllAuthors bool,
+	format OutputFormat,
+) error {
+	w := bufio.NewWriter(os.Stdout)
+
+	jsonBuckets := []jsonBucket{}
+	for _, bucket := range buckets {
+		jb := jsonBucket{
+			Name:        bucket.Name,
+			StartTime:   bucket.Time.Format(time.RFC3339),
+			WinnerValue: bucket.Value(opts.Mode),
+			TotalValue:  bucket.TotalValue(opts.Mode),
+		}
+
+		if len(bucket.Ranked) > 0 {
+			winner := toJsonTally(bucket.Tally, fieldsFor(opts))
+			jb.Winner = &winner
+		}
+
+		if showAllAuthors {
+			authors := []jsonBucketAuthor{}
+			for _, t := range bucket.Ranked {
+				authors = append(authors, jsonBucketAuthor{
+					jsonTally: toJsonTally(t, fieldsFor(opts)),
+					Value:     tally.TimelineValue(t, opts.Mode),
+				})
+			}
+			jb.Authors = &authors
+		}
+
+		jsonBuckets = append(jsonBuckets, jb)
+	}
+
+	if format == NdjsonOutput {
+		err := writeJsonValue(w, meta, format)
+		if err != nil {
+			return err
+		}
+
+		for _, jb := range jsonBuckets {
+			err := writeJsonValue(w, jb, format)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		err := writeJsonValue(
+			w,
+			jsonHist{jsonMeta: meta, Buckets: jsonBuckets},
+			format,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
+
 func fmtHistTally(
 	t tally.FinalTally,
 	mode tally.TallyMode,
@@ -220,10 +421,16 @@ func fmtHistTally(
 			format.Number(t.LinesRemoved),
 			pretty.DefaultColor,
 		)
+	case tally.CompositeMode:
+		metric = fmt.Sprintf("(score %s)", format.Score(t.Score))
 	default:
 		panic("unrecognized tally mode in switch")
 	}
 
+	if t.Decayed {
+		metric = fmt.Sprintf("(score %s)", format.Score(t.Score))
+	}
+
 	var author string
 	if showEmail {
 		author = format.Abbrev(format.GitEmail(t.AuthorEmail), 25)
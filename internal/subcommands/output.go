@@ -0,0 +1,160 @@
+package subcommands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sinclairtarget/git-who/internal/git/cmd"
+	"github.com/sinclairtarget/git-who/internal/tally"
+)
+
+// How a subcommand should print its results.
+type OutputFormat int
+
+const (
+	TerminalOutput OutputFormat = iota // Human-readable, maybe with color
+	CsvOutput
+	JsonOutput
+	NdjsonOutput // Newline-delimited JSON, one record per line
+	DotOutput    // Graphviz DOT, for graphs
+	GraphmlOutput
+)
+
+func (f OutputFormat) String() string {
+	switch f {
+	case TerminalOutput:
+		return "terminal"
+	case CsvOutput:
+		return "csv"
+	case JsonOutput:
+		return "json"
+	case NdjsonOutput:
+		return "ndjson"
+	case DotOutput:
+		return "dot"
+	case GraphmlOutput:
+		return "graphml"
+	default:
+		panic("unrecognized output format in switch statement")
+	}
+}
+
+// Describes the invocation that produced some JSON output.
+type jsonMeta struct {
+	Revs      []string    `json:"revs"`
+	Pathspecs []string    `json:"pathspecs"`
+	Filters   jsonFilters `json:"filters"`
+	Mode      string      `json:"mode"`
+}
+
+type jsonFilters struct {
+	Since    string   `json:"since"`
+	Until    string   `json:"until"`
+	Authors  []string `json:"authors"`
+	Nauthors []string `json:"nauthors"`
+}
+
+// Which of the optional metrics in a tally.FinalTally we serialize.
+type tallyFields struct {
+	diffs bool // Lines added, lines removed, and files
+	blame bool // Surviving lines and files
+	score bool // Score weighted by age
+}
+
+func fieldsFor(opts tally.TallyOpts) tallyFields {
+	return tallyFields{
+		diffs: opts.IsDiffMode(),
+		blame: opts.Mode == tally.BlameMode,
+		score: opts.IsScored(),
+	}
+}
+
+// Serialized form of a tally.FinalTally.
+//
+// Line and file counts are only known when diffs were examined; otherwise they
+// are null. Likewise, surviving lines are only known in blame mode and scores
+// are only known when contributions are weighted by age.
+type jsonTally struct {
+	Name            string   `json:"name"`
+	Email           string   `json:"email"`
+	Commits         int      `json:"commits"`
+	LinesAdded      *int     `json:"lines_added"`
+	LinesRemoved    *int     `json:"lines_removed"`
+	Files           *int     `json:"files"`
+	SurvivingLines  *int     `json:"surviving_lines"`
+	Score           *float64 `json:"score"`
+	FirstCommitTime string   `json:"first_commit_time"`
+	LastCommitTime  string   `json:"last_commit_time"`
+}
+
+// Never serialize a nil slice as null.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+
+	return s
+}
+
+func toJsonMeta(
+	revs []string,
+	pathspecs []string,
+	filters cmd.LogFilters,
+	mode tally.TallyMode,
+) jsonMeta {
+	return jsonMeta{
+		Revs:      nonNil(revs),
+		Pathspecs: nonNil(pathspecs),
+		Filters: jsonFilters{
+			Since:    filters.Since,
+			Until:    filters.Until,
+			Authors:  nonNil(filters.Authors),
+			Nauthors: nonNil(filters.Nauthors),
+		},
+		Mode: mode.String(),
+	}
+}
+
+func toJsonTally(t tally.FinalTally, fields tallyFields) jsonTally {
+	jt := jsonTally{
+		Name:            t.AuthorName,
+		Email:           t.AuthorEmail,
+		Commits:         t.Commits,
+		FirstCommitTime: t.FirstCommitTime.Format(time.RFC3339),
+		LastCommitTime:  t.LastCommitTime.Format(time.RFC3339),
+	}
+
+	if fields.diffs {
+		jt.LinesAdded = &t.LinesAdded
+		jt.LinesRemoved = &t.LinesRemoved
+		jt.Files = &t.FileCount
+	}
+
+	if fields.blame {
+		jt.SurvivingLines = &t.SurvivingLines
+		jt.Files = &t.FileCount
+	}
+
+	if fields.score {
+		jt.Score = &t.Score
+	}
+
+	return jt
+}
+
+// Writes a single value to w. Indented unless we are writing NDJSON.
+func writeJsonValue(w io.Writer, v any, format OutputFormat) error {
+	enc := json.NewEncoder(w)
+	if format != NdjsonOutput {
+		enc.SetIndent("", "  ")
+	}
+
+	err := enc.Encode(v)
+	if err != nil {
+		return fmt.Errorf("error writing JSON to stdout: %w", err)
+	}
+
+	return nil
+}
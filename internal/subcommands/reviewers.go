@@ -0,0 +1,380 @@
+package subcommands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	runewidth "github.com/mattn/go-runewidth"
+
+	"github.com/sinclairtarget/git-who/internal/format"
+	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/git/cmd"
+	"github.com/sinclairtarget/git-who/internal/git/config"
+	"github.com/sinclairtarget/git-who/internal/pretty"
+	"github.com/sinclairtarget/git-who/internal/tally"
+)
+
+// Max number of files listed as the reason for each suggestion
+const maxReviewerFiles = 3
+
+// The "reviewers" subcommand suggests who should review a change, given either
+// as a revision range or as a patch.
+//
+// If patch is nil, the change is the commits in revs, which must be a range
+// like "main..HEAD". Otherwise the change is read from patch and compared
+// against the history of HEAD.
+func Reviewers(
+	revs []string,
+	patch io.Reader,
+	mode tally.TallyMode,
+	halfLife time.Duration,
+	limit int,
+	showEmail bool,
+	countMerges bool,
+	since string,
+	until string,
+	authors []string,
+	nauthors []string,
+) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error running \"reviewers\": %w", err)
+		}
+	}()
+
+	logger().Debug(
+		"called reviewers()",
+		"revs",
+		revs,
+		"patch",
+		patch != nil,
+		"mode",
+		mode,
+		"halfLife",
+		halfLife,
+		"limit",
+		limit,
+		"showEmail",
+		showEmail,
+		"countMerges",
+		countMerges,
+		"since",
+		since,
+		"until",
+		until,
+		"authors",
+		authors,
+		"nauthors",
+		nauthors,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tallyOpts := tally.TallyOpts{
+		Mode:        mode,
+		CountMerges: countMerges,
+		HalfLife:    halfLife,
+		DecayFrom:   progStart,
+	}
+	if showEmail {
+		tallyOpts.Key = func(c git.Commit) string { return c.AuthorEmail }
+	} else {
+		tallyOpts.Key = func(c git.Commit) string { return c.AuthorName }
+	}
+
+	gitRootPath, err := git.GetRoot()
+	if err != nil {
+		return err
+	}
+
+	configFiles, err := config.DetectSupplementalFiles(gitRootPath)
+	if err != nil {
+		return err
+	}
+
+	// -- Find what the change touched --
+	var change []git.Commit
+	var historyRevs []string
+	if patch != nil {
+		change, err = patchCommits(patch, configFiles)
+		if err != nil {
+			return err
+		}
+
+		historyRevs = []string{"HEAD"}
+	} else {
+		// The history is everything reachable from the base of the range
+		for _, rev := range revs {
+			if base, ok := strings.CutPrefix(rev, "^"); ok {
+				historyRevs = append(historyRevs, base)
+			}
+		}
+
+		if len(historyRevs) == 0 {
+			return errors.New("expected a revision range like main..HEAD")
+		}
+
+		change, err = rangeCommits(ctx, revs, configFiles)
+		if err != nil {
+			return err
+		}
+	}
+
+	changed := map[string]int{} // Path -> weight
+	renames := map[string]string{}
+	exclude := map[string]bool{}
+	changeAuthors := []string{}
+	for _, commit := range change {
+		if commit.AuthorName != "" || commit.AuthorEmail != "" {
+			key := tallyOpts.Key(commit)
+			if !exclude[key] {
+				exclude[key] = true
+				changeAuthors = append(changeAuthors, key)
+			}
+		}
+
+		for _, diff := range commit.FileDiffs {
+			weight := 1
+			if mode == tally.LinesMode {
+				weight = max(diff.LinesAdded+diff.LinesRemoved, 1)
+			}
+
+			changed[diff.Path] += weight
+			if diff.PrevPath != "" {
+				renames[diff.PrevPath] = diff.Path
+			}
+		}
+	}
+
+	if len(changed) == 0 {
+		return errors.New("change does not touch any files")
+	}
+
+	// -- Tally the history of the touched paths --
+
+	// Also follow the touched files back through renames made before the
+	// change, so that history from before a rename counts. That history is
+	// credited to the path the file had when the change was made
+	histRenames, err := git.Renames(ctx, historyRevs)
+	if err != nil {
+		return err
+	}
+
+	oldPaths := map[string]bool{}
+	for from, to := range histRenames.All() {
+		_, isChanged := changed[to]
+		_, isRenamed := renames[to]
+		if isChanged || isRenamed {
+			oldPaths[from] = true
+		}
+	}
+
+	pathspecs, err := pathspecsFromRoot(
+		slices.Concat(
+			slices.Collect(maps.Keys(changed)),
+			slices.Collect(maps.Keys(renames)),
+			slices.Collect(maps.Keys(oldPaths)),
+		),
+		gitRootPath,
+	)
+	if err != nil {
+		return err
+	}
+
+	filters := cmd.LogFilters{
+		Since:    since,
+		Until:    until,
+		Authors:  authors,
+		Nauthors: nauthors,
+	}
+
+	byPath, err := tallyByPath(
+		ctx,
+		historyRevs,
+		pathspecs,
+		filters,
+		tallyOpts,
+		histRenames,
+	)
+	if err != nil {
+		return err
+	}
+
+	reviewers := tally.Reviewers(byPath, changed, renames, exclude, tallyOpts)
+
+	numFilteredOut := 0
+	if limit > 0 && limit < len(reviewers) {
+		numFilteredOut = len(reviewers) - limit
+		reviewers = reviewers[:limit]
+	}
+
+	writeReviewers(
+		reviewers,
+		len(changed),
+		changeAuthors,
+		showEmail,
+		numFilteredOut,
+	)
+	return nil
+}
+
+// Returns the non-merge commits in the given range.
+func rangeCommits(
+	ctx context.Context,
+	revs []string,
+	configFiles config.SupplementalFiles,
+) (_ []git.Commit, err error) {
+	commits, finish := git.CommitsWithOpts(
+		ctx,
+		revs,
+		[]string{},
+		cmd.LogFilters{},
+		true,
+		configFiles,
+	)
+	defer func() { err = finish() }()
+
+	change := []git.Commit{}
+	for commit := range commits {
+		if !commit.IsMerge {
+			change = append(change, commit)
+		}
+	}
+
+	return change, nil
+}
+
+// Reads the commits in a patch, mapping their authors with the mailmap like git
+// log would.
+func patchCommits(
+	patch io.Reader,
+	configFiles config.SupplementalFiles,
+) ([]git.Commit, error) {
+	change, err := git.ParsePatch(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	mm, err := configFiles.Mailmap()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, commit := range change {
+		if commit.AuthorName != "" || commit.AuthorEmail != "" {
+			change[i].AuthorName, change[i].AuthorEmail = mm.Map(
+				commit.AuthorName,
+				commit.AuthorEmail,
+			)
+		}
+	}
+
+	return change, nil
+}
+
+// Turns paths relative to the repository root into pathspecs relative to the
+// working dir.
+func pathspecsFromRoot(paths []string, gitRootPath string) ([]string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	pathspecs := []string{}
+	for _, p := range slices.Sorted(slices.Values(paths)) {
+		absPath := filepath.Join(gitRootPath, filepath.FromSlash(p))
+		relPath, err := filepath.Rel(wd, absPath)
+		if err != nil {
+			return nil, err
+		}
+
+		pathspecs = append(pathspecs, filepath.ToSlash(relPath))
+	}
+
+	return pathspecs, nil
+}
+
+func writeReviewers(
+	reviewers []tally.Reviewer,
+	numChanged int,
+	changeAuthors []string,
+	showEmail bool,
+	numFilteredOut int,
+) {
+	files := "files"
+	if numChanged == 1 {
+		files = "file"
+	}
+
+	fmt.Printf(
+		"Suggested reviewers for %s changed %s:\n",
+		format.Number(numChanged),
+		files,
+	)
+
+	if len(changeAuthors) > 0 {
+		fmt.Printf(
+			"%s(Leaving out authors of the change: %s)%s\n",
+			pretty.Dim,
+			strings.Join(changeAuthors, ", "),
+			pretty.Reset,
+		)
+	}
+
+	fmt.Println()
+
+	if len(reviewers) == 0 {
+		fmt.Println("Nobody else has worked on these files.")
+		return
+	}
+
+	nameWidth := 0
+	names := []string{}
+	for _, r := range reviewers {
+		name := r.AuthorName
+		if showEmail {
+			name = fmt.Sprintf(
+				"%s %s",
+				r.AuthorName,
+				format.GitEmail(r.AuthorEmail),
+			)
+		}
+
+		name = format.Abbrev(name, 30)
+		names = append(names, name)
+		nameWidth = max(nameWidth, runewidth.StringWidth(name))
+	}
+
+	for i, r := range reviewers {
+		reason := strings.Join(r.Files[:min(len(r.Files), maxReviewerFiles)], ", ")
+		if len(r.Files) > maxReviewerFiles {
+			reason += fmt.Sprintf(
+				" and %s more",
+				format.Number(len(r.Files)-maxReviewerFiles),
+			)
+		}
+
+		fmt.Printf(
+			"%2d. %s  owns %4s  %s%s%s\n",
+			i+1,
+			runewidth.FillRight(names[i], nameWidth),
+			format.Percent(r.Ownership),
+			pretty.Dim,
+			reason,
+			pretty.Reset,
+		)
+	}
+
+	if numFilteredOut > 0 {
+		fmt.Printf("...%s more...\n", format.Number(numFilteredOut))
+	}
+}
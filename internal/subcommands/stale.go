@@ -0,0 +1,413 @@
+package subcommands
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	runewidth "github.com/mattn/go-runewidth"
+
+	"github.com/sinclairtarget/git-who/internal/format"
+	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/git/cmd"
+	"github.com/sinclairtarget/git-who/internal/git/config"
+	"github.com/sinclairtarget/git-who/internal/pretty"
+	"github.com/sinclairtarget/git-who/internal/tally"
+)
+
+// A file or directory in the working tree that nobody works on anymore.
+type staleRow struct {
+	path     string
+	isDir    bool
+	last     tally.FinalTally // Tally of the last author to edit the path
+	orphaned bool             // Nobody who edited the path is still active
+	lines    int              // Lines in the working tree
+}
+
+// The "stale" subcommand prints the files and directories in the working tree
+// that haven't been edited in a while or that nobody left is working on.
+func Stale(
+	revs []string,
+	pathspecs []string,
+	untouchedSince string,
+	activeSince string,
+	sortBySize bool,
+	limit int,
+	showEmail bool,
+	since string,
+	until string,
+	authors []string,
+	nauthors []string,
+) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error running \"stale\": %w", err)
+		}
+	}()
+
+	logger().Debug(
+		"called stale()",
+		"revs",
+		revs,
+		"pathspecs",
+		pathspecs,
+		"untouchedSince",
+		untouchedSince,
+		"activeSince",
+		activeSince,
+		"sortBySize",
+		sortBySize,
+		"limit",
+		limit,
+		"showEmail",
+		showEmail,
+		"since",
+		since,
+		"until",
+		until,
+		"authors",
+		authors,
+		"nauthors",
+		nauthors,
+	)
+
+	var untouchedSinceTime time.Time
+	if untouchedSince != "" {
+		untouchedSinceTime, err = git.ParseDate(untouchedSince)
+		if err != nil {
+			return err
+		}
+	}
+
+	var activeSinceTime time.Time
+	if activeSince != "" {
+		activeSinceTime, err = git.ParseDate(activeSince)
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	filters := cmd.LogFilters{
+		Since:    since,
+		Until:    until,
+		Authors:  authors,
+		Nauthors: nauthors,
+	}
+
+	// The winning tally for each node is the one with the last commit
+	tallyOpts := tally.TallyOpts{Mode: tally.LastModifiedMode}
+	key := func(t tally.FinalTally) string { return t.AuthorName }
+	if showEmail {
+		tallyOpts.Key = func(c git.Commit) string { return c.AuthorEmail }
+		key = func(t tally.FinalTally) string { return t.AuthorEmail }
+	} else {
+		tallyOpts.Key = func(c git.Commit) string { return c.AuthorName }
+	}
+
+	followRenames := false
+	root, err := tallyTree(
+		ctx,
+		revs,
+		pathspecs,
+		filters,
+		tallyOpts,
+		followRenames,
+	)
+	if err == tally.EmptyTreeErr {
+		logger().Debug("Tree was empty.")
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	root = root.Rank(tallyOpts)
+
+	// Like with the bus subcommand, an author is active if they have committed
+	// anywhere since the given date, not just to the paths we were asked about.
+	// So we ignore the other filters here.
+	var isActive func(t tally.FinalTally) bool
+	if activeSince != "" {
+		active, err := activeAuthors(
+			ctx,
+			revs,
+			activeSince,
+			activeSinceTime,
+			tallyOpts.Key,
+		)
+		if err != nil {
+			return err
+		}
+
+		isActive = func(t tally.FinalTally) bool { return active[key(t)] }
+	}
+
+	rows := []staleRow{}
+	for _, childPath := range sortedChildPaths(root) {
+		rows, err = staleRows(
+			root.Children[childPath],
+			childPath,
+			untouchedSinceTime,
+			isActive,
+			rows,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Oldest first, or biggest first if sorting by size
+	slices.SortStableFunc(rows, func(a, b staleRow) int {
+		bySize := 0
+		if sortBySize {
+			bySize = -cmp.Compare(a.lines, b.lines)
+		}
+
+		return cmp.Or(
+			bySize,
+			a.last.LastCommitTime.Compare(b.last.LastCommitTime),
+			strings.Compare(a.path, b.path),
+		)
+	})
+
+	numFilteredOut := 0
+	if limit > 0 && limit < len(rows) {
+		numFilteredOut = len(rows) - limit
+		rows = rows[:limit]
+	}
+
+	writeStaleTable(rows, showEmail, numFilteredOut)
+	return nil
+}
+
+// Returns the authors with a commit to any path since the given date.
+func activeAuthors(
+	ctx context.Context,
+	revs []string,
+	activeSince string,
+	activeSinceTime time.Time,
+	key func(c git.Commit) string,
+) (_ map[string]bool, err error) {
+	gitRootPath, err := git.GetRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	configFiles, err := config.DetectSupplementalFiles(gitRootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	commits, finish := git.CommitsWithOpts(
+		ctx,
+		revs,
+		[]string{},
+		cmd.LogFilters{Since: activeSince},
+		false,
+		configFiles,
+	)
+	defer func() { err = finish() }()
+
+	tallies, err := tally.TallyCommits(
+		commits,
+		tally.TallyOpts{Mode: tally.CommitMode, Key: key},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	active := map[string]bool{}
+	for k, t := range tallies {
+		// Git filters by commit date, but we go by author date elsewhere
+		if !t.Final().LastCommitTime.Before(activeSinceTime) {
+			active[k] = true
+		}
+	}
+
+	return active, nil
+}
+
+// Recursively descend tree, collecting the stale files and directories in the
+// working tree. We stop at a stale directory, since everything under it is
+// stale too.
+func staleRows(
+	node *tally.TreeNode,
+	p string,
+	untouchedSince time.Time,
+	isActive func(t tally.FinalTally) bool,
+	rows []staleRow,
+) ([]staleRow, error) {
+	if !node.InWorkTree {
+		return rows, nil
+	}
+
+	untouched := !untouchedSince.IsZero() &&
+		node.Tally.LastCommitTime.Before(untouchedSince)
+	orphaned := isActive != nil && !slices.ContainsFunc(node.Ranked, isActive)
+
+	if untouched || orphaned {
+		lines, err := countLines(node, p)
+		if err != nil {
+			return nil, err
+		}
+
+		return append(rows, staleRow{
+			path:     p,
+			isDir:    len(node.Children) > 0,
+			last:     node.Tally,
+			orphaned: orphaned,
+			lines:    lines,
+		}), nil
+	}
+
+	var err error
+	for _, childPath := range sortedChildPaths(node) {
+		rows, err = staleRows(
+			node.Children[childPath],
+			path.Join(p, childPath),
+			untouchedSince,
+			isActive,
+			rows,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rows, nil
+}
+
+// Counts the lines in the working tree files under the node.
+func countLines(node *tally.TreeNode, p string) (int, error) {
+	if !node.InWorkTree {
+		return 0, nil
+	}
+
+	if len(node.Children) == 0 {
+		// Deleted files and things like submodules don't have lines to count
+		info, err := os.Lstat(filepath.FromSlash(p))
+		if errors.Is(err, fs.ErrNotExist) {
+			return 0, nil
+		} else if err != nil {
+			return 0, fmt.Errorf("could not count lines: %w", err)
+		}
+
+		if !info.Mode().IsRegular() {
+			return 0, nil
+		}
+
+		data, err := os.ReadFile(filepath.FromSlash(p))
+		if err != nil {
+			return 0, fmt.Errorf("could not count lines: %w", err)
+		}
+
+		lines := bytes.Count(data, []byte("\n"))
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			lines += 1
+		}
+
+		return lines, nil
+	}
+
+	total := 0
+	for childPath, child := range node.Children {
+		lines, err := countLines(child, path.Join(p, childPath))
+		if err != nil {
+			return 0, err
+		}
+
+		total += lines
+	}
+
+	return total, nil
+}
+
+func writeStaleTable(rows []staleRow, showEmail bool, numFilteredOut int) {
+	if len(rows) == 0 {
+		fmt.Println("No stale files or directories.")
+		return
+	}
+
+	colwidth := wideWidth
+	pathWidth := 30
+	authorWidth := colwidth - 2 - pathWidth - 12 - 7 - 9 - 4
+
+	var build strings.Builder
+	for _ = range colwidth - 2 {
+		build.WriteRune('─')
+	}
+	rule := build.String()
+
+	// -- Write header --
+	fmt.Printf("┌%s┐\n", rule)
+	fmt.Printf(
+		"│%-*s %-12s %-*s %7s %-9s│\n",
+		pathWidth,
+		"Path",
+		"Last Edit",
+		authorWidth,
+		"Last Author",
+		"Lines",
+		"Reason",
+	)
+	fmt.Printf("├%s┤\n", rule)
+
+	// -- Write table rows --
+	totalRows := len(rows)
+	for i, row := range rows {
+		alternating := ""
+		if totalRows > maxBeforeColorAlternating && i%2 == 1 {
+			alternating = pretty.Invert
+		}
+
+		p := row.path
+		if row.isDir {
+			p += "/"
+		}
+
+		author := row.last.AuthorName
+		if showEmail {
+			author = format.GitEmail(row.last.AuthorEmail)
+		}
+
+		reason := "untouched"
+		reasonColor := ""
+		if row.orphaned {
+			reason = "orphaned"
+			reasonColor = pretty.Red
+		}
+
+		fmt.Printf(
+			"│%s%s %-12s %s %7s %s%-9s%s│\n",
+			alternating,
+			runewidth.FillRight(format.Abbrev(p, pathWidth), pathWidth),
+			format.RelativeTime(progStart, row.last.LastCommitTime),
+			runewidth.FillRight(
+				format.Abbrev(author, authorWidth),
+				authorWidth,
+			),
+			format.Number(row.lines),
+			reasonColor,
+			reason,
+			pretty.Reset,
+		)
+	}
+
+	if numFilteredOut > 0 {
+		msg := fmt.Sprintf("...%s more...", format.Number(numFilteredOut))
+		fmt.Printf("│%-*s│\n", colwidth-2, msg)
+	}
+
+	fmt.Printf("└%s┘\n", rule)
+}
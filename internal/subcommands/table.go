@@ -1,6 +1,7 @@
 package subcommands
 
 import (
+	"bufio"
 	"context"
 	"encoding/csv"
 	"fmt"
@@ -27,7 +28,10 @@ const wideWidth = 80
 const maxBeforeColorAlternating = 14
 
 func pickWidth(mode tally.TallyMode, showEmail bool) int {
-	wideMode := mode == tally.FilesMode || mode == tally.LinesMode
+	wideMode := mode == tally.FilesMode ||
+		mode == tally.LinesMode ||
+		mode == tally.BlameMode ||
+		mode == tally.CompositeMode
 	if wideMode || showEmail {
 		return wideWidth
 	}
@@ -41,9 +45,14 @@ func Table(
 	revs []string,
 	pathspecs []string,
 	mode tally.TallyMode,
-	useCsv bool,
+	outputFormat OutputFormat,
 	showEmail bool,
+	showShares bool,
 	countMerges bool,
+	coAuthors tally.CoAuthorCredit,
+	committer bool,
+	halfLife time.Duration,
+	weights tally.Weights,
 	limit int,
 	since string,
 	until string,
@@ -64,12 +73,22 @@ func Table(
 		pathspecs,
 		"mode",
 		mode,
-		"useCsv",
-		useCsv,
+		"outputFormat",
+		outputFormat,
 		"showEmail",
 		showEmail,
+		"showShares",
+		showShares,
 		"countMerges",
 		countMerges,
+		"coAuthors",
+		coAuthors,
+		"committer",
+		committer,
+		"halfLife",
+		halfLife,
+		"weights",
+		weights,
 		"limit",
 		limit,
 		"since",
@@ -85,7 +104,15 @@ func Table(
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	tallyOpts := tally.TallyOpts{Mode: mode, CountMerges: countMerges}
+	tallyOpts := tally.TallyOpts{
+		Mode:        mode,
+		CountMerges: countMerges,
+		CoAuthors:   coAuthors,
+		Committer:   committer,
+		HalfLife:    halfLife,
+		DecayFrom:   progStart,
+		Weights:     weights,
+	}
 	if showEmail {
 		tallyOpts.Key = func(c git.Commit) string { return c.AuthorEmail }
 	} else {
@@ -94,10 +121,11 @@ func Table(
 
 	populateDiffs := tallyOpts.IsDiffMode()
 	filters := cmd.LogFilters{
-		Since:    since,
-		Until:    until,
-		Authors:  authors,
-		Nauthors: nauthors,
+		Since:     since,
+		Until:     until,
+		Authors:   authors,
+		Nauthors:  nauthors,
+		Committer: committer,
 	}
 
 	gitRootPath, err := git.GetRoot()
@@ -111,7 +139,14 @@ func Table(
 	}
 
 	var tallies map[string]tally.Tally
-	if populateDiffs && runtime.GOMAXPROCS(0) > 1 {
+	if mode == tally.BlameMode {
+		talliesByPath, err := blameByPath(ctx, revs, pathspecs, tallyOpts)
+		if err != nil {
+			return err
+		}
+
+		tallies = talliesByPath.Reduce()
+	} else if populateDiffs && runtime.GOMAXPROCS(0) > 1 {
 		tallies, err = concurrent.TallyCommits(
 			ctx,
 			revs,
@@ -119,7 +154,7 @@ func Table(
 			filters,
 			configFiles,
 			tallyOpts,
-			cache.GetCache(gitRootPath, configFiles),
+			cache.GetCache(gitRootPath),
 			pretty.AllowDynamic(os.Stdout),
 		)
 		if err != nil {
@@ -147,7 +182,13 @@ func Table(
 		}
 	}
 
-	rankedTallies := tally.Rank(tallies, mode)
+	rankedTallies := tally.Rank(tallies, tallyOpts)
+	totalAuthors := len(rankedTallies)
+
+	var shares *tableShares
+	if showShares && len(tallies) > 0 {
+		shares = newTableShares(tallies, tallyOpts)
+	}
 
 	numFilteredOut := 0
 	if limit > 0 && limit < len(rankedTallies) {
@@ -155,22 +196,95 @@ func Table(
 		rankedTallies = rankedTallies[:limit]
 	}
 
-	if useCsv {
-		err := writeCsv(rankedTallies, tallyOpts, showEmail)
+	switch outputFormat {
+	case CsvOutput:
+		err := writeCsv(rankedTallies, tallyOpts, showEmail, shares)
 		if err != nil {
 			return err
 		}
-	} else {
+	case JsonOutput, NdjsonOutput:
+		meta := jsonTableMeta{
+			jsonMeta:       toJsonMeta(revs, pathspecs, filters, mode),
+			TotalAuthors:   totalAuthors,
+			NumFilteredOut: numFilteredOut,
+		}
+		err := writeTableJson(rankedTallies, tallyOpts, meta, outputFormat)
+		if err != nil {
+			return err
+		}
+	default:
 		colwidth := pickWidth(mode, showEmail)
-		writeTable(rankedTallies, colwidth, showEmail, mode, numFilteredOut)
+		writeTable(
+			rankedTallies,
+			colwidth,
+			showEmail,
+			mode,
+			tallyOpts.IsScored(),
+			shares,
+			numFilteredOut,
+		)
 	}
 
 	return nil
 }
 
+type jsonTableMeta struct {
+	jsonMeta
+	TotalAuthors   int `json:"total_authors"`
+	NumFilteredOut int `json:"num_filtered_out"` // Authors cut off by -n
+}
+
+type jsonTable struct {
+	jsonTableMeta
+	Authors []jsonTally `json:"authors"`
+}
+
+// Writes the ranked tallies as a single JSON document, or, for NDJSON, as a
+// metadata line followed by one line per author.
+func writeTableJson(
+	tallies []tally.FinalTally,
+	opts tally.TallyOpts,
+	meta jsonTableMeta,
+	format OutputFormat,
+) error {
+	w := bufio.NewWriter(os.Stdout)
+
+	fields := fieldsFor(opts)
+
+	authors := []jsonTally{}
+	for _, t := range tallies {
+		authors = append(authors, toJsonTally(t, fields))
+	}
+
+	if format == NdjsonOutput {
+		err := writeJsonValue(w, meta, format)
+		if err != nil {
+			return err
+		}
+
+		for _, author := range authors {
+			err := writeJsonValue(w, author, format)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		err := writeJsonValue(
+			w,
+			jsonTable{jsonTableMeta: meta, Authors: authors},
+			format,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
+
 func toRecord(
 	t tally.FinalTally,
-	opts tally.TallyOpts,
+	fields tallyFields,
 	showEmail bool,
 ) []string {
 	record := []string{t.AuthorName}
@@ -181,7 +295,7 @@ func toRecord(
 
 	record = append(record, strconv.Itoa(t.Commits))
 
-	if opts.IsDiffMode() {
+	if fields.diffs {
 		record = append(
 			record,
 			strconv.Itoa(t.LinesAdded),
@@ -190,6 +304,18 @@ func toRecord(
 		)
 	}
 
+	if fields.blame {
+		record = append(
+			record,
+			strconv.Itoa(t.SurvivingLines),
+			strconv.Itoa(t.FileCount),
+		)
+	}
+
+	if fields.score {
+		record = append(record, strconv.FormatFloat(t.Score, 'g', -1, 64))
+	}
+
 	return append(
 		record,
 		t.LastCommitTime.Format(time.RFC3339),
@@ -197,14 +323,8 @@ func toRecord(
 	)
 }
 
-func writeCsv(
-	tallies []tally.FinalTally,
-	opts tally.TallyOpts,
-	showEmail bool,
-) error {
-	w := csv.NewWriter(os.Stdout)
-
-	// Write header
+// Column headers matching the records returned by toRecord().
+func recordHeaders(fields tallyFields, showEmail bool) []string {
 	columnHeaders := []string{"name"}
 	if showEmail {
 		columnHeaders = append(columnHeaders, "email")
@@ -212,7 +332,7 @@ func writeCsv(
 
 	columnHeaders = append(columnHeaders, "commits")
 
-	if opts.IsDiffMode() {
+	if fields.diffs {
 		columnHeaders = append(
 			columnHeaders,
 			"lines added",
@@ -221,11 +341,44 @@ func writeCsv(
 		)
 	}
 
-	columnHeaders = append(columnHeaders, "last commit time", "first commit time")
+	if fields.blame {
+		columnHeaders = append(columnHeaders, "surviving lines", "files")
+	}
+
+	if fields.score {
+		columnHeaders = append(columnHeaders, "score")
+	}
+
+	return append(columnHeaders, "last commit time", "first commit time")
+}
+
+func writeCsv(
+	tallies []tally.FinalTally,
+	opts tally.TallyOpts,
+	showEmail bool,
+	shares *tableShares, // nil unless showing shares
+) error {
+	w := csv.NewWriter(os.Stdout)
+
+	// Write header
+	fields := fieldsFor(opts)
+	columnHeaders := recordHeaders(fields, showEmail)
+	if shares != nil {
+		columnHeaders = append(columnHeaders, shares.csvHeaders()...)
+	}
 	w.Write(columnHeaders)
 
+	var cumulative float64
 	for _, tally := range tallies {
-		record := toRecord(tally, opts, showEmail)
+		record := toRecord(tally, fields, showEmail)
+		if shares != nil {
+			var values []float64
+			values, cumulative = shares.of(tally, cumulative)
+			for _, v := range values {
+				record = append(record, strconv.FormatFloat(v, 'g', -1, 64))
+			}
+		}
+
 		if err := w.Write(record); err != nil {
 			return fmt.Errorf("error writing CSV record to stdout: %w", err)
 		}
@@ -260,17 +413,182 @@ func formatAuthor(
 	return runewidth.FillRight(author, width)
 }
 
+// Totals for every author in the filtered history, including those cut off by
+// -n, used to show each author's share.
+type tableShares struct {
+	total tally.FinalTally
+	lines bool // Whether line counts are known
+	blame bool // Whether lines are surviving lines
+
+	// Whether the cumulative share adds up lines rather than commits
+	cumulativeLines bool
+}
+
+func newTableShares(
+	tallies map[string]tally.Tally,
+	opts tally.TallyOpts,
+) *tableShares {
+	isBlame := opts.Mode == tally.BlameMode
+	return &tableShares{
+		total:           tally.Total(tallies),
+		lines:           opts.IsDiffMode() || isBlame,
+		blame:           isBlame,
+		cumulativeLines: opts.Mode == tally.LinesMode || isBlame,
+	}
+}
+
+func (s *tableShares) headers() []string {
+	if s.lines {
+		return []string{"Commits%", "Lines%", "Cumul.%"}
+	}
+
+	return []string{"Commits%", "Cumul.%"}
+}
+
+func (s *tableShares) csvHeaders() []string {
+	if s.lines {
+		return []string{"commit share", "line share", "cumulative share"}
+	}
+
+	return []string{"commit share", "cumulative share"}
+}
+
+func share(n int, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return float64(n) / float64(total)
+}
+
+// Returns the shares for the given tally, given the cumulative share of the
+// authors ranked above it. Also returns the new cumulative share.
+func (s *tableShares) of(
+	t tally.FinalTally,
+	cumulative float64,
+) ([]float64, float64) {
+	commits := share(t.Commits, s.total.Commits)
+	if !s.lines {
+		return []float64{commits, cumulative + commits}, cumulative + commits
+	}
+
+	var lines float64
+	if s.blame {
+		lines = share(t.SurvivingLines, s.total.SurvivingLines)
+	} else {
+		lines = share(
+			t.LinesAdded+t.LinesRemoved,
+			s.total.LinesAdded+s.total.LinesRemoved,
+		)
+	}
+
+	if s.cumulativeLines {
+		cumulative += lines
+	} else {
+		cumulative += commits
+	}
+
+	return []float64{commits, lines, cumulative}, cumulative
+}
+
+// Like of(), but formats the shares as percentages.
+func (s *tableShares) cells(
+	t tally.FinalTally,
+	cumulative float64,
+) ([]string, float64) {
+	shares, cumulative := s.of(t, cumulative)
+
+	cells := []string{}
+	for _, share := range shares {
+		cells = append(cells, format.Percent(share))
+	}
+
+	return cells, cumulative
+}
+
 func writeTable(
 	tallies []tally.FinalTally,
 	colwidth int,
 	showEmail bool,
 	mode tally.TallyMode,
+	scored bool,
+	shares *tableShares, // nil unless showing shares
 	numFilteredOut int,
 ) {
 	if len(tallies) == 0 {
 		return
 	}
 
+	wide := mode == tally.LinesMode ||
+		mode == tally.FilesMode ||
+		mode == tally.CompositeMode ||
+		mode == tally.BlameMode
+
+	dateLabel := "Last Edit"
+	date := func(t tally.FinalTally) time.Time { return t.LastCommitTime }
+	if mode == tally.FirstModifiedMode {
+		dateLabel = "First Edit"
+		date = func(t tally.FinalTally) time.Time { return t.FirstCommitTime }
+	}
+
+	linesLabel := "Lines (+/-)"
+	lines := func(t tally.FinalTally) string {
+		return fmt.Sprintf(
+			"%s%7s%s / %s%7s%s",
+			pretty.Green,
+			format.Number(t.LinesAdded),
+			pretty.DefaultColor,
+			pretty.Red,
+			format.Number(t.LinesRemoved),
+			pretty.DefaultColor,
+		)
+	}
+	if mode == tally.BlameMode {
+		linesLabel = "Surviving Lines"
+		lines = func(t tally.FinalTally) string {
+			return format.Number(t.SurvivingLines)
+		}
+	}
+
+	// Columns between the author and any optional columns
+	columns := func(date, commits, files, lines string) string {
+		if wide {
+			return fmt.Sprintf(
+				" %-11s %7s %7s  %17s",
+				date,
+				commits,
+				files,
+				lines,
+			)
+		}
+
+		return fmt.Sprintf(" %-11s %7s", date, commits)
+	}
+	columnsWidth := 20
+	if wide {
+		columnsWidth = 47
+	}
+
+	// Optional columns go at the end. Scores are taken from the author column,
+	// while shares make the table wider.
+	extras := []string{}
+	if scored {
+		extras = append(extras, "Score")
+	}
+	if shares != nil {
+		extras = append(extras, shares.headers()...)
+		colwidth += len(shares.headers()) * 9
+	}
+	extraCells := func(cells []string) string {
+		var b strings.Builder
+		for _, cell := range cells {
+			fmt.Fprintf(&b, " %8s", cell)
+		}
+		return b.String()
+	}
+
+	authorWidth := colwidth - 2 - columnsWidth - len(extras)*9
+
 	var build strings.Builder
 	for _ = range colwidth - 2 {
 		build.WriteRune('─')
@@ -279,37 +597,17 @@ func writeTable(
 
 	// -- Write header --
 	fmt.Printf("┌%s┐\n", rule)
-
-	if mode == tally.LinesMode || mode == tally.FilesMode {
-		fmt.Printf(
-			"│%-*s %-11s %7s %7s  %17s│\n",
-			colwidth-36-13,
-			"Author",
-			"Last Edit",
-			"Commits",
-			"Files",
-			"Lines (+/-)",
-		)
-	} else if mode == tally.FirstModifiedMode {
-		fmt.Printf(
-			"│%-*s %-11s %7s│\n",
-			colwidth-22,
-			"Author",
-			"First Edit",
-			"Commits",
-		)
-	} else {
-		fmt.Printf(
-			"│%-*s %-11s %7s│\n",
-			colwidth-22,
-			"Author",
-			"Last Edit",
-			"Commits",
-		)
-	}
+	fmt.Printf(
+		"│%-*s%s%s│\n",
+		authorWidth,
+		"Author",
+		columns(dateLabel, "Commits", "Files", linesLabel),
+		extraCells(extras),
+	)
 	fmt.Printf("├%s┤\n", rule)
 
 	// -- Write table rows --
+	var cumulative float64
 	totalRows := len(tallies)
 	for i, t := range tallies {
 		alternating := ""
@@ -317,46 +615,29 @@ func writeTable(
 			alternating = pretty.Invert
 		}
 
-		lines := fmt.Sprintf(
-			"%s%7s%s / %s%7s%s",
-			pretty.Green,
-			format.Number(t.LinesAdded),
-			pretty.DefaultColor,
-			pretty.Red,
-			format.Number(t.LinesRemoved),
-			pretty.DefaultColor,
-		)
+		cells := []string{}
+		if scored {
+			cells = append(cells, format.Score(t.Score))
+		}
+		if shares != nil {
+			var shareCells []string
+			shareCells, cumulative = shares.cells(t, cumulative)
+			cells = append(cells, shareCells...)
+		}
 
-		if mode == tally.LinesMode || mode == tally.FilesMode {
-			fmt.Printf(
-				"│%s%s %-11s %7s %7s  %17s%s│\n",
-				alternating,
-				formatAuthor(t, showEmail, colwidth-36-13),
-				format.RelativeTime(progStart, t.LastCommitTime),
+		fmt.Printf(
+			"│%s%s%s%s%s│\n",
+			alternating,
+			formatAuthor(t, showEmail, authorWidth),
+			columns(
+				format.RelativeTime(progStart, date(t)),
 				format.Number(t.Commits),
 				format.Number(t.FileCount),
-				lines,
-				pretty.Reset,
-			)
-		} else if mode == tally.FirstModifiedMode {
-			fmt.Printf(
-				"│%s%s %-11s %7s%s│\n",
-				alternating,
-				formatAuthor(t, showEmail, colwidth-22),
-				format.RelativeTime(progStart, t.FirstCommitTime),
-				format.Number(t.Commits),
-				pretty.Reset,
-			)
-		} else {
-			fmt.Printf(
-				"│%s%s %-11s %7s%s│\n",
-				alternating,
-				formatAuthor(t, showEmail, colwidth-22),
-				format.RelativeTime(progStart, t.LastCommitTime),
-				format.Number(t.Commits),
-				pretty.Reset,
-			)
-		}
+				lines(t),
+			),
+			extraCells(cells),
+			pretty.Reset,
+		)
 	}
 
 	if numFilteredOut > 0 {
@@ -364,5 +645,31 @@ func writeTable(
 		fmt.Printf("│%-*s│\n", colwidth-2, msg)
 	}
 
+	// -- Write footer --
+	if shares != nil {
+		t := shares.total
+
+		cells := []string{}
+		if scored {
+			cells = append(cells, "")
+		}
+		shareCells, _ := shares.cells(t, 0)
+		cells = append(cells, shareCells...)
+
+		fmt.Printf("├%s┤\n", rule)
+		fmt.Printf(
+			"│%-*s%s%s│\n",
+			authorWidth,
+			"Total",
+			columns(
+				format.RelativeTime(progStart, date(t)),
+				format.Number(t.Commits),
+				format.Number(t.FileCount),
+				lines(t),
+			),
+			extraCells(cells),
+		)
+	}
+
 	fmt.Printf("└%s┘\n", rule)
 }
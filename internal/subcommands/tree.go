@@ -1,14 +1,20 @@
 package subcommands
 
 import (
+	"bufio"
 	"context"
+	"encoding/csv"
+	"errors"
 	"fmt"
 	"maps"
 	"os"
+	"path"
 	"path/filepath"
 	"runtime"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/sinclairtarget/git-who/internal/cache"
@@ -27,14 +33,32 @@ type printTreeOpts struct {
 	mode       tally.TallyMode
 	maxDepth   int
 	showHidden bool
+	scored     bool // Show scores instead of the metric for the mode
+	numAuthors int  // Show this many top authors for each node
 	key        func(t tally.FinalTally) string
 }
 
+// Trees always include diffs, except in blame mode.
+func (opts printTreeOpts) fields() tallyFields {
+	return tallyFields{
+		diffs: opts.mode != tally.BlameMode,
+		blame: opts.mode == tally.BlameMode,
+		score: opts.scored,
+	}
+}
+
+// An author's share of the total contributions at a node.
+type treeAuthorShare struct {
+	tally tally.FinalTally
+	share float64 // Between 0 and 1
+}
+
 type treeOutputLine struct {
 	indent    string
 	path      string
 	metric    string
 	tally     tally.FinalTally
+	shares    []treeAuthorShare // Top authors, if showing more than one
 	showLine  bool
 	showTally bool
 	dimTally  bool
@@ -46,9 +70,16 @@ func Tree(
 	pathspecs []string,
 	mode tally.TallyMode,
 	depth int,
+	numAuthors int,
+	outputFormat OutputFormat,
 	showEmail bool,
 	showHidden bool,
 	countMerges bool,
+	coAuthors tally.CoAuthorCredit,
+	committer bool,
+	halfLife time.Duration,
+	weights tally.Weights,
+	followRenames bool,
 	since string,
 	until string,
 	authors []string,
@@ -70,12 +101,26 @@ func Tree(
 		mode,
 		"depth",
 		depth,
+		"numAuthors",
+		numAuthors,
+		"outputFormat",
+		outputFormat,
 		"showEmail",
 		showEmail,
 		"showHidden",
 		showHidden,
 		"countMerges",
 		countMerges,
+		"coAuthors",
+		coAuthors,
+		"committer",
+		committer,
+		"halfLife",
+		halfLife,
+		"weights",
+		weights,
+		"followRenames",
+		followRenames,
 		"since",
 		since,
 		"until",
@@ -86,41 +131,137 @@ func Tree(
 		nauthors,
 	)
 
-	wtreeset, err := git.WorkingTreeFiles(pathspecs)
-	if err != nil {
-		return err
-	}
-
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	filters := cmd.LogFilters{
-		Since:    since,
-		Until:    until,
-		Authors:  authors,
-		Nauthors: nauthors,
+		Since:     since,
+		Until:     until,
+		Authors:   authors,
+		Nauthors:  nauthors,
+		Committer: committer,
 	}
 
-	tallyOpts := tally.TallyOpts{Mode: mode, CountMerges: countMerges}
+	tallyOpts := tally.TallyOpts{
+		Mode:        mode,
+		CountMerges: countMerges,
+		CoAuthors:   coAuthors,
+		Committer:   committer,
+		HalfLife:    halfLife,
+		DecayFrom:   progStart,
+		Weights:     weights,
+	}
 	if showEmail {
 		tallyOpts.Key = func(c git.Commit) string { return c.AuthorEmail }
 	} else {
 		tallyOpts.Key = func(c git.Commit) string { return c.AuthorName }
 	}
 
+	root, err := tallyTree(
+		ctx,
+		revs,
+		pathspecs,
+		filters,
+		tallyOpts,
+		followRenames,
+	)
+	if err == tally.EmptyTreeErr {
+		logger().Debug("Tree was empty.")
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	root = root.Rank(tallyOpts)
+
+	maxDepth := depth
+	if depth == 0 {
+		maxDepth = defaultMaxDepth
+	}
+
+	opts := printTreeOpts{
+		maxDepth:   maxDepth,
+		mode:       mode,
+		showHidden: showHidden,
+		scored:     tallyOpts.IsScored(),
+		numAuthors: numAuthors,
+	}
+	if showEmail {
+		opts.key = func(t tally.FinalTally) string { return t.AuthorEmail }
+	} else {
+		opts.key = func(t tally.FinalTally) string { return t.AuthorName }
+	}
+
+	switch outputFormat {
+	case CsvOutput:
+		return writeTreeCsv(root, opts, showEmail)
+	case JsonOutput, NdjsonOutput:
+		meta := toJsonMeta(revs, pathspecs, filters, mode)
+		return writeTreeJson(root, opts, meta, outputFormat)
+	}
+
+	lines := toLines(root, ".", 0, "", []bool{}, opts, []treeOutputLine{})
+	printTree(lines, showEmail)
+	return nil
+}
+
+// Tallies commits into an unranked tree of the working directory.
+//
+// If followRenames is true, commits made to a file before it was renamed are
+// credited to the file's current path.
+//
+// Returns tally.EmptyTreeErr if there were no commits to tally.
+func tallyTree(
+	ctx context.Context,
+	revs []string,
+	pathspecs []string,
+	filters cmd.LogFilters,
+	tallyOpts tally.TallyOpts,
+	followRenames bool,
+) (*tally.TreeNode, error) {
+	wtreeset, err := git.WorkingTreeFiles(pathspecs)
+	if err != nil {
+		return nil, err
+	}
+
 	gitRootPath, err := git.GetRoot()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	configFiles, err := config.DetectSupplementalFiles(gitRootPath)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if tallyOpts.Mode == tally.BlameMode {
+		talliesByPath, err := blameByPath(ctx, revs, pathspecs, tallyOpts)
+		if err != nil {
+			return nil, err
+		}
+
+		return tally.TallyCommitsTreeFromPaths(
+			talliesByPath,
+			wtreeset,
+			gitRootPath,
+		)
+	}
+
+	var renames git.RenameHistory
+	if followRenames {
+		renames, err = git.Renames(ctx, revs)
+		if err != nil {
+			return nil, err
+		}
+
+		pathspecs, err = withRenamedPaths(pathspecs, renames, gitRootPath)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	var root *tally.TreeNode
 	if runtime.GOMAXPROCS(0) > 1 {
-		root, err = concurrent.TallyCommitsTree(
+		return concurrent.TallyCommitsTree(
 			ctx,
 			revs,
 			pathspecs,
@@ -129,76 +270,324 @@ func Tree(
 			tallyOpts,
 			wtreeset,
 			gitRootPath,
-			cache.GetCache(gitRootPath, configFiles),
+			renames,
+			cache.GetCache(gitRootPath),
 			pretty.AllowDynamic(os.Stdout),
 		)
+	}
+
+	root, err := func() (_ *tally.TreeNode, err error) {
+		commits, finish := git.CommitsWithOpts(
+			ctx,
+			revs,
+			pathspecs,
+			filters,
+			true,
+			configFiles,
+		)
+		defer func() { err = finish() }()
+
+		root, err := tally.TallyCommitsTree(
+			commits,
+			tallyOpts,
+			wtreeset,
+			gitRootPath,
+			renames,
+		)
+		return root, err
+	}()
+	if err != nil && err != tally.EmptyTreeErr {
+		return nil, fmt.Errorf("failed to tally commits: %w", err)
+	}
+
+	return root, err
+}
+
+// Adds the old paths of files under the given pathspecs that were renamed, so
+// that we also walk the commits made before the renames.
+//
+// No paths are added if there are no pathspecs, since then we walk every
+// commit anyway.
+func withRenamedPaths(
+	pathspecs []string,
+	renames git.RenameHistory,
+	gitRootPath string,
+) ([]string, error) {
+	if len(pathspecs) == 0 || renames.Len() == 0 {
+		return pathspecs, nil
+	}
 
-		if err == tally.EmptyTreeErr {
-			logger().Debug("Tree was empty.")
-			return nil
+	wtreeset, err := worktreeFromRoot(pathspecs)
+	if err != nil {
+		return nil, err
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	// Pathspecs are relative to the working dir
+	fromPaths := map[string]bool{}
+	for from, to := range renames.All() {
+		if wtreeset[to] {
+			fromPaths[from] = true
 		}
+	}
 
+	extended := slices.Clone(pathspecs)
+	for _, from := range slices.Sorted(maps.Keys(fromPaths)) {
+		absPath := filepath.Join(gitRootPath, filepath.FromSlash(from))
+		relPath, err := filepath.Rel(wd, absPath)
 		if err != nil {
-			return err
+			return nil, err
 		}
-	} else {
-		root, err = func() (_ *tally.TreeNode, err error) {
-			commits, finish := git.CommitsWithOpts(
-				ctx,
-				revs,
-				pathspecs,
-				filters,
-				true,
-				configFiles,
-			)
-			defer func() { err = finish() }()
 
-			root, err := tally.TallyCommitsTree(
-				commits,
-				tallyOpts,
-				wtreeset,
-				gitRootPath,
-			)
-			return root, err
-		}()
-
-		if err == tally.EmptyTreeErr {
-			logger().Debug("Tree was empty.")
-			return nil
-		} else if err != nil {
-			return fmt.Errorf("failed to tally commits: %w", err)
+		extended = append(extended, filepath.ToSlash(relPath))
+	}
+
+	return extended, nil
+}
+
+// Runs git blame on every working tree file under the given pathspecs and
+// tallies the surviving lines by author and then by path.
+//
+// Paths are relative to the repository root.
+func blameByPath(
+	ctx context.Context,
+	revs []string,
+	pathspecs []string,
+	tallyOpts tally.TallyOpts,
+) (_ tally.TalliesByPath, err error) {
+	if len(revs) != 1 || strings.HasPrefix(revs[0], "^") {
+		return nil, errors.New("blame mode requires a single revision")
+	}
+	rev := revs[0]
+
+	gitRootPath, err := git.GetRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	configFiles, err := config.DetectSupplementalFiles(gitRootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	treeBlobs, err := git.TreeBlobs(ctx, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	wtreeset, err := worktreeFromRoot(pathspecs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only blame files that are still in the working tree
+	blobs := map[string]string{}
+	for p, blob := range treeBlobs {
+		if wtreeset[p] {
+			blobs[p] = blob
 		}
 	}
 
-	root = root.Rank(mode)
+	if runtime.GOMAXPROCS(0) > 1 {
+		return concurrent.TallyBlames(
+			ctx,
+			rev,
+			blobs,
+			gitRootPath,
+			configFiles,
+			tallyOpts,
+			cache.GetBlameCache(gitRootPath, configFiles),
+			pretty.AllowDynamic(os.Stdout),
+		)
+	}
 
-	maxDepth := depth
-	if depth == 0 {
-		maxDepth = defaultMaxDepth
+	mm, err := configFiles.Mailmap()
+	if err != nil {
+		return nil, err
 	}
 
-	opts := printTreeOpts{
-		maxDepth:   maxDepth,
-		mode:       mode,
-		showHidden: showHidden,
+	blames := []git.FileBlame{}
+	for _, p := range slices.Sorted(maps.Keys(blobs)) {
+		blame, err := git.Blame(
+			ctx,
+			rev,
+			gitRootPath,
+			p,
+			blobs[p],
+			configFiles.IgnoreRevsPath,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		blames = append(blames, blame)
 	}
-	if showEmail {
-		opts.key = func(t tally.FinalTally) string { return t.AuthorEmail }
-	} else {
-		opts.key = func(t tally.FinalTally) string { return t.AuthorName }
+
+	err = git.UseRawBlameAuthors(ctx, blames)
+	if err != nil {
+		return nil, err
+	}
+
+	return tally.TallyBlames(
+		git.MapBlameIdentities(slices.Values(blames), mm),
+		tallyOpts,
+	)
+}
+
+// A tree node along with its path, for when we want to print nodes in a flat
+// list instead of as a tree.
+type flatTreeNode struct {
+	path string
+	node *tally.TreeNode
+}
+
+func (n flatTreeNode) isDir() bool {
+	return len(n.node.Children) > 0
+}
+
+// Lists the nodes in the tree in the same order they would appear in the
+// printed tree, respecting max depth and whether to show hidden paths.
+func flattenTree(
+	node *tally.TreeNode,
+	p string,
+	depth int,
+	opts printTreeOpts,
+	nodes []flatTreeNode,
+) []flatTreeNode {
+	if path.Base(p) == tally.NoDiffPathname || depth > opts.maxDepth {
+		return nodes
+	}
+
+	if !node.InWorkTree && !opts.showHidden {
+		return nodes
+	}
+
+	nodes = append(nodes, flatTreeNode{path: p, node: node})
+
+	for _, childPath := range sortedChildPaths(node) {
+		nodes = flattenTree(
+			node.Children[childPath],
+			path.Join(p, childPath),
+			depth+1,
+			opts,
+			nodes,
+		)
+	}
+
+	return nodes
+}
+
+// One row per author per node. The row with rank 1 is the node's winner.
+func writeTreeCsv(
+	root *tally.TreeNode,
+	opts printTreeOpts,
+	showEmail bool,
+) error {
+	w := csv.NewWriter(os.Stdout)
+
+	columnHeaders := slices.Concat(
+		[]string{"path", "directory", "in working tree", "rank"},
+		recordHeaders(opts.fields(), showEmail),
+	)
+	w.Write(columnHeaders)
+
+	for _, n := range flattenTree(root, ".", 0, opts, []flatTreeNode{}) {
+		for i, t := range n.node.Ranked {
+			record := slices.Concat(
+				[]string{
+					n.path,
+					strconv.FormatBool(n.isDir()),
+					strconv.FormatBool(n.node.InWorkTree),
+					strconv.Itoa(i + 1),
+				},
+				toRecord(t, opts.fields(), showEmail),
+			)
+			if err := w.Write(record); err != nil {
+				return fmt.Errorf("error writing CSV record to stdout: %w", err)
+			}
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("error flushing CSV writer: %w", err)
 	}
 
-	lines := toLines(root, ".", 0, "", []bool{}, opts, []treeOutputLine{})
-	printTree(lines, showEmail)
 	return nil
 }
 
+type jsonTreeNode struct {
+	Path       string      `json:"path"`
+	IsDir      bool        `json:"is_dir"`
+	InWorkTree bool        `json:"in_work_tree"`
+	Winner     jsonTally   `json:"winner"`
+	Authors    []jsonTally `json:"authors"` // Ranked, including winner
+}
+
+type jsonTree struct {
+	jsonMeta
+	Nodes []jsonTreeNode `json:"nodes"`
+}
+
+// Writes the tree as a single JSON document, or, for NDJSON, as a metadata
+// line followed by one line per node.
+func writeTreeJson(
+	root *tally.TreeNode,
+	opts printTreeOpts,
+	meta jsonMeta,
+	format OutputFormat,
+) error {
+	w := bufio.NewWriter(os.Stdout)
+	fields := opts.fields()
+
+	nodes := []jsonTreeNode{}
+	for _, n := range flattenTree(root, ".", 0, opts, []flatTreeNode{}) {
+		authors := []jsonTally{}
+		for _, t := range n.node.Ranked {
+			authors = append(authors, toJsonTally(t, fields))
+		}
+
+		nodes = append(nodes, jsonTreeNode{
+			Path:       n.path,
+			IsDir:      n.isDir(),
+			InWorkTree: n.node.InWorkTree,
+			Winner:     toJsonTally(n.node.Tally, fields),
+			Authors:    authors,
+		})
+	}
+
+	if format == NdjsonOutput {
+		err := writeJsonValue(w, meta, format)
+		if err != nil {
+			return err
+		}
+
+		for _, node := range nodes {
+			err := writeJsonValue(w, node, format)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		err := writeJsonValue(w, jsonTree{jsonMeta: meta, Nodes: nodes}, format)
+		if err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
+
 // Recursively descend tree, turning tree nodes into output lines.
 func toLines(
 	node *tally.TreeNode,
 	path string,
 	depth int,
-	lastAuthor string,
+	lastAuthors string,
 	isFinalChild []bool,
 	opts printTreeOpts,
 	lines []treeOutputLine,
@@ -218,7 +607,7 @@ func toLines(
 				v,
 				filepath.Join(path, k),
 				depth+1,
-				lastAuthor,
+				lastAuthors,
 				isFinalChild,
 				opts,
 				lines,
@@ -255,31 +644,20 @@ func toLines(
 
 	line.tally = node.Tally
 	line.metric = fmtTallyMetric(node.Tally, opts)
+	if opts.numAuthors > 1 {
+		line.shares = topAuthorShares(node, opts)
+	}
 	line.showLine = node.InWorkTree || opts.showHidden
 	line.dimTally = len(node.Children) > 0
 	line.dimPath = !node.InWorkTree
 
-	newAuthor := opts.key(node.Tally) != lastAuthor
-	line.showTally = opts.showHidden || newAuthor || len(node.Children) > 0
+	authors := topAuthorsKey(node, opts)
+	newAuthors := authors != lastAuthors
+	line.showTally = opts.showHidden || newAuthors || len(node.Children) > 0
 
 	lines = append(lines, line)
 
-	childPaths := slices.SortedFunc(
-		maps.Keys(node.Children),
-		func(a, b string) int {
-			// Show directories first
-			aHasChildren := len(node.Children[a].Children) > 0
-			bHasChildren := len(node.Children[b].Children) > 0
-
-			if aHasChildren == bHasChildren {
-				return strings.Compare(a, b) // Sort alphabetically
-			} else if aHasChildren {
-				return -1
-			} else {
-				return 1
-			}
-		},
-	)
+	childPaths := sortedChildPaths(node)
 
 	// Find last non-hidden child
 	finalChildIndex := 0
@@ -296,7 +674,7 @@ func toLines(
 			child,
 			p,
 			depth+1,
-			opts.key(node.Tally),
+			authors,
 			append(isFinalChild, i == finalChildIndex),
 			opts,
 			lines,
@@ -306,7 +684,77 @@ func toLines(
 	return lines
 }
 
+// Identifies the set of top authors shown for a node, so we can avoid repeating
+// the same authors for a node and its children. Order doesn't matter.
+func topAuthorsKey(node *tally.TreeNode, opts printTreeOpts) string {
+	n := min(max(opts.numAuthors, 1), len(node.Ranked))
+
+	keys := []string{}
+	for _, t := range node.Ranked[:n] {
+		keys = append(keys, opts.key(t))
+	}
+	slices.Sort(keys)
+
+	return strings.Join(keys, "\x00")
+}
+
+// Returns the top authors for a node along with each author's share of the
+// total contributions by all authors to that node.
+func topAuthorShares(
+	node *tally.TreeNode,
+	opts printTreeOpts,
+) []treeAuthorShare {
+	value := func(t tally.FinalTally) float64 {
+		if opts.scored {
+			return t.Score
+		}
+
+		return float64(tally.TimelineValue(t, opts.mode))
+	}
+
+	var total float64
+	for _, t := range node.Ranked {
+		total += value(t)
+	}
+
+	shares := []treeAuthorShare{}
+	for _, t := range node.Ranked[:min(opts.numAuthors, len(node.Ranked))] {
+		var share float64
+		if total > 0 {
+			share = value(t) / total
+		}
+
+		shares = append(shares, treeAuthorShare{tally: t, share: share})
+	}
+
+	return shares
+}
+
+// Sorts child paths, putting directories first.
+func sortedChildPaths(node *tally.TreeNode) []string {
+	return slices.SortedFunc(
+		maps.Keys(node.Children),
+		func(a, b string) int {
+			// Show directories first
+			aHasChildren := len(node.Children[a].Children) > 0
+			bHasChildren := len(node.Children[b].Children) > 0
+
+			if aHasChildren == bHasChildren {
+				return strings.Compare(a, b) // Sort alphabetically
+			} else if aHasChildren {
+				return -1
+			} else {
+				return 1
+			}
+		},
+	)
+}
+
 func fmtTallyMetric(t tally.FinalTally, opts printTreeOpts) string {
+	if opts.scored {
+		return fmt.Sprintf("(score %s)", format.Score(t.Score))
+	}
+
 	switch opts.mode {
 	case tally.CommitMode:
 		return fmt.Sprintf("(%s)", format.Number(t.Commits))
@@ -332,6 +780,8 @@ func fmtTallyMetric(t tally.FinalTally, opts printTreeOpts) string {
 			"(%s)",
 			format.RelativeTime(progStart, t.FirstCommitTime),
 		)
+	case tally.BlameMode:
+		return fmt.Sprintf("(%s)", format.Number(t.SurvivingLines))
 	default:
 		panic("unrecognized mode in switch")
 	}
@@ -366,11 +816,23 @@ func printTree(lines []treeOutputLine, showEmail bool) {
 			continue
 		}
 
-		var author string
-		if showEmail {
-			author = format.Abbrev(format.GitEmail(line.tally.AuthorEmail), 25)
+		var tallyPart string
+		if len(line.shares) > 0 {
+			parts := []string{}
+			for _, s := range line.shares {
+				parts = append(parts, fmt.Sprintf(
+					"%s %s",
+					treeAuthor(s.tally, showEmail),
+					format.Percent(s.share),
+				))
+			}
+			tallyPart = strings.Join(parts, ", ")
 		} else {
-			author = format.Abbrev(line.tally.AuthorName, 25)
+			tallyPart = fmt.Sprintf(
+				"%s %s",
+				treeAuthor(line.tally, showEmail),
+				line.metric,
+			)
 		}
 
 		indentLen := utf8.RuneCountInString(line.indent)
@@ -379,26 +841,32 @@ func printTree(lines []treeOutputLine, showEmail bool) {
 
 		if line.dimTally {
 			fmt.Printf(
-				"%s%s%s%s%s%s %s\n",
+				"%s%s%s%s%s%s\n",
 				line.indent,
 				path,
 				pretty.Dim,
 				separator,
 				pretty.Reset,
-				author,
-				line.metric,
+				tallyPart,
 			)
 		} else {
 			fmt.Printf(
-				"%s%s%s%s%s %s%s\n",
+				"%s%s%s%s%s%s\n",
 				line.indent,
 				path,
 				pretty.Dim,
 				separator,
-				author,
-				line.metric,
+				tallyPart,
 				pretty.Reset,
 			)
 		}
 	}
 }
+
+func treeAuthor(t tally.FinalTally, showEmail bool) string {
+	if showEmail {
+		return format.Abbrev(format.GitEmail(t.AuthorEmail), 25)
+	}
+
+	return format.Abbrev(t.AuthorName, 25)
+}
@@ -0,0 +1,60 @@
+package tally
+
+import (
+	"iter"
+
+	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/utils/timeutils"
+)
+
+// Tally surviving lines per author per path from the output of git blame.
+//
+// An author's commit count for a path is the number of distinct commits by
+// that author that still account for lines in the file.
+func TallyBlames(
+	blames iter.Seq[git.FileBlame],
+	opts TallyOpts,
+) (TalliesByPath, error) {
+	tallies := TalliesByPath{}
+
+	for blame := range blames {
+		for _, bc := range blame.Commits {
+			key := opts.Key(git.Commit{
+				Hash:        bc.Hash,
+				AuthorName:  bc.AuthorName,
+				AuthorEmail: bc.AuthorEmail,
+				Date:        bc.Date,
+			})
+
+			pathTallies, ok := tallies[key]
+			if !ok {
+				pathTallies = map[string]Tally{}
+			}
+
+			tally, ok := pathTallies[blame.Path]
+			if !ok {
+				tally.name = bc.AuthorName
+				tally.email = bc.AuthorEmail
+				tally.firstCommitTime = bc.Date
+				tally.commitset = map[string]bool{}
+				tally.numTallied = 1 // Count toward files
+			}
+
+			tally.commitset[bc.Hash] = true
+			tally.surviving += bc.Lines
+			tally.firstCommitTime = timeutils.Min(
+				tally.firstCommitTime,
+				bc.Date,
+			)
+			tally.lastCommitTime = timeutils.Max(
+				tally.lastCommitTime,
+				bc.Date,
+			)
+
+			pathTallies[blame.Path] = tally
+			tallies[key] = pathTallies
+		}
+	}
+
+	return tallies, nil
+}
@@ -0,0 +1,77 @@
+package tally_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/tally"
+)
+
+func TestTallyBlames(t *testing.T) {
+	blames := []git.FileBlame{
+		git.FileBlame{
+			Path: "bim.txt",
+			Commits: []git.BlameCommit{
+				git.BlameCommit{
+					Hash:        "baa",
+					AuthorName:  "bob",
+					AuthorEmail: "bob@mail.com",
+					Date:        time.Unix(1000, 0),
+					Lines:       10,
+				},
+				git.BlameCommit{
+					Hash:        "bab",
+					AuthorName:  "jim",
+					AuthorEmail: "jim@mail.com",
+					Date:        time.Unix(2000, 0),
+					Lines:       3,
+				},
+			},
+		},
+		git.FileBlame{
+			Path: "vim.txt",
+			Commits: []git.BlameCommit{
+				git.BlameCommit{
+					Hash:        "bac",
+					AuthorName:  "bob",
+					AuthorEmail: "bob@mail.com",
+					Date:        time.Unix(3000, 0),
+					Lines:       2,
+				},
+			},
+		},
+	}
+
+	opts := tally.TallyOpts{
+		Mode: tally.BlameMode,
+		Key: func(c git.Commit) string {
+			return c.AuthorEmail
+		},
+	}
+	talliesByPath, err := tally.TallyBlames(slices.Values(blames), opts)
+	if err != nil {
+		t.Fatalf("TallyBlames() returned error: %v", err)
+	}
+
+	rankedTallies := tally.Rank(talliesByPath.Reduce(), opts)
+	if len(rankedTallies) != 2 {
+		t.Fatalf("expected 2 tallies but got %d", len(rankedTallies))
+	}
+
+	expected := tally.FinalTally{
+		AuthorName:      "bob",
+		AuthorEmail:     "bob@mail.com",
+		Commits:         2,
+		FileCount:       2,
+		SurvivingLines:  12,
+		FirstCommitTime: time.Unix(1000, 0),
+		LastCommitTime:  time.Unix(3000, 0),
+	}
+	if diff := cmp.Diff(expected, rankedTallies[0]); diff != "" {
+		t.Errorf("bob's tally is wrong:\n%s", diff)
+	}
+}
@@ -9,13 +9,15 @@ import (
 	"time"
 
 	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/utils/timeutils"
 )
 
 type TimeBucket struct {
 	Name       string
 	Time       time.Time
-	Tally      FinalTally // Winning author's tally
-	TotalTally FinalTally // Overall tally for all authors
+	Tally      FinalTally   // Winning author's tally
+	TotalTally FinalTally   // Overall tally for all authors
+	Ranked     []FinalTally // Every author's tally, best first
 	tallies    map[string]Tally
 }
 
@@ -27,30 +29,28 @@ func newBucket(name string, t time.Time) TimeBucket {
 	}
 }
 
-func (b TimeBucket) Value(mode TallyMode) int {
+// The value of the metric we plot in a timeline for the given tally.
+func TimelineValue(t FinalTally, mode TallyMode) int {
 	switch mode {
-	case CommitMode:
-		return b.Tally.Commits
+	case CommitMode, CompositeMode:
+		return t.Commits
 	case FilesMode:
-		return b.Tally.FileCount
+		return t.FileCount
 	case LinesMode:
-		return b.Tally.LinesAdded + b.Tally.LinesRemoved
+		return t.LinesAdded + t.LinesRemoved
+	case BlameMode:
+		return t.SurvivingLines
 	default:
 		panic("unrecognized tally mode in switch")
 	}
 }
 
+func (b TimeBucket) Value(mode TallyMode) int {
+	return TimelineValue(b.Tally, mode)
+}
+
 func (b TimeBucket) TotalValue(mode TallyMode) int {
-	switch mode {
-	case CommitMode:
-		return b.TotalTally.Commits
-	case FilesMode:
-		return b.TotalTally.FileCount
-	case LinesMode:
-		return b.TotalTally.LinesAdded + b.TotalTally.LinesRemoved
-	default:
-		panic("unrecognized tally mode in switch")
-	}
+	return TimelineValue(b.TotalTally, mode)
 }
 
 func (a TimeBucket) Combine(b TimeBucket) TimeBucket {
@@ -75,15 +75,11 @@ func (a TimeBucket) Combine(b TimeBucket) TimeBucket {
 	return merged
 }
 
-func (b TimeBucket) Rank(mode TallyMode) TimeBucket {
+func (b TimeBucket) Rank(opts TallyOpts) TimeBucket {
 	if len(b.tallies) > 0 {
-		b.Tally = Rank(b.tallies, mode)[0]
-
-		var runningTally Tally
-		for _, tally := range b.tallies {
-			runningTally = runningTally.Combine(tally)
-		}
-		b.TotalTally = runningTally.Final()
+		b.Ranked = Rank(b.tallies, opts)
+		b.Tally = b.Ranked[0]
+		b.TotalTally = Total(b.tallies)
 	}
 
 	return b
@@ -211,7 +207,7 @@ func TallyCommitsByDate(
 	buckets := map[int64]TimeBucket{} // Map of (unix) time to bucket
 
 	// Tally
-	for commit := range commits {
+	for commit := range attribute(commits, opts) {
 		bucketedCommitTime := resolution.apply(commit.Date)
 		if bucketedCommitTime.Before(minTime) {
 			minTime = bucketedCommitTime
@@ -237,18 +233,40 @@ func TallyCommitsByDate(
 				tally.name = commit.AuthorName
 				tally.email = commit.AuthorEmail
 				tally.fileset = map[string]bool{}
+				tally.firstCommitTime = commit.Date
 			}
 
 			tally.numTallied += 1
+			tally.firstCommitTime = timeutils.Min(
+				tally.firstCommitTime,
+				commit.Date,
+			)
+			tally.lastCommitTime = timeutils.Max(
+				tally.lastCommitTime,
+				commit.Date,
+			)
 
 			if !commit.IsMerge {
 				for _, diff := range commit.FileDiffs {
 					tally.added += diff.LinesAdded
 					tally.removed += diff.LinesRemoved
 					tally.fileset[diff.Path] = true
+
+					if opts.IsDiffMode() {
+						tally = tally.decay(
+							opts,
+							commit,
+							diff.Path,
+							diff.LinesAdded+diff.LinesRemoved,
+						)
+					}
 				}
 			}
 
+			if !opts.IsDiffMode() {
+				tally = tally.decay(opts, commit, "", 0)
+			}
+
 			bucket.tallies[key] = tally
 			buckets[bucket.Time.Unix()] = bucket
 		}
@@ -155,3 +155,49 @@ func TestTallyCommitsTimelineEmpty(t *testing.T) {
 		)
 	}
 }
+
+func TestTimeBucketRank(t *testing.T) {
+	bucket := TimeBucket{
+		Name: "2024-04-01",
+		Time: time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local),
+		tallies: map[string]Tally{
+			"alice": {name: "alice", added: 3, numTallied: 1},
+			"bob":   {name: "bob", added: 5, removed: 1, numTallied: 2},
+		},
+	}
+
+	bucket = bucket.Rank(TallyOpts{Mode: LinesMode})
+
+	if len(bucket.Ranked) != 2 {
+		t.Fatalf("expected 2 ranked tallies but got %d", len(bucket.Ranked))
+	}
+
+	if bucket.Ranked[0].AuthorName != "bob" {
+		t.Errorf(
+			"expected bob to be ranked first but got %s",
+			bucket.Ranked[0].AuthorName,
+		)
+	}
+
+	if bucket.Tally.AuthorName != "bob" {
+		t.Errorf("expected bob to win but got %s", bucket.Tally.AuthorName)
+	}
+
+	if bucket.Value(LinesMode) != 6 {
+		t.Errorf("expected value of 6 but got %d", bucket.Value(LinesMode))
+	}
+
+	if bucket.TotalValue(LinesMode) != 9 {
+		t.Errorf(
+			"expected total value of 9 but got %d",
+			bucket.TotalValue(LinesMode),
+		)
+	}
+
+	if TimelineValue(bucket.Ranked[1], LinesMode) != 3 {
+		t.Errorf(
+			"expected alice's value to be 3 but got %d",
+			TimelineValue(bucket.Ranked[1], LinesMode),
+		)
+	}
+}
@@ -0,0 +1,43 @@
+package tally
+
+/*
+* BusFactor() returns the smallest group of authors who together account for at
+* least the given share (0 to 1) of the total value of the given tallies, which
+* must already be ranked according to mode.
+*
+* Only authors for whom isActive() returns true can be part of the group, but
+* every author's contributions count toward the total. So if too much of the
+* work was done by inactive authors, the group is empty: nobody left covers the
+* required share. A nil isActive() treats every author as active.
+ */
+func BusFactor(
+	ranked []FinalTally,
+	mode TallyMode,
+	share float64,
+	isActive func(t FinalTally) bool,
+) []FinalTally {
+	total := 0
+	for _, t := range ranked {
+		total += TimelineValue(t, mode)
+	}
+
+	if total == 0 {
+		return []FinalTally{}
+	}
+
+	covering := []FinalTally{}
+	covered := 0
+	for _, t := range ranked {
+		if isActive != nil && !isActive(t) {
+			continue
+		}
+
+		covering = append(covering, t)
+		covered += TimelineValue(t, mode)
+		if float64(covered) >= share*float64(total) {
+			return covering
+		}
+	}
+
+	return []FinalTally{}
+}
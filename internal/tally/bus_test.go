@@ -0,0 +1,82 @@
+package tally_test
+
+import (
+	"testing"
+
+	"github.com/sinclairtarget/git-who/internal/tally"
+)
+
+func TestBusFactor(t *testing.T) {
+	ranked := []tally.FinalTally{
+		tally.FinalTally{AuthorName: "bob", Commits: 6},
+		tally.FinalTally{AuthorName: "jim", Commits: 3},
+		tally.FinalTally{AuthorName: "sue", Commits: 1},
+	}
+
+	notBob := func(t tally.FinalTally) bool { return t.AuthorName != "bob" }
+
+	tests := []struct {
+		name     string
+		share    float64
+		isActive func(t tally.FinalTally) bool
+		expected []string
+	}{
+		{
+			name:     "one_author_suffices",
+			share:    0.5,
+			expected: []string{"bob"},
+		},
+		{
+			name:     "needs_two_authors",
+			share:    0.8,
+			expected: []string{"bob", "jim"},
+		},
+		{
+			name:     "needs_everyone",
+			share:    1,
+			expected: []string{"bob", "jim", "sue"},
+		},
+		{
+			name:     "inactive_author_skipped",
+			share:    0.4,
+			isActive: notBob,
+			expected: []string{"jim", "sue"},
+		},
+		{
+			name:     "inactive_author_leaves_too_little",
+			share:    0.5,
+			isActive: notBob,
+			expected: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			covering := tally.BusFactor(
+				ranked,
+				tally.CommitMode,
+				test.share,
+				test.isActive,
+			)
+
+			if len(covering) != len(test.expected) {
+				t.Fatalf(
+					"expected %d authors but got %d",
+					len(test.expected),
+					len(covering),
+				)
+			}
+
+			for i, name := range test.expected {
+				if covering[i].AuthorName != name {
+					t.Errorf(
+						"expected author %d to be %s but got %s",
+						i,
+						name,
+						covering[i].AuthorName,
+					)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package tally
+
+import (
+	"iter"
+
+	"github.com/sinclairtarget/git-who/internal/git"
+)
+
+// How we credit the people named in a commit's "Co-authored-by" trailers.
+type CoAuthorCredit int
+
+const (
+	NoCoAuthorCredit    CoAuthorCredit = iota
+	FullCoAuthorCredit                 // Everyone gets credit for all lines
+	SplitCoAuthorCredit                // Lines are divided between everyone
+)
+
+func (c CoAuthorCredit) String() string {
+	switch c {
+	case NoCoAuthorCredit:
+		return "none"
+	case FullCoAuthorCredit:
+		return "full"
+	case SplitCoAuthorCredit:
+		return "split"
+	default:
+		panic("unrecognized co-author credit in switch statement")
+	}
+}
+
+/*
+* creditCoAuthors() yields a copy of each commit for its author and for each of
+* its co-authors, so that tallying the copies credits everyone.
+*
+* In split mode, the lines added and removed in each file are divided evenly,
+* with any remainder going to the commit author. Each person still counts the
+* commit and the files it touched in full.
+*
+* A co-author with the same key as the author or an earlier co-author is only
+* credited once.
+ */
+func creditCoAuthors(
+	commits iter.Seq[git.Commit],
+	opts TallyOpts,
+) iter.Seq[git.Commit] {
+	if opts.CoAuthors == NoCoAuthorCredit {
+		return commits
+	}
+
+	return func(yield func(git.Commit) bool) {
+		for commit := range commits {
+			credited := []git.Commit{commit}
+			seen := map[string]bool{opts.Key(commit): true}
+
+			for _, coAuthor := range commit.CoAuthors {
+				c := commit
+				c.AuthorName = coAuthor.Name
+				c.AuthorEmail = coAuthor.Email
+
+				key := opts.Key(c)
+				if key == "" || seen[key] {
+					continue
+				}
+
+				seen[key] = true
+				credited = append(credited, c)
+			}
+
+			if opts.CoAuthors == SplitCoAuthorCredit && len(credited) > 1 {
+				credited = splitLines(credited)
+			}
+
+			for _, c := range credited {
+				c.CoAuthors = nil
+				if !yield(c) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Divides the lines of each file diff between copies of the same commit.
+func splitLines(credited []git.Commit) []git.Commit {
+	n := len(credited)
+
+	for i := range credited {
+		diffs := make([]git.FileDiff, len(credited[i].FileDiffs))
+		for j, diff := range credited[i].FileDiffs {
+			added := diff.LinesAdded / n
+			removed := diff.LinesRemoved / n
+			if i == 0 {
+				added += diff.LinesAdded % n
+				removed += diff.LinesRemoved % n
+			}
+
+			diff.LinesAdded = added
+			diff.LinesRemoved = removed
+			diffs[j] = diff
+		}
+
+		credited[i].FileDiffs = diffs
+	}
+
+	return credited
+}
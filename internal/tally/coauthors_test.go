@@ -0,0 +1,103 @@
+package tally_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/tally"
+)
+
+func TestTallyCommitsCoAuthors(t *testing.T) {
+	commits := []git.Commit{
+		git.Commit{
+			Hash:        "baa",
+			ShortHash:   "baa",
+			AuthorName:  "bob",
+			AuthorEmail: "bob@mail.com",
+			CoAuthors: []git.CoAuthor{
+				git.CoAuthor{Name: "jim", Email: "jim@mail.com"},
+				git.CoAuthor{Name: "sue", Email: "sue@mail.com"},
+				git.CoAuthor{Name: "Bob", Email: "bob@mail.com"},
+			},
+			FileDiffs: []git.FileDiff{
+				git.FileDiff{
+					Path:         "bim.txt",
+					LinesAdded:   10,
+					LinesRemoved: 2,
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		coAuthors tally.CoAuthorCredit
+		expected  map[string][2]int // Email -> lines added, removed
+	}{
+		{
+			name:      "no_credit",
+			coAuthors: tally.NoCoAuthorCredit,
+			expected: map[string][2]int{
+				"bob@mail.com": {10, 2},
+			},
+		},
+		{
+			name:      "full_credit",
+			coAuthors: tally.FullCoAuthorCredit,
+			expected: map[string][2]int{
+				"bob@mail.com": {10, 2},
+				"jim@mail.com": {10, 2},
+				"sue@mail.com": {10, 2},
+			},
+		},
+		{
+			name:      "split_credit",
+			coAuthors: tally.SplitCoAuthorCredit,
+			expected: map[string][2]int{
+				"bob@mail.com": {4, 2},
+				"jim@mail.com": {3, 0},
+				"sue@mail.com": {3, 0},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := tally.TallyOpts{
+				Mode: tally.LinesMode,
+				Key: func(c git.Commit) string {
+					return c.AuthorEmail
+				},
+				CoAuthors: test.coAuthors,
+			}
+
+			tallies, err := tally.TallyCommits(slices.Values(commits), opts)
+			if err != nil {
+				t.Fatalf("TallyCommits() returned error: %v", err)
+			}
+
+			lines := map[string][2]int{}
+			for _, final := range tally.Rank(tallies, opts) {
+				if final.Commits != 1 {
+					t.Errorf(
+						"expected %s to have 1 commit but got %d",
+						final.AuthorEmail,
+						final.Commits,
+					)
+				}
+
+				lines[final.AuthorEmail] = [2]int{
+					final.LinesAdded,
+					final.LinesRemoved,
+				}
+			}
+
+			if diff := cmp.Diff(test.expected, lines); diff != "" {
+				t.Errorf("lines are wrong:\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package tally
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// Two authors who edited some of the same files.
+type Collaboration struct {
+	Keys        [2]string // Keys of the two authors, in sorted order
+	SharedFiles int       // Num files both authors edited
+}
+
+/*
+* Collaborations() returns every pair of authors who edited at least minShared
+* of the same files, according to their contributions tallied by path (see
+* TallyCommitsByPath()). Pairs who share the most files come first.
+ */
+func Collaborations(byPath TalliesByPath, minShared int) []Collaboration {
+	// Invert to path -> keys of authors who edited it
+	editors := map[string][]string{}
+	for key, pathTallies := range byPath {
+		for p := range pathTallies {
+			if p != NoDiffPathname {
+				editors[p] = append(editors[p], key)
+			}
+		}
+	}
+
+	shared := map[[2]string]int{}
+	for _, keys := range editors {
+		slices.Sort(keys)
+		for i, a := range keys {
+			for _, b := range keys[i+1:] {
+				shared[[2]string{a, b}] += 1
+			}
+		}
+	}
+
+	collabs := []Collaboration{}
+	for keys, n := range shared {
+		if n >= minShared {
+			collabs = append(collabs, Collaboration{Keys: keys, SharedFiles: n})
+		}
+	}
+
+	slices.SortFunc(collabs, func(a, b Collaboration) int {
+		return cmp.Or(
+			-cmp.Compare(a.SharedFiles, b.SharedFiles),
+			strings.Compare(a.Keys[0], b.Keys[0]),
+			strings.Compare(a.Keys[1], b.Keys[1]),
+		)
+	})
+	return collabs
+}
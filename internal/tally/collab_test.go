@@ -0,0 +1,83 @@
+package tally_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/tally"
+)
+
+func TestCollaborations(t *testing.T) {
+	commit := func(hash string, name string, paths ...string) git.Commit {
+		c := git.Commit{
+			Hash:        hash,
+			ShortHash:   hash,
+			AuthorName:  name,
+			AuthorEmail: name + "@mail.com",
+		}
+		for _, p := range paths {
+			c.FileDiffs = append(c.FileDiffs, git.FileDiff{
+				Path:       p,
+				LinesAdded: 1,
+			})
+		}
+
+		return c
+	}
+
+	// Commits without diffs (e.g. empty commits) don't make anyone
+	// collaborators
+	commits := []git.Commit{
+		commit("baa", "bob", "foo.txt", "bar.txt"),
+		commit("bab", "jim", "foo.txt"),
+		commit("bac", "jim", "bar.txt", "baz.txt"),
+		commit("bad", "sue", "baz.txt"),
+		commit("bae", "ann", "qux.txt"),
+		commit("baf", "ann"),
+		commit("bag", "sue"),
+	}
+
+	opts := tally.TallyOpts{
+		Mode: tally.CommitMode,
+		Key:  func(c git.Commit) string { return c.AuthorName },
+	}
+
+	byPath, err := tally.TallyCommitsByPath(slices.Values(commits), opts)
+	if err != nil {
+		t.Fatalf("TallyCommitsByPath() returned error: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		minShared int
+		expected  []tally.Collaboration
+	}{
+		{
+			name:      "all",
+			minShared: 1,
+			expected: []tally.Collaboration{
+				tally.Collaboration{Keys: [2]string{"bob", "jim"}, SharedFiles: 2},
+				tally.Collaboration{Keys: [2]string{"jim", "sue"}, SharedFiles: 1},
+			},
+		},
+		{
+			name:      "min_shared",
+			minShared: 2,
+			expected: []tally.Collaboration{
+				tally.Collaboration{Keys: [2]string{"bob", "jim"}, SharedFiles: 2},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			collabs := tally.Collaborations(byPath, test.minShared)
+			if diff := cmp.Diff(test.expected, collabs); diff != "" {
+				t.Errorf("collaborations are wrong:\n%s", diff)
+			}
+		})
+	}
+}
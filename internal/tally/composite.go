@@ -0,0 +1,141 @@
+package tally
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// How much each metric counts toward the score in CompositeMode.
+//
+// Only the ratios between weights matter.
+type Weights struct {
+	Commits float64
+	Lines   float64 // Lines added + lines removed
+	Files   float64
+	Recency float64 // How recently the author last committed
+}
+
+var DefaultWeights = Weights{Commits: 1, Lines: 1, Files: 1, Recency: 1}
+
+func (w Weights) String() string {
+	return fmt.Sprintf(
+		"commits=%s,lines=%s,files=%s,recency=%s",
+		strconv.FormatFloat(w.Commits, 'g', -1, 64),
+		strconv.FormatFloat(w.Lines, 'g', -1, 64),
+		strconv.FormatFloat(w.Files, 'g', -1, 64),
+		strconv.FormatFloat(w.Recency, 'g', -1, 64),
+	)
+}
+
+func (w Weights) total() float64 {
+	return w.Commits + w.Lines + w.Files + w.Recency
+}
+
+// Parses weights given as a comma-separated list of metric=weight pairs, e.g.
+// "commits=2,lines=1,recency=0.5". Metrics not listed get a weight of zero.
+func ParseWeights(s string) (_ Weights, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("error parsing weights %q: %w", s, err)
+		}
+	}()
+
+	var w Weights
+	for _, pair := range strings.Split(s, ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found {
+			return w, fmt.Errorf("expected metric=weight but got %q", pair)
+		}
+
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return w, fmt.Errorf("bad weight for %s: %w", name, err)
+		}
+
+		if f < 0 {
+			return w, fmt.Errorf("weight for %s cannot be negative", name)
+		}
+
+		switch name {
+		case "commits":
+			w.Commits = f
+		case "lines":
+			w.Lines = f
+		case "files":
+			w.Files = f
+		case "recency":
+			w.Recency = f
+		default:
+			return w, fmt.Errorf(
+				"unknown metric %q (expected commits, lines, files, or recency)",
+				name,
+			)
+		}
+	}
+
+	if w.total() == 0 {
+		return w, errors.New("at least one weight must be positive")
+	}
+
+	return w, nil
+}
+
+func ratio(n int, max int) float64 {
+	if max == 0 {
+		return 0
+	}
+
+	return float64(n) / float64(max)
+}
+
+/*
+* scoreComposite() sets the score of each tally to a weighted sum of its
+* metrics, scaled so that an author who leads on every metric scores 100.
+*
+* Commits, lines, and files are normalized against the largest value among the
+* given tallies. Recency is where the author's last commit falls between the
+* earliest commit and the latest commit among the given tallies.
+ */
+func scoreComposite(tallies []FinalTally, w Weights) {
+	if len(tallies) == 0 {
+		return
+	}
+
+	if w == (Weights{}) {
+		w = DefaultWeights
+	}
+
+	var maxCommits, maxLines, maxFiles int
+	start := tallies[0].FirstCommitTime
+	end := tallies[0].LastCommitTime
+	for _, t := range tallies {
+		maxCommits = max(maxCommits, t.Commits)
+		maxLines = max(maxLines, t.LinesAdded+t.LinesRemoved)
+		maxFiles = max(maxFiles, t.FileCount)
+
+		if t.FirstCommitTime.Before(start) {
+			start = t.FirstCommitTime
+		}
+
+		if t.LastCommitTime.After(end) {
+			end = t.LastCommitTime
+		}
+	}
+
+	span := end.Sub(start)
+	for i, t := range tallies {
+		recency := 1.0
+		if span > 0 {
+			recency = float64(t.LastCommitTime.Sub(start)) / float64(span)
+		}
+
+		sum := w.Commits*ratio(t.Commits, maxCommits) +
+			w.Lines*ratio(t.LinesAdded+t.LinesRemoved, maxLines) +
+			w.Files*ratio(t.FileCount, maxFiles) +
+			w.Recency*recency
+
+		tallies[i].Score = sum / w.total() * 100
+	}
+}
@@ -0,0 +1,177 @@
+package tally_test
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/tally"
+)
+
+func TestParseWeights(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		expected tally.Weights
+		wantErr  bool
+	}{
+		{
+			name: "all",
+			spec: "commits=1,lines=2,files=0.5,recency=3",
+			expected: tally.Weights{
+				Commits: 1,
+				Lines:   2,
+				Files:   0.5,
+				Recency: 3,
+			},
+		},
+		{
+			name:     "some",
+			spec:     "lines=1, recency=1",
+			expected: tally.Weights{Lines: 1, Recency: 1},
+		},
+		{
+			name:    "unknown_metric",
+			spec:    "commits=1,bugs=2",
+			wantErr: true,
+		},
+		{
+			name:    "negative",
+			spec:    "commits=-1",
+			wantErr: true,
+		},
+		{
+			name:    "all_zero",
+			spec:    "commits=0",
+			wantErr: true,
+		},
+		{
+			name:    "missing_value",
+			spec:    "commits",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			weights, err := tally.ParseWeights(test.spec)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected error but got weights %v", weights)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseWeights() returned error: %v", err)
+			}
+
+			if diff := cmp.Diff(test.expected, weights); diff != "" {
+				t.Errorf("weights are wrong:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRankComposite(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Bob wrote more lines, but jim committed more often and more recently
+	commits := []git.Commit{
+		git.Commit{
+			Hash:        "baa",
+			ShortHash:   "baa",
+			AuthorName:  "bob",
+			AuthorEmail: "bob@mail.com",
+			Date:        start,
+			FileDiffs: []git.FileDiff{
+				git.FileDiff{Path: "bim.txt", LinesAdded: 100},
+			},
+		},
+		git.Commit{
+			Hash:        "bab",
+			ShortHash:   "bab",
+			AuthorName:  "jim",
+			AuthorEmail: "jim@mail.com",
+			Date:        start.Add(24 * time.Hour),
+			FileDiffs: []git.FileDiff{
+				git.FileDiff{Path: "bim.txt", LinesAdded: 10},
+			},
+		},
+		git.Commit{
+			Hash:        "bac",
+			ShortHash:   "bac",
+			AuthorName:  "jim",
+			AuthorEmail: "jim@mail.com",
+			Date:        start.Add(48 * time.Hour),
+			FileDiffs: []git.FileDiff{
+				git.FileDiff{Path: "bim.txt", LinesAdded: 10},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		weights  tally.Weights
+		expected map[string]float64 // Email -> score
+	}{
+		{
+			name:    "default",
+			weights: tally.Weights{},
+			expected: map[string]float64{
+				"jim@mail.com": 80,
+				"bob@mail.com": 62.5,
+			},
+		},
+		{
+			name:    "lines_only",
+			weights: tally.Weights{Lines: 1},
+			expected: map[string]float64{
+				"bob@mail.com": 100,
+				"jim@mail.com": 20,
+			},
+		},
+		{
+			name:    "commits_and_recency",
+			weights: tally.Weights{Commits: 1, Recency: 1},
+			expected: map[string]float64{
+				"jim@mail.com": 100,
+				"bob@mail.com": 25,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := tally.TallyOpts{
+				Mode:    tally.CompositeMode,
+				Key:     func(c git.Commit) string { return c.AuthorEmail },
+				Weights: test.weights,
+			}
+
+			tallies, err := tally.TallyCommits(slices.Values(commits), opts)
+			if err != nil {
+				t.Fatalf("TallyCommits() returned error: %v", err)
+			}
+
+			ranked := tally.Rank(tallies, opts)
+
+			scores := map[string]float64{}
+			for _, final := range ranked {
+				scores[final.AuthorEmail] = math.Round(final.Score*1000) / 1000
+			}
+
+			if diff := cmp.Diff(test.expected, scores); diff != "" {
+				t.Errorf("scores are wrong:\n%s", diff)
+			}
+
+			if ranked[0].Score < ranked[1].Score {
+				t.Errorf("tallies are not ranked by score")
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package tally
+
+import (
+	"maps"
+	"math"
+	"time"
+
+	"github.com/sinclairtarget/git-who/internal/git"
+)
+
+// Whether contributions are weighted by their age.
+func (opts TallyOpts) IsDecayed() bool {
+	return opts.HalfLife > 0
+}
+
+// Weight of a contribution made at the given time. Halves every half-life.
+func (opts TallyOpts) decayWeight(t time.Time) float64 {
+	age := max(opts.DecayFrom.Sub(t), 0)
+	return math.Exp2(-age.Hours() / opts.HalfLife.Hours())
+}
+
+/*
+* decay() records a commit's contribution to the tally, weighted by the
+* commit's age.
+*
+* What counts as a contribution depends on the mode. In lines mode, it is the
+* number of lines changed in the given path. In files mode, it is the path
+* itself; a path touched by several commits is weighted by the most recent. In
+* the other modes it is the commit itself.
+*
+* Contributions are keyed so that combining two tallies that both saw the same
+* contribution counts it only once.
+ */
+func (t Tally) decay(
+	opts TallyOpts,
+	commit git.Commit,
+	path string,
+	linesChanged int,
+) Tally {
+	if !opts.IsDecayed() {
+		return t
+	}
+
+	if t.decayed == nil {
+		t.decayed = map[string]float64{}
+	}
+
+	weight := opts.decayWeight(commit.Date)
+
+	var key string
+	switch opts.Mode {
+	case FilesMode:
+		key = path
+	case LinesMode:
+		key = commit.Hash + "\x00" + path
+		weight *= float64(linesChanged)
+	default:
+		key = commit.Hash
+	}
+
+	t.decayed[key] = max(t.decayed[key], weight)
+	return t
+}
+
+// Combines two sets of decayed contributions, keeping the larger weight for
+// any contribution in both. Modifies a, unless a is nil, in which case b is
+// copied so that later changes to the result don't show up in b.
+func mergeDecayedInPlace(a, b map[string]float64) map[string]float64 {
+	if a == nil {
+		return maps.Clone(b)
+	}
+
+	for k, v := range b {
+		a[k] = max(a[k], v)
+	}
+
+	return a
+}
+
+func sumDecayed(decayed map[string]float64) float64 {
+	var sum float64
+	for _, v := range decayed {
+		sum += v
+	}
+
+	return sum
+}
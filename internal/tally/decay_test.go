@@ -0,0 +1,197 @@
+package tally_test
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/tally"
+)
+
+func TestTallyCommitsDecay(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	halfLife := 365 * 24 * time.Hour
+	twoHalfLivesAgo := now.Add(-2 * halfLife)
+
+	commits := []git.Commit{
+		git.Commit{
+			Hash:        "baa",
+			ShortHash:   "baa",
+			AuthorName:  "bob",
+			AuthorEmail: "bob@mail.com",
+			Date:        twoHalfLivesAgo,
+			FileDiffs: []git.FileDiff{
+				git.FileDiff{Path: "bim.txt", LinesAdded: 100},
+				git.FileDiff{Path: "bar.txt", LinesAdded: 20},
+			},
+		},
+		git.Commit{
+			Hash:        "bab",
+			ShortHash:   "bab",
+			AuthorName:  "bob",
+			AuthorEmail: "bob@mail.com",
+			Date:        twoHalfLivesAgo,
+			FileDiffs: []git.FileDiff{
+				git.FileDiff{Path: "bim.txt", LinesAdded: 40},
+			},
+		},
+		git.Commit{
+			Hash:        "bac",
+			ShortHash:   "bac",
+			AuthorName:  "jim",
+			AuthorEmail: "jim@mail.com",
+			Date:        now,
+			FileDiffs: []git.FileDiff{
+				git.FileDiff{Path: "bim.txt", LinesAdded: 30, LinesRemoved: 10},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		mode     tally.TallyMode
+		expected map[string]float64 // Email -> score
+	}{
+		{
+			name: "commits",
+			mode: tally.CommitMode,
+			expected: map[string]float64{
+				"bob@mail.com": 0.5,
+				"jim@mail.com": 1,
+			},
+		},
+		{
+			name: "files",
+			mode: tally.FilesMode,
+			expected: map[string]float64{
+				"bob@mail.com": 0.5,
+				"jim@mail.com": 1,
+			},
+		},
+		{
+			name: "lines",
+			mode: tally.LinesMode,
+			expected: map[string]float64{
+				"bob@mail.com": 40,
+				"jim@mail.com": 40,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := tally.TallyOpts{
+				Mode:      test.mode,
+				Key:       func(c git.Commit) string { return c.AuthorEmail },
+				HalfLife:  halfLife,
+				DecayFrom: now,
+			}
+
+			tallies, err := tally.TallyCommits(slices.Values(commits), opts)
+			if err != nil {
+				t.Fatalf("TallyCommits() returned error: %v", err)
+			}
+
+			scores := map[string]float64{}
+			for key, tally := range tallies {
+				final := tally.Final()
+				if !final.Decayed {
+					t.Errorf("tally for %s should be decayed", key)
+				}
+
+				scores[key] = math.Round(final.Score*1000) / 1000
+			}
+
+			if diff := cmp.Diff(test.expected, scores); diff != "" {
+				t.Errorf("scores are wrong:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTallyCommitsTreeDecayCountsCommitsOnce(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	commits := []git.Commit{
+		git.Commit{
+			Hash:        "baa",
+			ShortHash:   "baa",
+			AuthorName:  "bob",
+			AuthorEmail: "bob@mail.com",
+			Date:        now,
+			FileDiffs: []git.FileDiff{
+				git.FileDiff{Path: "foo/bim.txt", LinesAdded: 1},
+				git.FileDiff{Path: "foo/bar.txt", LinesAdded: 1},
+			},
+		},
+	}
+
+	opts := tally.TallyOpts{
+		Mode:      tally.CommitMode,
+		Key:       func(c git.Commit) string { return c.AuthorEmail },
+		HalfLife:  24 * time.Hour,
+		DecayFrom: now,
+	}
+	worktreeset := map[string]bool{"foo/bim.txt": true, "foo/bar.txt": true}
+
+	root, err := tally.TallyCommitsTree(
+		slices.Values(commits),
+		opts,
+		worktreeset,
+		"",
+		git.RenameHistory{},
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsTree() returned error: %v", err)
+	}
+
+	root = root.Rank(opts)
+	if root.Tally.Score != 1 {
+		t.Errorf("expected score of 1 but got %f", root.Tally.Score)
+	}
+}
+
+func TestRankDecayed(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	halfLife := 30 * 24 * time.Hour
+
+	// Bob made more commits, but long ago
+	commits := []git.Commit{}
+	for _, hash := range []string{"baa", "bab", "bac"} {
+		commits = append(commits, git.Commit{
+			Hash:        hash,
+			ShortHash:   hash,
+			AuthorName:  "bob",
+			AuthorEmail: "bob@mail.com",
+			Date:        now.Add(-10 * halfLife),
+		})
+	}
+	commits = append(commits, git.Commit{
+		Hash:        "bad",
+		ShortHash:   "bad",
+		AuthorName:  "jim",
+		AuthorEmail: "jim@mail.com",
+		Date:        now.Add(-halfLife),
+	})
+
+	opts := tally.TallyOpts{
+		Mode:      tally.CommitMode,
+		Key:       func(c git.Commit) string { return c.AuthorEmail },
+		HalfLife:  halfLife,
+		DecayFrom: now,
+	}
+
+	tallies, err := tally.TallyCommits(slices.Values(commits), opts)
+	if err != nil {
+		t.Fatalf("TallyCommits() returned error: %v", err)
+	}
+
+	ranked := tally.Rank(tallies, opts)
+	if ranked[0].AuthorEmail != "jim@mail.com" {
+		t.Errorf("expected jim to rank first but got %s", ranked[0].AuthorEmail)
+	}
+}
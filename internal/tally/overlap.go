@@ -0,0 +1,83 @@
+package tally
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// How much another author has worked on the same files as a given author.
+type Overlap struct {
+	Tally       FinalTally // The other author's tally for just the shared files
+	SharedFiles int        // Num files in working dir both authors edited
+}
+
+/*
+* Overlaps() returns every other author who edited a file in the working tree
+* that the author with the given key also edited. Authors who share the most
+* files come first; ties are broken by their contributions to those files
+* according to mode.
+ */
+func Overlaps(root *TreeNode, key string, mode TallyMode) []Overlap {
+	shared := map[string]int{}
+	tallies := map[string]Tally{}
+	collectOverlaps(root, key, shared, tallies)
+
+	overlaps := []Overlap{}
+	for other, t := range tallies {
+		overlaps = append(overlaps, Overlap{
+			Tally:       t.Final(),
+			SharedFiles: shared[other],
+		})
+	}
+
+	slices.SortFunc(overlaps, func(a, b Overlap) int {
+		return cmp.Or(
+			-cmp.Compare(a.SharedFiles, b.SharedFiles),
+			-a.Tally.Compare(b.Tally, mode),
+			strings.Compare(a.Tally.AuthorName, b.Tally.AuthorName),
+		)
+	})
+	return overlaps
+}
+
+func collectOverlaps(
+	node *TreeNode,
+	key string,
+	shared map[string]int,
+	tallies map[string]Tally,
+) {
+	if !node.InWorkTree {
+		return
+	}
+
+	for _, child := range node.Children {
+		collectOverlaps(child, key, shared, tallies)
+	}
+
+	if len(node.Children) > 0 {
+		return
+	}
+
+	// Leaf, i.e. a file
+	if _, ok := node.tallies[key]; !ok {
+		return
+	}
+
+	for other, leafTally := range node.tallies {
+		if other == key {
+			continue
+		}
+
+		t, ok := tallies[other]
+		if !ok {
+			// Fresh set so that combining doesn't modify the leaf's tally
+			t.commitset = map[string]bool{}
+			t.firstCommitTime = time.Unix(1<<62, 0)
+		}
+
+		tallies[other] = t.Combine(leafTally)
+		shared[other] += 1
+	}
+}
@@ -0,0 +1,88 @@
+package tally_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/tally"
+)
+
+func TestOverlaps(t *testing.T) {
+	commit := func(hash string, name string, paths ...string) git.Commit {
+		c := git.Commit{
+			Hash:        hash,
+			ShortHash:   hash,
+			AuthorName:  name,
+			AuthorEmail: name + "@mail.com",
+		}
+		for _, p := range paths {
+			c.FileDiffs = append(c.FileDiffs, git.FileDiff{
+				Path:       p,
+				LinesAdded: 1,
+			})
+		}
+
+		return c
+	}
+
+	commits := []git.Commit{
+		commit("baa", "bob", "foo/bim.txt", "foo/bar.txt", "gone.txt"),
+		commit("bab", "jim", "foo/bim.txt", "foo/bar.txt"),
+		commit("bac", "jim", "foo/bim.txt"),
+		commit("bad", "sue", "foo/bar.txt", "baz.txt"),
+		commit("bae", "sue", "gone.txt"),
+		commit("baf", "ann", "baz.txt"),
+	}
+
+	worktreeset := map[string]bool{
+		"foo/bim.txt": true,
+		"foo/bar.txt": true,
+		"baz.txt":     true,
+	}
+	opts := tally.TallyOpts{
+		Mode: tally.CommitMode,
+		Key:  func(c git.Commit) string { return c.AuthorName },
+	}
+
+	root, err := tally.TallyCommitsTree(
+		slices.Values(commits),
+		opts,
+		worktreeset,
+		"",
+		git.RenameHistory{},
+	)
+	if err != nil {
+		t.Fatalf("TallyCommitsTree() returned error: %v", err)
+	}
+
+	root = root.Rank(opts)
+
+	type overlap struct {
+		Name        string
+		SharedFiles int
+		Commits     int
+	}
+
+	overlaps := []overlap{}
+	for _, o := range tally.Overlaps(root, "bob", opts.Mode) {
+		overlaps = append(overlaps, overlap{
+			Name:        o.Tally.AuthorName,
+			SharedFiles: o.SharedFiles,
+			Commits:     o.Tally.Commits,
+		})
+	}
+
+	// Sue's edit to gone.txt doesn't count since it's not in the working tree,
+	// and ann never edited a file bob edited
+	expected := []overlap{
+		overlap{Name: "jim", SharedFiles: 2, Commits: 2},
+		overlap{Name: "sue", SharedFiles: 1, Commits: 1},
+	}
+
+	if diff := cmp.Diff(expected, overlaps); diff != "" {
+		t.Errorf("overlaps are wrong:\n%s", diff)
+	}
+}
@@ -0,0 +1,124 @@
+package tally
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// An author suggested to review a change.
+type Reviewer struct {
+	AuthorName  string
+	AuthorEmail string
+	Ownership   float64  // Share (0 to 1) of the change the author owns
+	Files       []string // Changed paths the author owns part of, most first
+}
+
+// How much of a path an author owns, weighted by how much the path changed.
+type ownedPath struct {
+	path  string
+	owned float64
+}
+
+/*
+* Reviewers() ranks authors by how much they own of the paths touched by a
+* change, according to their past contributions tallied by path (see
+* TallyCommitsByPath()).
+*
+* changed maps each touched path to its weight, e.g. the number of lines
+* changed. An author owns the fraction of a path's weight equal to their share
+* of everything tallied for that path: their score if the tallies are decayed,
+* otherwise their metric according to the mode. So recent contributions count
+* for more when the tallies are decayed.
+*
+* renames maps the paths the change renamed files away from to their new paths.
+* History for the old paths counts toward the new ones. Authors whose keys are
+* in exclude are never suggested, but their contributions still count toward
+* each path's total.
+ */
+func Reviewers(
+	byPath TalliesByPath,
+	changed map[string]int,
+	renames map[string]string,
+	exclude map[string]bool,
+	opts TallyOpts,
+) []Reviewer {
+	if len(renames) > 0 {
+		byPath = followRenames(byPath, renames)
+	}
+
+	value := func(t Tally) float64 {
+		final := t.Final()
+		if opts.IsDecayed() {
+			return final.Score
+		}
+
+		return float64(TimelineValue(final, opts.Mode))
+	}
+
+	totals := map[string]float64{}
+	for _, pathTallies := range byPath {
+		for p, t := range pathTallies {
+			if _, ok := changed[p]; ok {
+				totals[p] += value(t)
+			}
+		}
+	}
+
+	totalWeight := 0
+	for _, weight := range changed {
+		totalWeight += weight
+	}
+
+	reviewers := []Reviewer{}
+	for key, pathTallies := range byPath {
+		if exclude[key] {
+			continue
+		}
+
+		var reviewer Reviewer
+		owned := []ownedPath{}
+		for p, t := range pathTallies {
+			weight, ok := changed[p]
+			if !ok || totals[p] == 0 {
+				continue
+			}
+
+			reviewer.AuthorName = or(reviewer.AuthorName, t.name)
+			reviewer.AuthorEmail = or(reviewer.AuthorEmail, t.email)
+
+			o := value(t) / totals[p] * float64(weight)
+			if o > 0 {
+				owned = append(owned, ownedPath{path: p, owned: o})
+			}
+		}
+
+		if len(owned) == 0 {
+			continue
+		}
+
+		slices.SortFunc(owned, func(a, b ownedPath) int {
+			return cmp.Or(
+				-cmp.Compare(a.owned, b.owned),
+				strings.Compare(a.path, b.path),
+			)
+		})
+
+		for _, o := range owned {
+			reviewer.Ownership += o.owned / float64(totalWeight)
+			reviewer.Files = append(reviewer.Files, o.path)
+		}
+
+		reviewers = append(reviewers, reviewer)
+	}
+
+	slices.SortFunc(reviewers, func(a, b Reviewer) int {
+		return cmp.Or(
+			-cmp.Compare(a.Ownership, b.Ownership),
+			strings.Compare(a.AuthorName, b.AuthorName),
+			strings.Compare(a.AuthorEmail, b.AuthorEmail),
+		)
+	})
+
+	return reviewers
+}
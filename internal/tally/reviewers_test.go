@@ -0,0 +1,151 @@
+package tally_test
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/tally"
+)
+
+func TestReviewers(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	halfLife := 365 * 24 * time.Hour
+
+	commit := func(
+		hash string,
+		name string,
+		date time.Time,
+		diffs ...git.FileDiff,
+	) git.Commit {
+		return git.Commit{
+			Hash:        hash,
+			ShortHash:   hash,
+			AuthorName:  name,
+			AuthorEmail: name + "@mail.com",
+			Date:        date,
+			FileDiffs:   diffs,
+		}
+	}
+
+	commits := []git.Commit{
+		commit(
+			"baa",
+			"bob",
+			now.Add(-2*halfLife),
+			git.FileDiff{Path: "foo.go", LinesAdded: 40},
+		),
+		commit(
+			"bab",
+			"jim",
+			now,
+			git.FileDiff{Path: "foo.go", LinesAdded: 10},
+			git.FileDiff{Path: "old.go", LinesAdded: 10},
+		),
+		commit(
+			"bac",
+			"sue",
+			now,
+			git.FileDiff{Path: "foo.go", LinesAdded: 10},
+		),
+		commit(
+			"bad",
+			"ann",
+			now,
+			git.FileDiff{Path: "other.go", LinesAdded: 100},
+		),
+	}
+
+	type reviewer struct {
+		Name      string
+		Ownership float64
+		Files     []string
+	}
+
+	tests := []struct {
+		name     string
+		halfLife time.Duration
+		exclude  map[string]bool
+		expected []reviewer
+	}{
+		{
+			name: "undecayed",
+			expected: []reviewer{
+				reviewer{"jim", 0.5 + 0.5/6, []string{"bar.go", "foo.go"}},
+				reviewer{"bob", 0.5 * 4 / 6, []string{"foo.go"}},
+				reviewer{"sue", 0.5 / 6, []string{"foo.go"}},
+			},
+		},
+		{
+			// Bob's lines are two half-lives old, so they count as 10 lines
+			name:     "decayed",
+			halfLife: halfLife,
+			expected: []reviewer{
+				reviewer{"jim", 0.5 + 0.5/3, []string{"bar.go", "foo.go"}},
+				reviewer{"bob", 0.5 / 3, []string{"foo.go"}},
+				reviewer{"sue", 0.5 / 3, []string{"foo.go"}},
+			},
+		},
+		{
+			name:    "excluded",
+			exclude: map[string]bool{"jim": true},
+			expected: []reviewer{
+				reviewer{"bob", 0.5 * 4 / 6, []string{"foo.go"}},
+				reviewer{"sue", 0.5 / 6, []string{"foo.go"}},
+			},
+		},
+	}
+
+	// Half the change is to foo.go, the other half to bar.go, which was
+	// renamed from old.go
+	changed := map[string]int{"foo.go": 10, "bar.go": 10}
+	renames := map[string]string{"old.go": "bar.go"}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := tally.TallyOpts{
+				Mode:      tally.LinesMode,
+				Key:       func(c git.Commit) string { return c.AuthorName },
+				HalfLife:  test.halfLife,
+				DecayFrom: now,
+			}
+
+			byPath, err := tally.TallyCommitsByPath(
+				slices.Values(commits),
+				opts,
+			)
+			if err != nil {
+				t.Fatalf("TallyCommitsByPath() returned error: %v", err)
+			}
+
+			reviewers := []reviewer{}
+			for _, r := range tally.Reviewers(
+				byPath,
+				changed,
+				renames,
+				test.exclude,
+				opts,
+			) {
+				reviewers = append(reviewers, reviewer{
+					Name:      r.AuthorName,
+					Ownership: math.Round(r.Ownership*1000) / 1000,
+					Files:     r.Files,
+				})
+			}
+
+			expected := []reviewer{}
+			for _, r := range test.expected {
+				r.Ownership = math.Round(r.Ownership*1000) / 1000
+				expected = append(expected, r)
+			}
+
+			if diff := cmp.Diff(expected, reviewers); diff != "" {
+				t.Errorf("reviewers are wrong:\n%s", diff)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package tally
 
 import (
 	"iter"
+	"math"
 	"slices"
 	"time"
 
@@ -19,19 +20,101 @@ const (
 	FilesMode
 	LastModifiedMode
 	FirstModifiedMode
+	BlameMode     // Lines surviving in the working tree, according to git blame
+	CompositeMode // Weighted sum of commits, lines, files, and recency
 )
 
+func (m TallyMode) String() string {
+	switch m {
+	case CommitMode:
+		return "commits"
+	case LinesMode:
+		return "lines"
+	case FilesMode:
+		return "files"
+	case LastModifiedMode:
+		return "last-modified"
+	case FirstModifiedMode:
+		return "first-modified"
+	case BlameMode:
+		return "blame"
+	case CompositeMode:
+		return "composite"
+	default:
+		panic("unrecognized mode in switch statement")
+	}
+}
+
 const NoDiffPathname = ".git-who-no-diff-commits"
 
 type TallyOpts struct {
 	Mode        TallyMode
 	Key         func(c git.Commit) string // Unique ID for author
 	CountMerges bool
+	CoAuthors   CoAuthorCredit
+	Committer   bool // Tally by committer and commit date instead of author
+
+	// If nonzero, weight each contribution by its age as of DecayFrom, halving
+	// its weight every HalfLife
+	HalfLife  time.Duration
+	DecayFrom time.Time
+
+	Weights Weights // Used in CompositeMode; DefaultWeights if zero
 }
 
 // Whether we need --stat and --summary data from git log for this tally mode
 func (opts TallyOpts) IsDiffMode() bool {
-	return opts.Mode == FilesMode || opts.Mode == LinesMode
+	return opts.Mode == FilesMode ||
+		opts.Mode == LinesMode ||
+		opts.Mode == CompositeMode
+}
+
+// Whether authors are ranked by FinalTally.Score
+func (opts TallyOpts) IsScored() bool {
+	return opts.IsDecayed() || opts.Mode == CompositeMode
+}
+
+// Yields the commits to tally, attributed to whoever should get credit.
+//
+// When tallying by committer, the committer identity and commit date replace
+// the author identity and author date, so everything downstream (including
+// opts.Key) treats the committer as the author.
+func attribute(
+	commits iter.Seq[git.Commit],
+	opts TallyOpts,
+) iter.Seq[git.Commit] {
+	if opts.Committer {
+		commits = asCommitter(commits)
+	}
+
+	return creditCoAuthors(commits, opts)
+}
+
+// Like git.ParseCommits() does for author dates, skips commits with a commit
+// date in the future.
+func asCommitter(commits iter.Seq[git.Commit]) iter.Seq[git.Commit] {
+	return func(yield func(git.Commit) bool) {
+		now := time.Now()
+
+		for commit := range commits {
+			if commit.CommitDate.After(now) {
+				logger().Debug(
+					"skipping commit with commit date in the future",
+					"commit",
+					commit.Name(),
+				)
+				continue
+			}
+
+			commit.AuthorName = commit.CommitterName
+			commit.AuthorEmail = commit.CommitterEmail
+			commit.Date = commit.CommitDate
+
+			if !yield(commit) {
+				return
+			}
+		}
+	}
 }
 
 // Metrics tallied for a single author while walking git log.
@@ -41,15 +124,23 @@ func (opts TallyOpts) IsDiffMode() bool {
 type FinalTally struct {
 	AuthorName      string
 	AuthorEmail     string
-	Commits         int // Num commits editing paths in tree by this author
-	LinesAdded      int // Num lines added to paths in tree by author
-	LinesRemoved    int // Num lines deleted from paths in tree by author
-	FileCount       int // Num of file paths in working dir touched by author
+	Commits         int     // Num commits editing paths in tree by this author
+	LinesAdded      int     // Num lines added to paths in tree by author
+	LinesRemoved    int     // Num lines deleted from paths in tree by author
+	FileCount       int     // Num of file paths in working dir touched by author
+	SurvivingLines  int     // Num lines in working dir last changed by author
+	Score           float64 // Weighted by age if decayed, or composite score
+	Decayed         bool    // Whether Score is weighted by age
 	FirstCommitTime time.Time
 	LastCommitTime  time.Time
 }
 
 func (t FinalTally) SortKey(mode TallyMode) int64 {
+	if t.Decayed {
+		// Scores are never negative, so their bits sort in the same order
+		return int64(math.Float64bits(t.Score))
+	}
+
 	switch mode {
 	case CommitMode:
 		return int64(t.Commits)
@@ -61,6 +152,10 @@ func (t FinalTally) SortKey(mode TallyMode) int64 {
 		return -t.FirstCommitTime.Unix()
 	case LastModifiedMode:
 		return t.LastCommitTime.Unix()
+	case BlameMode:
+		return int64(t.SurvivingLines)
+	case CompositeMode:
+		return int64(math.Float64bits(t.Score))
 	default:
 		panic("unrecognized mode in switch statement")
 	}
@@ -87,7 +182,9 @@ type Tally struct {
 	commitset       map[string]bool
 	added           int
 	removed         int
+	surviving       int
 	fileset         map[string]bool
+	decayed         map[string]float64 // Weighted contributions, if decaying
 	firstCommitTime time.Time
 	lastCommitTime  time.Time
 	// Can be used to count Tally objs when we don't need to disambiguate
@@ -125,7 +222,9 @@ func (a Tally) Combine(b Tally) Tally {
 		commitset:       unionInPlace(a.commitset, b.commitset),
 		added:           a.added + b.added,
 		removed:         a.removed + b.removed,
+		surviving:       a.surviving + b.surviving,
 		fileset:         unionInPlace(a.fileset, b.fileset),
+		decayed:         mergeDecayedInPlace(a.decayed, b.decayed),
 		firstCommitTime: timeutils.Min(a.firstCommitTime, b.firstCommitTime),
 		lastCommitTime:  timeutils.Max(a.lastCommitTime, b.lastCommitTime),
 		numTallied:      a.numTallied + b.numTallied,
@@ -154,6 +253,9 @@ func (t Tally) Final() FinalTally {
 		LinesAdded:      t.added,
 		LinesRemoved:    t.removed,
 		FileCount:       files,
+		SurvivingLines:  t.surviving,
+		Score:           sumDecayed(t.decayed),
+		Decayed:         t.decayed != nil,
 		FirstCommitTime: t.firstCommitTime,
 		LastCommitTime:  t.lastCommitTime,
 	}
@@ -220,7 +322,7 @@ func TallyCommits(
 		tallies = map[string]Tally{}
 
 		// Don't need info about file paths, just count commits and commit time
-		for commit := range commits {
+		for commit := range attribute(commits, opts) {
 			if commit.IsMerge && !opts.CountMerges {
 				continue
 			}
@@ -235,6 +337,7 @@ func TallyCommits(
 			}
 
 			tally.numTallied += 1
+			tally = tally.decay(opts, commit, "", 0)
 			tally.firstCommitTime = timeutils.Min(
 				commit.Date,
 				tally.firstCommitTime,
@@ -269,7 +372,7 @@ func TallyCommitsByPath(
 	tallies := TalliesByPath{}
 
 	// Tally over commits
-	for commit := range commits {
+	for commit := range attribute(commits, opts) {
 		if commit.IsMerge && !opts.CountMerges {
 			continue
 		}
@@ -299,6 +402,9 @@ func TallyCommitsByPath(
 			}
 
 			tally.commitset[commit.ShortHash] = true
+			if !opts.IsDiffMode() {
+				tally = tally.decay(opts, commit, NoDiffPathname, 0)
+			}
 			tally.firstCommitTime = timeutils.Min(
 				tally.firstCommitTime,
 				commit.Date,
@@ -336,6 +442,15 @@ func TallyCommitsByPath(
 					tally.removed += diff.LinesRemoved
 				}
 
+				if !commit.IsMerge || !opts.IsDiffMode() {
+					tally = tally.decay(
+						opts,
+						commit,
+						diff.Path,
+						diff.LinesAdded+diff.LinesRemoved,
+					)
+				}
+
 				pathTallies[diff.Path] = tally
 			}
 		}
@@ -346,15 +461,35 @@ func TallyCommitsByPath(
 	return tallies, nil
 }
 
+// Combines every tally into a single tally for all authors.
+//
+// Commits and files are only counted once even if several authors share them.
+func Total(tallies map[string]Tally) FinalTally {
+	var total Tally
+	total.commitset = map[string]bool{}
+	total.fileset = map[string]bool{}
+	total.firstCommitTime = time.Unix(1<<62, 0)
+
+	for _, t := range tallies {
+		total = total.Combine(t)
+	}
+
+	return total.Final()
+}
+
 // Sort tallies according to mode.
-func Rank(tallies map[string]Tally, mode TallyMode) []FinalTally {
+func Rank(tallies map[string]Tally, opts TallyOpts) []FinalTally {
 	final := []FinalTally{}
 	for _, t := range tallies {
 		final = append(final, t.Final())
 	}
 
+	if opts.Mode == CompositeMode {
+		scoreComposite(final, opts.Weights)
+	}
+
 	slices.SortFunc(final, func(a, b FinalTally) int {
-		return -a.Compare(b, mode)
+		return -a.Compare(b, opts.Mode)
 	})
 	return final
 }
@@ -3,6 +3,7 @@ package tally_test
 import (
 	"slices"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 
@@ -58,7 +59,7 @@ func TestTallyCommits(t *testing.T) {
 		},
 	}
 	tallies, err := tally.TallyCommits(seq, opts)
-	rankedTallies := tally.Rank(tallies, opts.Mode)
+	rankedTallies := tally.Rank(tallies, opts)
 	if err != nil {
 		t.Fatalf("TallyCommits() returned error: %v", err)
 	}
@@ -93,3 +94,98 @@ func TestTallyCommits(t *testing.T) {
 		t.Errorf("jim's tally is wrong:\n%s", diff)
 	}
 }
+
+func TestTallyCommitsCommitter(t *testing.T) {
+	commits := []git.Commit{
+		git.Commit{
+			Hash:           "baa",
+			ShortHash:      "baa",
+			AuthorName:     "bob",
+			AuthorEmail:    "bob@mail.com",
+			Date:           time.Unix(1000, 0),
+			CommitterName:  "jim",
+			CommitterEmail: "jim@mail.com",
+			CommitDate:     time.Unix(2000, 0),
+		},
+		git.Commit{
+			Hash:           "bab",
+			ShortHash:      "bab",
+			AuthorName:     "sue",
+			AuthorEmail:    "sue@mail.com",
+			Date:           time.Unix(3000, 0),
+			CommitterName:  "jim",
+			CommitterEmail: "jim@mail.com",
+			CommitDate:     time.Unix(4000, 0),
+		},
+	}
+
+	seq := slices.Values(commits)
+	opts := tally.TallyOpts{
+		Mode: tally.CommitMode,
+		Key: func(c git.Commit) string {
+			return c.AuthorEmail
+		},
+		Committer: true,
+	}
+	tallies, err := tally.TallyCommits(seq, opts)
+	if err != nil {
+		t.Fatalf("TallyCommits() returned error: %v", err)
+	}
+
+	rankedTallies := tally.Rank(tallies, opts)
+	if len(rankedTallies) != 1 {
+		t.Fatalf("expected 1 tally but got %d", len(rankedTallies))
+	}
+
+	expected := tally.FinalTally{
+		AuthorName:      "jim",
+		AuthorEmail:     "jim@mail.com",
+		Commits:         2,
+		FileCount:       2,
+		FirstCommitTime: time.Unix(2000, 0),
+		LastCommitTime:  time.Unix(4000, 0),
+	}
+	if diff := cmp.Diff(expected, rankedTallies[0]); diff != "" {
+		t.Errorf("jim's tally is wrong:\n%s", diff)
+	}
+}
+
+func TestTallyCommitsCommitterFutureDate(t *testing.T) {
+	commits := []git.Commit{
+		git.Commit{
+			Hash:           "baa",
+			ShortHash:      "baa",
+			AuthorName:     "bob",
+			AuthorEmail:    "bob@mail.com",
+			Date:           time.Unix(1000, 0),
+			CommitterName:  "jim",
+			CommitterEmail: "jim@mail.com",
+			CommitDate:     time.Now().Add(24 * time.Hour),
+		},
+	}
+
+	opts := tally.TallyOpts{
+		Mode: tally.CommitMode,
+		Key: func(c git.Commit) string {
+			return c.AuthorEmail
+		},
+	}
+	tallies, err := tally.TallyCommits(slices.Values(commits), opts)
+	if err != nil {
+		t.Fatalf("TallyCommits() returned error: %v", err)
+	}
+
+	if len(tallies) != 1 {
+		t.Errorf("expected commit to count for author but got %d tallies", len(tallies))
+	}
+
+	opts.Committer = true
+	tallies, err = tally.TallyCommits(slices.Values(commits), opts)
+	if err != nil {
+		t.Fatalf("TallyCommits() returned error: %v", err)
+	}
+
+	if len(tallies) != 0 {
+		t.Errorf("expected commit to be skipped but got %d tallies", len(tallies))
+	}
+}
@@ -17,7 +17,8 @@ var EmptyTreeErr = errors.New("No commits; tree is empty.")
 
 // A file tree of edits to the repo
 type TreeNode struct {
-	Tally      FinalTally
+	Tally      FinalTally   // Winning author's tally
+	Ranked     []FinalTally // Every author's tally, best first
 	Children   map[string]*TreeNode
 	InWorkTree bool // In git working tree/directory
 	tallies    map[string]Tally
@@ -64,12 +65,12 @@ func (t *TreeNode) insert(path string, key string, tally Tally, inWTree bool) {
 	child.insert(nextP, key, tally, inWTree)
 }
 
-func (t *TreeNode) Rank(mode TallyMode) *TreeNode {
+func (t *TreeNode) Rank(opts TallyOpts) *TreeNode {
 	if len(t.Children) > 0 {
 		// Recursively sum up metrics.
 		// For each author, merge the tallies for all children together.
 		for p, child := range t.Children {
-			t.Children[p] = child.Rank(mode)
+			t.Children[p] = child.Rank(opts)
 
 			for key, childTally := range child.tallies {
 				tally, ok := t.tallies[key]
@@ -87,23 +88,30 @@ func (t *TreeNode) Rank(mode TallyMode) *TreeNode {
 	}
 
 	// Pick best tally for the node according to the tally mode
-	sorted := Rank(t.tallies, mode)
-	t.Tally = sorted[0]
+	t.Ranked = Rank(t.tallies, opts)
+	t.Tally = t.Ranked[0]
 	return t
 }
 
 /*
 * TallyCommitsTree() returns a tree of nodes mirroring the working directory
 * with a tally for each node.
+*
+* Edits made to a file before it was renamed are credited to the path the file
+* ended up at (see FollowRenames()).
  */
 func TallyCommitsTree(
 	commits iter.Seq[git.Commit],
 	opts TallyOpts,
 	worktreePaths map[string]bool,
 	gitRootPath string,
+	renames git.RenameHistory,
 ) (*TreeNode, error) {
 	// Tally paths
-	talliesByPath, err := TallyCommitsByPath(commits, opts)
+	talliesByPath, err := TallyCommitsByPath(
+		FollowRenames(commits, renames),
+		opts,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -150,3 +158,64 @@ func TallyCommitsTreeFromPaths(
 
 	return root, nil
 }
+
+// Credits the edits each commit made to a file to the path that file ended up
+// at after any later renames, so that the file's history stays with it.
+//
+// If a new file was created where a renamed file used to be, edits made after
+// the rename stay with the new file.
+func FollowRenames(
+	commits iter.Seq[git.Commit],
+	renames git.RenameHistory,
+) iter.Seq[git.Commit] {
+	if renames.Len() == 0 {
+		return commits
+	}
+
+	return func(yield func(git.Commit) bool) {
+		for commit := range commits {
+			// Copy, since someone else (like the cache) might be holding on to
+			// the commit's diffs
+			diffs := make([]git.FileDiff, len(commit.FileDiffs))
+			for i, diff := range commit.FileDiffs {
+				diff.Path = renames.Follow(commit, diff.Path)
+				diffs[i] = diff
+			}
+			commit.FileDiffs = diffs
+
+			if !yield(commit) {
+				return
+			}
+		}
+	}
+}
+
+// Moves the tallies for each renamed path onto the path the file was renamed
+// to, combining them with any tallies already there.
+func followRenames(
+	talliesByPath TalliesByPath,
+	renames map[string]string,
+) TalliesByPath {
+	followed := TalliesByPath{}
+
+	for key, pathTallies := range talliesByPath {
+		followedPathTallies := map[string]Tally{}
+
+		for p, tally := range pathTallies {
+			if to, ok := renames[p]; ok {
+				p = to
+			}
+
+			if existing, ok := followedPathTallies[p]; ok {
+				tally = existing.Combine(tally)
+				tally.numTallied = min(tally.numTallied, 1) // Same path
+			}
+
+			followedPathTallies[p] = tally
+		}
+
+		followed[key] = followedPathTallies
+	}
+
+	return followed
+}
@@ -3,6 +3,7 @@ package tally_test
 import (
 	"slices"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 
@@ -65,12 +66,18 @@ func TestTallyCommitsTree(t *testing.T) {
 		Key:  func(c git.Commit) string { return c.AuthorEmail },
 	}
 
-	root, err := tally.TallyCommitsTree(seq, opts, worktreeset, "")
+	root, err := tally.TallyCommitsTree(
+		seq,
+		opts,
+		worktreeset,
+		"",
+		git.RenameHistory{},
+	)
 	if err != nil {
 		t.Fatalf("TallyCommits() returned error: %v", err)
 	}
 
-	root = root.Rank(opts.Mode)
+	root = root.Rank(opts)
 
 	if len(root.Children) == 0 {
 		t.Fatalf("root node has no children")
@@ -114,6 +121,29 @@ func TestTallyCommitsTree(t *testing.T) {
 	if diff := cmp.Diff(expected, bimNode.Tally); diff != "" {
 		t.Errorf("bob's second tally is wrong:\n%s", diff)
 	}
+
+	if len(bimNode.Ranked) != 2 {
+		t.Fatalf(
+			"expected 2 ranked tallies for bim.txt but got %d",
+			len(bimNode.Ranked),
+		)
+	}
+
+	if diff := cmp.Diff(bimNode.Tally, bimNode.Ranked[0]); diff != "" {
+		t.Errorf("first ranked tally should be the winner:\n%s", diff)
+	}
+
+	expected = tally.FinalTally{
+		AuthorName:   "jim",
+		AuthorEmail:  "jim@mail.com",
+		Commits:      1,
+		LinesAdded:   3,
+		LinesRemoved: 1,
+		FileCount:    1,
+	}
+	if diff := cmp.Diff(expected, bimNode.Ranked[1]); diff != "" {
+		t.Errorf("jim's tally is wrong:\n%s", diff)
+	}
 }
 
 func TestTallyCommitsTreeNoCommits(t *testing.T) {
@@ -124,7 +154,13 @@ func TestTallyCommitsTreeNoCommits(t *testing.T) {
 	}
 	worktreeset := map[string]bool{}
 
-	_, err := tally.TallyCommitsTree(seq, opts, worktreeset, "")
+	_, err := tally.TallyCommitsTree(
+		seq,
+		opts,
+		worktreeset,
+		"",
+		git.RenameHistory{},
+	)
 	if err != tally.EmptyTreeErr {
 		t.Fatalf(
 			"TallyCommits() should have returned EmptyTreeErr but returned %v",
@@ -132,3 +168,194 @@ func TestTallyCommitsTreeNoCommits(t *testing.T) {
 		)
 	}
 }
+
+func TestTallyCommitsTreeFollowRenames(t *testing.T) {
+	commits := []git.Commit{
+		git.Commit{
+			Hash:        "baa",
+			CommitDate:  time.Unix(1000, 0),
+			ShortHash:   "baa",
+			AuthorName:  "bob",
+			AuthorEmail: "bob@mail.com",
+			FileDiffs: []git.FileDiff{
+				git.FileDiff{
+					Path:         "lib/bim.txt",
+					LinesAdded:   4,
+					LinesRemoved: 0,
+				},
+			},
+		},
+		git.Commit{
+			Hash:        "bab",
+			CommitDate:  time.Unix(2000, 0),
+			ShortHash:   "bab",
+			AuthorName:  "jim",
+			AuthorEmail: "jim@mail.com",
+			FileDiffs: []git.FileDiff{
+				git.FileDiff{
+					Path:     "src/bim.txt",
+					PrevPath: "lib/bim.txt",
+				},
+			},
+		},
+		git.Commit{
+			Hash:        "bac",
+			CommitDate:  time.Unix(3000, 0),
+			ShortHash:   "bac",
+			AuthorName:  "bob",
+			AuthorEmail: "bob@mail.com",
+			FileDiffs: []git.FileDiff{
+				git.FileDiff{
+					Path:         "src/bim.txt",
+					LinesAdded:   2,
+					LinesRemoved: 1,
+				},
+			},
+		},
+	}
+
+	worktreeset := map[string]bool{"src/bim.txt": true}
+	seq := slices.Values(commits)
+	opts := tally.TallyOpts{
+		Mode: tally.CommitMode,
+		Key:  func(c git.Commit) string { return c.AuthorEmail },
+	}
+	renames := git.NewRenameHistory(slices.Values(commits), nil)
+
+	root, err := tally.TallyCommitsTree(seq, opts, worktreeset, "", renames)
+	if err != nil {
+		t.Fatalf("TallyCommits() returned error: %v", err)
+	}
+
+	root = root.Rank(opts)
+
+	if _, ok := root.Children["lib"]; ok {
+		t.Errorf("root node should have no \"lib\" child")
+	}
+
+	srcNode, ok := root.Children["src"]
+	if !ok {
+		t.Fatalf("root node has no \"src\" child")
+	}
+
+	bimNode, ok := srcNode.Children["bim.txt"]
+	if !ok {
+		t.Fatalf("\"src\" node has no \"bim.txt\" child")
+	}
+
+	expected := tally.FinalTally{
+		AuthorName:   "bob",
+		AuthorEmail:  "bob@mail.com",
+		Commits:      2,
+		LinesAdded:   4 + 2,
+		LinesRemoved: 1,
+		FileCount:    1,
+	}
+	if diff := cmp.Diff(expected, bimNode.Tally); diff != "" {
+		t.Errorf("bob's tally is wrong:\n%s", diff)
+	}
+}
+
+// A new file created where a renamed file used to be keeps its own history
+func TestTallyCommitsTreeFollowRenamesRecreated(t *testing.T) {
+	commits := []git.Commit{
+		git.Commit{
+			Hash:        "baa",
+			CommitDate:  time.Unix(1000, 0),
+			ShortHash:   "baa",
+			AuthorName:  "bob",
+			AuthorEmail: "bob@mail.com",
+			FileDiffs: []git.FileDiff{
+				git.FileDiff{Path: "lib/bim.txt", LinesAdded: 4},
+			},
+		},
+		git.Commit{
+			Hash:        "bab",
+			CommitDate:  time.Unix(2000, 0),
+			ShortHash:   "bab",
+			AuthorName:  "jim",
+			AuthorEmail: "jim@mail.com",
+			FileDiffs: []git.FileDiff{
+				git.FileDiff{Path: "src/bim.txt", PrevPath: "lib/bim.txt"},
+			},
+		},
+		git.Commit{
+			Hash:        "bac",
+			CommitDate:  time.Unix(3000, 0),
+			ShortHash:   "bac",
+			AuthorName:  "ann",
+			AuthorEmail: "ann@mail.com",
+			FileDiffs: []git.FileDiff{
+				git.FileDiff{Path: "lib/bim.txt", LinesAdded: 7},
+			},
+		},
+		git.Commit{
+			Hash:        "bad",
+			CommitDate:  time.Unix(4000, 0),
+			ShortHash:   "bad",
+			AuthorName:  "ann",
+			AuthorEmail: "ann@mail.com",
+			FileDiffs: []git.FileDiff{
+				git.FileDiff{Path: "lib/bim.txt", LinesAdded: 1},
+			},
+		},
+	}
+
+	worktreeset := map[string]bool{"src/bim.txt": true, "lib/bim.txt": true}
+	seq := slices.Values(commits)
+	opts := tally.TallyOpts{
+		Mode: tally.CommitMode,
+		Key:  func(c git.Commit) string { return c.AuthorEmail },
+	}
+	renames := git.NewRenameHistory(slices.Values(commits), nil)
+
+	root, err := tally.TallyCommitsTree(seq, opts, worktreeset, "", renames)
+	if err != nil {
+		t.Fatalf("TallyCommits() returned error: %v", err)
+	}
+
+	root = root.Rank(opts)
+
+	libNode, ok := root.Children["lib"]
+	if !ok {
+		t.Fatalf("root node has no \"lib\" child")
+	}
+
+	bimNode, ok := libNode.Children["bim.txt"]
+	if !ok {
+		t.Fatalf("\"lib\" node has no \"bim.txt\" child")
+	}
+
+	if bimNode.Tally.AuthorEmail != "ann@mail.com" {
+		t.Errorf(
+			"expected ann to have most commits to lib/bim.txt, but got %s",
+			bimNode.Tally.AuthorEmail,
+		)
+	}
+
+	if slices.ContainsFunc(bimNode.Ranked, func(t tally.FinalTally) bool {
+		return t.AuthorEmail == "bob@mail.com"
+	}) {
+		t.Errorf("expected bob's work to move away from lib/bim.txt")
+	}
+
+	// The original file's history followed it to its new path
+	movedNode, ok := root.Children["src"].Children["bim.txt"]
+	if !ok {
+		t.Fatalf("\"src\" node has no \"bim.txt\" child")
+	}
+
+	i := slices.IndexFunc(movedNode.Ranked, func(t tally.FinalTally) bool {
+		return t.AuthorEmail == "bob@mail.com"
+	})
+	if i < 0 {
+		t.Fatalf("expected bob's work to show up on src/bim.txt")
+	}
+
+	if movedNode.Ranked[i].LinesAdded != 4 {
+		t.Errorf(
+			"expected bob to have added 4 lines to src/bim.txt, but got %d",
+			movedNode.Ranked[i].LinesAdded,
+		)
+	}
+}
@@ -0,0 +1,30 @@
+// Advisory locks on files, so that separate git-who processes don't trip over
+// each other when writing to the same files.
+//
+// A file can have either one exclusive lock or any number of shared locks. The
+// locks belong to the open file, not to the process, so opening the same file
+// twice and locking it twice conflicts even within one process.
+package fileutils
+
+import "os"
+
+// Blocks until we hold an exclusive lock on the file.
+func Lock(f *os.File) error {
+	return lock(f)
+}
+
+// Blocks until we hold a shared lock on the file.
+func LockShared(f *os.File) error {
+	return lockShared(f)
+}
+
+// Takes an exclusive lock on the file if nobody else holds a lock on it.
+// Returns whether we got the lock.
+func TryLock(f *os.File) (bool, error) {
+	return tryLock(f)
+}
+
+// Releases a lock taken with Lock(), LockShared(), or TryLock().
+func Unlock(f *os.File) error {
+	return unlock(f)
+}
@@ -0,0 +1,51 @@
+//go:build windows
+
+package fileutils
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// Locking the first byte is enough, since everyone locks the same byte
+func lockFileEx(f *os.File, flags uint32) error {
+	return windows.LockFileEx(
+		windows.Handle(f.Fd()),
+		flags,
+		0,
+		1,
+		0,
+		&windows.Overlapped{},
+	)
+}
+
+func lock(f *os.File) error {
+	return lockFileEx(f, windows.LOCKFILE_EXCLUSIVE_LOCK)
+}
+
+func lockShared(f *os.File) error {
+	return lockFileEx(f, 0)
+}
+
+func tryLock(f *os.File) (bool, error) {
+	err := lockFileEx(
+		f,
+		windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY,
+	)
+	if err == windows.ERROR_LOCK_VIOLATION {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func unlock(f *os.File) error {
+	return windows.UnlockFileEx(
+		windows.Handle(f.Fd()),
+		0,
+		1,
+		0,
+		&windows.Overlapped{},
+	)
+}
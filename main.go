@@ -4,11 +4,16 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"strings"
+	"time"
+
+	"golang.org/x/term"
 
 	"github.com/sinclairtarget/git-who/internal/git"
+	"github.com/sinclairtarget/git-who/internal/git/config"
 	"github.com/sinclairtarget/git-who/internal/subcommands"
 	"github.com/sinclairtarget/git-who/internal/tally"
 	"github.com/sinclairtarget/git-who/internal/utils/flagutils"
@@ -21,6 +26,7 @@ type command struct {
 	flagSet     *flag.FlagSet
 	run         func(args []string) error
 	description string
+	subcommands map[string]command // e.g. "check" in "git who codeowners check"
 }
 
 // Main examines the args and delegates to the specified subcommand.
@@ -33,6 +39,16 @@ func main() {
 		"table": tableCmd(),
 		"tree":  treeCmd(),
 		"hist":  histCmd(),
+		"bus":   busCmd(),
+		"stale": staleCmd(),
+		"churn": churnCmd(),
+
+		"author":     authorCmd(),
+		"diff":       diffCmd(),
+		"reviewers":  reviewersCmd(),
+		"graph":      graphCmd(),
+		"codeowners": codeownersCmd(),
+		"cache":      cacheCmd(),
 	}
 
 	// --- Handle top-level flags ---
@@ -52,7 +68,20 @@ func main() {
 		fmt.Println()
 		fmt.Println("Subcommands:")
 
-		helpSubcommands := []string{"table", "tree", "hist"}
+		helpSubcommands := []string{
+			"table",
+			"tree",
+			"hist",
+			"bus",
+			"stale",
+			"churn",
+			"author",
+			"diff",
+			"reviewers",
+			"graph",
+			"codeowners",
+			"cache",
+		}
 		for _, name := range helpSubcommands {
 			cmd := subcommands[name]
 
@@ -104,6 +133,13 @@ loop:
 		}
 	}
 
+	if len(args) > 0 {
+		if subcommand, ok := cmd.subcommands[args[0]]; ok {
+			cmd = subcommand
+			args = args[1:]
+		}
+	}
+
 	args = escapeTerminator(args)
 
 	cmd.flagSet.Parse(args)
@@ -121,15 +157,44 @@ loop:
 func tableCmd() command {
 	flagSet := flag.NewFlagSet("git-who table", flag.ExitOnError)
 
-	useCsv := flagSet.Bool("csv", false, "Output as csv")
 	showEmail := flagSet.Bool("e", false, "Show email address of each author")
 	countMerges := flagSet.Bool("merges", false, "Count merge commits toward commit total")
+	coAuthors := flagSet.String("coauthors", "", strings.TrimSpace(`
+Also credit authors named in Co-authored-by trailers. Either "full" or "split"
+	`))
+	committer := flagSet.Bool(
+		"committer",
+		false,
+		"Tally by committer and commit date instead of author",
+	)
+	halfLife := flagSet.Int("half-life", 0, strings.TrimSpace(`
+Weight each commit by its age, halving its weight every this many days
+	`))
 	linesMode := flagSet.Bool("l", false, "Sort by lines added + removed")
 	filesMode := flagSet.Bool("f", false, "Sort by files changed")
 	firstModifiedMode := flagSet.Bool("c", false, "Sort by first modified (created)")
 	lastModifiedMode := flagSet.Bool("m", false, "Sort by last modified")
+	blameMode := flagSet.Bool(
+		"b",
+		false,
+		"Sort by lines surviving in the working tree (uses git blame)",
+	)
+	compositeMode := flagSet.Bool(
+		"w",
+		false,
+		"Sort by a weighted score combining commits, lines, files, and recency",
+	)
+	weightsSpec := flagSet.String("weights", "", strings.TrimSpace(`
+Weights for -w, e.g. "commits=2,lines=1,files=1,recency=1"
+	`))
 	limit := flagSet.Int("n", 10, "Limit rows in table (set to 0 for no limit)")
+	showShares := flagSet.Bool(
+		"p",
+		false,
+		"Show each author's percentage share of commits and lines, with totals",
+	)
 
+	outputFlags := addOutputFlags(flagSet)
 	filterFlags := addFilterFlags(flagSet)
 
 	description := "Print out a table showing total contributions by author"
@@ -154,6 +219,8 @@ Usage: git-who table [options...] [revisions...] [[--] paths...]
 				*filesMode,
 				*lastModifiedMode,
 				*firstModifiedMode,
+				*blameMode,
+				*compositeMode,
 			) {
 				return errors.New("all sort flags are mutually exclusive")
 			}
@@ -166,12 +233,60 @@ Usage: git-who table [options...] [revisions...] [[--] paths...]
 				mode = tally.LastModifiedMode
 			} else if *firstModifiedMode {
 				mode = tally.FirstModifiedMode
+			} else if *blameMode {
+				mode = tally.BlameMode
+			} else if *compositeMode {
+				mode = tally.CompositeMode
 			}
 
 			if *limit < 0 {
 				return errors.New("-n flag must be a positive integer")
 			}
 
+			outputFormat, err := outputFlags.format()
+			if err != nil {
+				return err
+			}
+
+			if *showShares &&
+				(outputFormat == subcommands.JsonOutput ||
+					outputFormat == subcommands.NdjsonOutput) {
+				return errors.New("-p cannot be used with --json or --ndjson")
+			}
+
+			coAuthorCredit, err := parseCoAuthorCredit(*coAuthors)
+			if err != nil {
+				return err
+			}
+
+			if *committer && coAuthorCredit != tally.NoCoAuthorCredit {
+				return errors.New(
+					"--committer and --coauthors are mutually exclusive",
+				)
+			}
+
+			halfLifeDuration, err := parseHalfLife(*halfLife, mode)
+			if err != nil {
+				return err
+			}
+
+			weights, err := compositeWeights(*weightsSpec, mode)
+			if err != nil {
+				return err
+			}
+
+			if mode == tally.BlameMode {
+				err = checkBlameOpts(
+					filterFlags,
+					*countMerges,
+					coAuthorCredit,
+					*committer,
+				)
+				if err != nil {
+					return err
+				}
+			}
+
 			revs, pathspecs, err := git.ParseArgs(args)
 			if err != nil {
 				return err
@@ -186,9 +301,14 @@ Usage: git-who table [options...] [revisions...] [[--] paths...]
 				revs,
 				pathspecs,
 				mode,
-				*useCsv,
+				outputFormat,
 				*showEmail,
+				*showShares,
 				*countMerges,
+				coAuthorCredit,
+				*committer,
+				halfLifeDuration,
+				weights,
 				*limit,
 				*filterFlags.since,
 				*filterFlags.until,
@@ -205,6 +325,17 @@ func treeCmd() command {
 	showEmail := flagSet.Bool("e", false, "Show email address of each author")
 	showHidden := flagSet.Bool("a", false, "Show files not in working tree (also annotates all files)")
 	countMerges := flagSet.Bool("merges", false, "Count merge commits toward commit total")
+	coAuthors := flagSet.String("coauthors", "", strings.TrimSpace(`
+Also credit authors named in Co-authored-by trailers. Either "full" or "split"
+	`))
+	committer := flagSet.Bool(
+		"committer",
+		false,
+		"Tally by committer and commit date instead of author",
+	)
+	halfLife := flagSet.Int("half-life", 0, strings.TrimSpace(`
+Weight each commit by its age, halving its weight every this many days
+	`))
 	useLines := flagSet.Bool("l", false, "Rank authors by lines added/changed")
 	useFiles := flagSet.Bool("f", false, "Rank authors by files touched")
 	useFirstModified := flagSet.Bool("c", false, "Rank authors by first commit time (created)")
@@ -213,8 +344,32 @@ func treeCmd() command {
 		false,
 		"Rank authors by last commit time",
 	)
+	useBlame := flagSet.Bool(
+		"b",
+		false,
+		"Rank authors by lines surviving in the working tree (uses git blame)",
+	)
+	useComposite := flagSet.Bool(
+		"w",
+		false,
+		"Rank authors by a weighted score combining commits, lines, files, and recency",
+	)
+	weightsSpec := flagSet.String("weights", "", strings.TrimSpace(`
+Weights for -w, e.g. "commits=2,lines=1,files=1,recency=1"
+	`))
 	depth := flagSet.Int("d", 0, "Limit on tree depth")
+	numAuthors := flagSet.Int(
+		"k",
+		1,
+		"Show this many top authors for each node, with their shares",
+	)
+	followRenames := flagSet.Bool(
+		"follow-renames",
+		false,
+		"Credit commits made before a file was renamed to its current path",
+	)
 
+	outputFlags := addOutputFlags(flagSet)
 	filterFlags := addFilterFlags(flagSet)
 
 	description := "Print out a file tree showing most contributions by path"
@@ -247,6 +402,8 @@ Usage: git-who tree [options...] [revisions...] [[--] paths...]
 				*useFiles,
 				*useLastModified,
 				*useFirstModified,
+				*useBlame,
+				*useComposite,
 			) {
 				return errors.New("all ranking flags are mutually exclusive")
 			}
@@ -260,6 +417,65 @@ Usage: git-who tree [options...] [revisions...] [[--] paths...]
 				mode = tally.LastModifiedMode
 			} else if *useFirstModified {
 				mode = tally.FirstModifiedMode
+			} else if *useBlame {
+				mode = tally.BlameMode
+			} else if *useComposite {
+				mode = tally.CompositeMode
+			}
+
+			outputFormat, err := outputFlags.format()
+			if err != nil {
+				return err
+			}
+
+			coAuthorCredit, err := parseCoAuthorCredit(*coAuthors)
+			if err != nil {
+				return err
+			}
+
+			if *committer && coAuthorCredit != tally.NoCoAuthorCredit {
+				return errors.New(
+					"--committer and --coauthors are mutually exclusive",
+				)
+			}
+
+			halfLifeDuration, err := parseHalfLife(*halfLife, mode)
+			if err != nil {
+				return err
+			}
+
+			weights, err := compositeWeights(*weightsSpec, mode)
+			if err != nil {
+				return err
+			}
+
+			if mode == tally.BlameMode {
+				err = checkBlameOpts(
+					filterFlags,
+					*countMerges,
+					coAuthorCredit,
+					*committer,
+				)
+				if err != nil {
+					return err
+				}
+
+				if *followRenames {
+					return errors.New("--follow-renames cannot be used with -b")
+				}
+			}
+
+			if *numAuthors < 1 {
+				return errors.New("-k flag must be a positive integer")
+			}
+
+			if *numAuthors > 1 {
+				switch mode {
+				case tally.LastModifiedMode, tally.FirstModifiedMode:
+					return errors.New("-k cannot be used with -m or -c")
+				case tally.CompositeMode:
+					return errors.New("-k cannot be used with -w")
+				}
 			}
 
 			return subcommands.Tree(
@@ -267,9 +483,16 @@ Usage: git-who tree [options...] [revisions...] [[--] paths...]
 				pathspecs,
 				mode,
 				*depth,
+				*numAuthors,
+				outputFormat,
 				*showEmail,
 				*showHidden,
 				*countMerges,
+				coAuthorCredit,
+				*committer,
+				halfLifeDuration,
+				weights,
+				*followRenames,
 				*filterFlags.since,
 				*filterFlags.until,
 				filterFlags.authors,
@@ -284,9 +507,34 @@ func histCmd() command {
 
 	useLines := flagSet.Bool("l", false, "Rank authors by lines added/changed")
 	useFiles := flagSet.Bool("f", false, "Rank authors by files touched")
+	useComposite := flagSet.Bool(
+		"w",
+		false,
+		"Rank authors by a weighted score combining commits, lines, files, and recency",
+	)
+	weightsSpec := flagSet.String("weights", "", strings.TrimSpace(`
+Weights for -w, e.g. "commits=2,lines=1,files=1,recency=1"
+	`))
 	showEmail := flagSet.Bool("e", false, "Show email address of each author")
+	showAllAuthors := flagSet.Bool(
+		"a",
+		false,
+		"Include every author's tally in each bucket (csv and JSON output only)",
+	)
 	countMerges := flagSet.Bool("merges", false, "Count merge commits toward commit total")
+	coAuthors := flagSet.String("coauthors", "", strings.TrimSpace(`
+Also credit authors named in Co-authored-by trailers. Either "full" or "split"
+	`))
+	committer := flagSet.Bool(
+		"committer",
+		false,
+		"Tally by committer and commit date instead of author",
+	)
+	halfLife := flagSet.Int("half-life", 0, strings.TrimSpace(`
+Weight each commit by its age, halving its weight every this many days
+	`))
 
+	outputFlags := addOutputFlags(flagSet)
 	filterFlags := addFilterFlags(flagSet)
 
 	description := "Print out a timeline showing most contributions by date"
@@ -314,7 +562,7 @@ Usage: git-who hist [options...] [revisions...] [[--] paths...]
 				return err
 			}
 
-			if !isOnlyOne(*useLines, *useFiles) {
+			if !isOnlyOne(*useLines, *useFiles, *useComposite) {
 				return errors.New("all ranking flags are mutually exclusive")
 			}
 
@@ -323,14 +571,54 @@ Usage: git-who hist [options...] [revisions...] [[--] paths...]
 				mode = tally.LinesMode
 			} else if *useFiles {
 				mode = tally.FilesMode
+			} else if *useComposite {
+				mode = tally.CompositeMode
+			}
+
+			outputFormat, err := outputFlags.format()
+			if err != nil {
+				return err
+			}
+
+			if *showAllAuthors && outputFormat == subcommands.TerminalOutput {
+				return errors.New(
+					"-a can only be used with --csv, --json, or --ndjson",
+				)
+			}
+
+			coAuthorCredit, err := parseCoAuthorCredit(*coAuthors)
+			if err != nil {
+				return err
+			}
+
+			if *committer && coAuthorCredit != tally.NoCoAuthorCredit {
+				return errors.New(
+					"--committer and --coauthors are mutually exclusive",
+				)
+			}
+
+			halfLifeDuration, err := parseHalfLife(*halfLife, mode)
+			if err != nil {
+				return err
+			}
+
+			weights, err := compositeWeights(*weightsSpec, mode)
+			if err != nil {
+				return err
 			}
 
 			return subcommands.Hist(
 				revs,
 				pathspecs,
 				mode,
+				outputFormat,
 				*showEmail,
+				*showAllAuthors,
 				*countMerges,
+				coAuthorCredit,
+				*committer,
+				halfLifeDuration,
+				weights,
 				*filterFlags.since,
 				*filterFlags.until,
 				filterFlags.authors,
@@ -340,15 +628,38 @@ Usage: git-who hist [options...] [revisions...] [[--] paths...]
 	}
 }
 
-func dumpCmd() command {
-	flagSet := flag.NewFlagSet("git-who dump", flag.ExitOnError)
+func busCmd() command {
+	flagSet := flag.NewFlagSet("git-who bus", flag.ExitOnError)
 
-	short := flagSet.Bool("s", false, "Use short log")
+	useLines := flagSet.Bool("l", false, "Measure contributions by lines added/changed")
+	useFiles := flagSet.Bool("f", false, "Measure contributions by files touched")
+	showEmail := flagSet.Bool("e", false, "Show email address of each author")
+	countMerges := flagSet.Bool("merges", false, "Count merge commits toward commit total")
+	share := flagSet.Float64("share", 0.5, strings.TrimSpace(`
+Fraction (0 to 1) of a directory's contributions the authors must account for
+	`))
+	depth := flagSet.Int("d", 0, "Limit on directory depth")
+	limit := flagSet.Int("n", 10, "Limit rows in table (set to 0 for no limit)")
+	activeSince := flagSet.String("active-since", "", strings.TrimSpace(`
+Only count authors who have committed since this date
+	`))
 
 	filterFlags := addFilterFlags(flagSet)
 
+	description := "Print out the directories that depend on the fewest authors"
+
+	flagSet.Usage = func() {
+		fmt.Println(strings.TrimSpace(`
+Usage: git-who bus [options...] [revisions...] [[--] paths...]
+		`))
+		fmt.Println(description)
+		fmt.Println()
+		flagSet.PrintDefaults()
+	}
+
 	return command{
-		flagSet: flagSet,
+		flagSet:     flagSet,
+		description: description,
 		run: func(args []string) error {
 			revs, pathspecs, err := git.ParseArgs(args)
 			if err != nil {
@@ -360,10 +671,35 @@ func dumpCmd() command {
 				return err
 			}
 
-			return subcommands.Dump(
+			if !isOnlyOne(*useLines, *useFiles) {
+				return errors.New("all ranking flags are mutually exclusive")
+			}
+
+			mode := tally.CommitMode
+			if *useLines {
+				mode = tally.LinesMode
+			} else if *useFiles {
+				mode = tally.FilesMode
+			}
+
+			if *share <= 0 || *share > 1 {
+				return errors.New("--share must be greater than 0 and at most 1")
+			}
+
+			if *limit < 0 {
+				return errors.New("-n flag must be a positive integer")
+			}
+
+			return subcommands.Bus(
 				revs,
 				pathspecs,
-				*short,
+				mode,
+				*share,
+				*depth,
+				*limit,
+				*activeSince,
+				*showEmail,
+				*countMerges,
 				*filterFlags.since,
 				*filterFlags.until,
 				filterFlags.authors,
@@ -373,15 +709,36 @@ func dumpCmd() command {
 	}
 }
 
-func parseCmd() command {
-	flagSet := flag.NewFlagSet("git-who parse", flag.ExitOnError)
+func staleCmd() command {
+	flagSet := flag.NewFlagSet("git-who stale", flag.ExitOnError)
 
-	short := flagSet.Bool("s", false, "Use short log")
+	untouchedSince := flagSet.String("untouched-since", "1 year ago", strings.TrimSpace(`
+List paths with no commits since this date (set to "" to skip this check)
+	`))
+	activeSince := flagSet.String("active-since", "1 year ago", strings.TrimSpace(`
+List paths none of whose authors have committed since this date (set to "" to
+skip this check)
+	`))
+	sortBySize := flagSet.Bool("s", false, "Sort by lines in the working tree instead of by age")
+	showEmail := flagSet.Bool("e", false, "Show email address of each author")
+	limit := flagSet.Int("n", 10, "Limit rows in table (set to 0 for no limit)")
 
 	filterFlags := addFilterFlags(flagSet)
 
+	description := "Print out the files and directories nobody works on anymore"
+
+	flagSet.Usage = func() {
+		fmt.Println(strings.TrimSpace(`
+Usage: git-who stale [options...] [revisions...] [[--] paths...]
+		`))
+		fmt.Println(description)
+		fmt.Println()
+		flagSet.PrintDefaults()
+	}
+
 	return command{
-		flagSet: flagSet,
+		flagSet:     flagSet,
+		description: description,
 		run: func(args []string) error {
 			revs, pathspecs, err := git.ParseArgs(args)
 			if err != nil {
@@ -393,10 +750,24 @@ func parseCmd() command {
 				return err
 			}
 
-			return subcommands.Parse(
+			if *untouchedSince == "" && *activeSince == "" {
+				return errors.New(
+					"--untouched-since and --active-since cannot both be empty",
+				)
+			}
+
+			if *limit < 0 {
+				return errors.New("-n flag must be a positive integer")
+			}
+
+			return subcommands.Stale(
 				revs,
 				pathspecs,
-				*short,
+				*untouchedSince,
+				*activeSince,
+				*sortBySize,
+				*limit,
+				*showEmail,
 				*filterFlags.since,
 				*filterFlags.until,
 				filterFlags.authors,
@@ -406,33 +777,1010 @@ func parseCmd() command {
 	}
 }
 
-// -^---------------------------------------------------------------------------
+func churnCmd() command {
+	flagSet := flag.NewFlagSet("git-who churn", flag.ExitOnError)
 
-func configureLogging(level slog.Level) {
-	handler := slog.NewTextHandler(
-		os.Stderr,
-		&slog.HandlerOptions{
-			Level: level,
-		},
-	)
-	logger := slog.New(handler)
-	slog.SetDefault(logger)
-}
+	useLines := flagSet.Bool("l", false, "Rank by lines added/changed instead of by commits")
+	useDirs := flagSet.Bool("dirs", false, "Rank directories instead of files")
+	countMerges := flagSet.Bool("merges", false, "Count merge commits toward commit total")
+	limit := flagSet.Int("n", 10, "Limit rows in table (set to 0 for no limit)")
 
-// Used to check mutual exclusion.
-func isOnlyOne(flags ...bool) bool {
-	var foundOne bool
-	for _, f := range flags {
-		if f {
-			if foundOne {
-				return false
+	outputFlags := addOutputFlags(flagSet)
+	filterFlags := addFilterFlags(flagSet)
+
+	description := "Print out the files that change the most, by the most authors"
+
+	flagSet.Usage = func() {
+		fmt.Println(strings.TrimSpace(`
+Usage: git-who churn [options...] [revisions...] [[--] paths...]
+		`))
+		fmt.Println(description)
+		fmt.Println()
+		flagSet.PrintDefaults()
+	}
+
+	return command{
+		flagSet:     flagSet,
+		description: description,
+		run: func(args []string) error {
+			revs, pathspecs, err := git.ParseArgs(args)
+			if err != nil {
+				return fmt.Errorf("could not parse args: %w", err)
 			}
 
-			foundOne = true
-		}
+			err = checkPathspecs(pathspecs)
+			if err != nil {
+				return err
+			}
+
+			outputFormat, err := outputFlags.format()
+			if err != nil {
+				return err
+			}
+
+			if *limit < 0 {
+				return errors.New("-n flag must be a positive integer")
+			}
+
+			return subcommands.Churn(
+				revs,
+				pathspecs,
+				*useLines,
+				*useDirs,
+				outputFormat,
+				*limit,
+				*countMerges,
+				*filterFlags.since,
+				*filterFlags.until,
+				filterFlags.authors,
+				filterFlags.nauthors,
+			)
+		},
 	}
+}
 
-	return true
+func authorCmd() command {
+	flagSet := flag.NewFlagSet("git-who author", flag.ExitOnError)
+
+	useLines := flagSet.Bool("l", false, "Measure contributions by lines added/changed")
+	useFiles := flagSet.Bool("f", false, "Measure contributions by files touched")
+	countMerges := flagSet.Bool("merges", false, "Count merge commits toward commit total")
+	limit := flagSet.Int("n", 10, "Limit rows in each table (set to 0 for no limit)")
+
+	filterFlags := addFilterFlags(flagSet)
+
+	description := "Print out a profile of a single author's contributions"
+
+	flagSet.Usage = func() {
+		fmt.Println(strings.TrimSpace(`
+Usage: git-who author [options...] <name|email> [revisions...] [[--] paths...]
+		`))
+		fmt.Println(description)
+		fmt.Println()
+		flagSet.PrintDefaults()
+	}
+
+	return command{
+		flagSet:     flagSet,
+		description: description,
+		run: func(args []string) error {
+			if len(args) == 0 || args[0] == "--" {
+				return errors.New("expected an author name or email")
+			}
+
+			who := args[0]
+			revs, pathspecs, err := git.ParseArgs(args[1:])
+			if err != nil {
+				return fmt.Errorf("could not parse args: %w", err)
+			}
+
+			err = checkPathspecs(pathspecs)
+			if err != nil {
+				return err
+			}
+
+			if !isOnlyOne(*useLines, *useFiles) {
+				return errors.New("all ranking flags are mutually exclusive")
+			}
+
+			mode := tally.CommitMode
+			if *useLines {
+				mode = tally.LinesMode
+			} else if *useFiles {
+				mode = tally.FilesMode
+			}
+
+			if *limit < 0 {
+				return errors.New("-n flag must be a positive integer")
+			}
+
+			return subcommands.Author(
+				who,
+				revs,
+				pathspecs,
+				mode,
+				*limit,
+				*countMerges,
+				*filterFlags.since,
+				*filterFlags.until,
+				filterFlags.authors,
+				filterFlags.nauthors,
+			)
+		},
+	}
+}
+
+func diffCmd() command {
+	flagSet := flag.NewFlagSet("git-who diff", flag.ExitOnError)
+
+	useLines := flagSet.Bool("l", false, "Rank authors by lines added/changed")
+	showEmail := flagSet.Bool("e", false, "Show email address of each author")
+	countMerges := flagSet.Bool("merges", false, "Count merge commits toward commit total")
+	depth := flagSet.Int("d", 1, "Depth of directories to compare")
+	limit := flagSet.Int("n", 10, "Limit rows in each table (set to 0 for no limit)")
+
+	outputFlags := addOutputFlags(flagSet)
+	filterFlags := addFilterFlags(flagSet)
+
+	description := "Print out how contributions changed between two revision ranges"
+
+	flagSet.Usage = func() {
+		fmt.Println(strings.TrimSpace(`
+Usage: git-who diff [options...] <range> <range> [[--] paths...]
+		`))
+		fmt.Println(description)
+		fmt.Println()
+		flagSet.PrintDefaults()
+	}
+
+	return command{
+		flagSet:     flagSet,
+		description: description,
+		run: func(args []string) error {
+			if len(args) < 2 || args[0] == "--" || args[1] == "--" {
+				return errors.New("expected two revision ranges")
+			}
+
+			revsBefore, err := parseRange(args[0])
+			if err != nil {
+				return err
+			}
+
+			revsAfter, err := parseRange(args[1])
+			if err != nil {
+				return err
+			}
+
+			revs, pathspecs, err := git.ParseArgs(args[2:])
+			if err != nil {
+				return fmt.Errorf("could not parse args: %w", err)
+			}
+
+			if len(revs) != 1 || revs[0] != "HEAD" {
+				return errors.New("expected two revision ranges")
+			}
+
+			err = checkPathspecs(pathspecs)
+			if err != nil {
+				return err
+			}
+
+			mode := tally.CommitMode
+			if *useLines {
+				mode = tally.LinesMode
+			}
+
+			outputFormat, err := outputFlags.format()
+			if err != nil {
+				return err
+			}
+
+			if *depth < 1 {
+				return errors.New("-d flag must be a positive integer")
+			}
+
+			if *limit < 0 {
+				return errors.New("-n flag must be a positive integer")
+			}
+
+			return subcommands.Diff(
+				args[0],
+				args[1],
+				revsBefore,
+				revsAfter,
+				pathspecs,
+				mode,
+				outputFormat,
+				*depth,
+				*limit,
+				*showEmail,
+				*countMerges,
+				*filterFlags.since,
+				*filterFlags.until,
+				filterFlags.authors,
+				filterFlags.nauthors,
+			)
+		},
+	}
+}
+
+func reviewersCmd() command {
+	flagSet := flag.NewFlagSet("git-who reviewers", flag.ExitOnError)
+
+	useFiles := flagSet.Bool("f", false, "Weigh each changed file equally instead of by lines changed")
+	showEmail := flagSet.Bool("e", false, "Show email address of each author")
+	countMerges := flagSet.Bool("merges", false, "Count merge commits toward commit total")
+	halfLife := flagSet.Int("half-life", 180, strings.TrimSpace(`
+Weight past contributions by age, halving their weight every this many days
+(set to 0 to weight all contributions equally)
+	`))
+	limit := flagSet.Int("n", 5, "Limit number of suggestions (set to 0 for no limit)")
+
+	filterFlags := addFilterFlags(flagSet)
+
+	description := "Suggest reviewers for the changes in a revision range or patch"
+
+	flagSet.Usage = func() {
+		fmt.Println(strings.TrimSpace(`
+Usage: git-who reviewers [options...] <range>
+       git diff | git-who reviewers [options...]
+		`))
+		fmt.Println(description)
+		fmt.Println()
+		flagSet.PrintDefaults()
+	}
+
+	return command{
+		flagSet:     flagSet,
+		description: description,
+		run: func(args []string) error {
+			if len(args) > 1 {
+				return errors.New("expected a single revision range")
+			}
+
+			var revs []string
+			var patch io.Reader
+			if len(args) == 1 {
+				var err error
+				revs, err = parseRange(args[0])
+				if err != nil {
+					return err
+				}
+			} else if !term.IsTerminal(int(os.Stdin.Fd())) {
+				patch = os.Stdin
+			} else {
+				return errors.New("expected a revision range or a patch on stdin")
+			}
+
+			mode := tally.LinesMode
+			if *useFiles {
+				mode = tally.FilesMode
+			}
+
+			halfLifeDuration, err := parseHalfLife(*halfLife, mode)
+			if err != nil {
+				return err
+			}
+
+			if *limit < 0 {
+				return errors.New("-n flag must be a positive integer")
+			}
+
+			return subcommands.Reviewers(
+				revs,
+				patch,
+				mode,
+				halfLifeDuration,
+				*limit,
+				*showEmail,
+				*countMerges,
+				*filterFlags.since,
+				*filterFlags.until,
+				filterFlags.authors,
+				filterFlags.nauthors,
+			)
+		},
+	}
+}
+
+func graphCmd() command {
+	flagSet := flag.NewFlagSet("git-who graph", flag.ExitOnError)
+
+	showEmail := flagSet.Bool("e", false, "Tell authors apart by email instead of by name")
+	countMerges := flagSet.Bool("merges", false, "Count merge commits toward commit total")
+	minShared := flagSet.Int("min-shared", 1, strings.TrimSpace(`
+Only connect authors who edited at least this many of the same files
+	`))
+	useDot := flagSet.Bool("dot", false, "Output as Graphviz DOT (the default)")
+	useGraphml := flagSet.Bool("graphml", false, "Output as GraphML")
+	useJson := flagSet.Bool("json", false, "Output as JSON")
+
+	filterFlags := addFilterFlags(flagSet)
+
+	description := "Print out a graph of which authors have edited the same files"
+
+	flagSet.Usage = func() {
+		fmt.Println(strings.TrimSpace(`
+Usage: git-who graph [options...] [revisions...] [[--] paths...]
+		`))
+		fmt.Println(description)
+		fmt.Println()
+		flagSet.PrintDefaults()
+	}
+
+	return command{
+		flagSet:     flagSet,
+		description: description,
+		run: func(args []string) error {
+			revs, pathspecs, err := git.ParseArgs(args)
+			if err != nil {
+				return fmt.Errorf("could not parse args: %w", err)
+			}
+
+			err = checkPathspecs(pathspecs)
+			if err != nil {
+				return err
+			}
+
+			if !isOnlyOne(*useDot, *useGraphml, *useJson) {
+				return errors.New("all output format flags are mutually exclusive")
+			}
+
+			outputFormat := subcommands.DotOutput
+			if *useGraphml {
+				outputFormat = subcommands.GraphmlOutput
+			} else if *useJson {
+				outputFormat = subcommands.JsonOutput
+			}
+
+			if *minShared < 1 {
+				return errors.New("--min-shared must be a positive integer")
+			}
+
+			return subcommands.Graph(
+				revs,
+				pathspecs,
+				outputFormat,
+				*minShared,
+				*showEmail,
+				*countMerges,
+				*filterFlags.since,
+				*filterFlags.until,
+				filterFlags.authors,
+				filterFlags.nauthors,
+			)
+		},
+	}
+}
+
+func codeownersCmd() command {
+	flagSet := flag.NewFlagSet("git-who codeowners", flag.ExitOnError)
+
+	useLines := flagSet.Bool("l", false, "Rank authors by lines added/changed")
+	useFiles := flagSet.Bool("f", false, "Rank authors by files touched")
+	countMerges := flagSet.Bool("merges", false, "Count merge commits toward commit total")
+	depth := flagSet.Int("d", 0, "Limit on directory depth")
+	maxOwners := flagSet.Int("n", 2, "Maximum number of owners per directory")
+	minShare := flagSet.Float64("min-share", 0.1, strings.TrimSpace(`
+Minimum fraction (0 to 1) of a directory's contributions an owner must have
+	`))
+	activeSince := flagSet.String("active-since", "", strings.TrimSpace(`
+Only make owners of authors who committed to the directory after this date
+	`))
+	handlesPath := flagSet.String("handles", "", strings.TrimSpace(`
+File mapping authors to handles, one "<author> = <handle>" per line
+	`))
+	outputPath := flagSet.String("o", "", "Write to this file instead of stdout")
+
+	filterFlags := addFilterFlags(flagSet)
+
+	description := "Print out a CODEOWNERS file naming the top authors by directory"
+
+	flagSet.Usage = func() {
+		fmt.Println(strings.TrimSpace(`
+Usage: git-who codeowners [options...] [revisions...] [[--] paths...]
+       git-who codeowners check [options...] [revisions...] [[--] paths...]
+		`))
+		fmt.Println(description)
+		fmt.Println()
+		flagSet.PrintDefaults()
+		fmt.Println()
+		fmt.Println("Run git-who codeowners check -h for help checking an existing file")
+	}
+
+	return command{
+		flagSet:     flagSet,
+		description: description,
+		subcommands: map[string]command{
+			"check": codeownersCheckCmd(),
+		},
+		run: func(args []string) error {
+			revs, pathspecs, err := git.ParseArgs(args)
+			if err != nil {
+				return fmt.Errorf("could not parse args: %w", err)
+			}
+
+			err = checkPathspecs(pathspecs)
+			if err != nil {
+				return err
+			}
+
+			if !isOnlyOne(*useLines, *useFiles) {
+				return errors.New("all ranking flags are mutually exclusive")
+			}
+
+			mode := tally.CommitMode
+			if *useLines {
+				mode = tally.LinesMode
+			} else if *useFiles {
+				mode = tally.FilesMode
+			}
+
+			if *maxOwners < 1 {
+				return errors.New("-n flag must be a positive integer")
+			}
+
+			if *minShare < 0 || *minShare > 1 {
+				return errors.New("--min-share must be between 0 and 1")
+			}
+
+			return subcommands.Codeowners(
+				revs,
+				pathspecs,
+				mode,
+				*depth,
+				*maxOwners,
+				*minShare,
+				*activeSince,
+				*handlesPath,
+				*outputPath,
+				*countMerges,
+				*filterFlags.since,
+				*filterFlags.until,
+				filterFlags.authors,
+				filterFlags.nauthors,
+			)
+		},
+	}
+}
+
+func codeownersCheckCmd() command {
+	flagSet := flag.NewFlagSet("git-who codeowners check", flag.ExitOnError)
+
+	useLines := flagSet.Bool("l", false, "Rank authors by lines added/changed")
+	countMerges := flagSet.Bool("merges", false, "Count merge commits toward commit total")
+	codeownersPath := flagSet.String("file", "", strings.TrimSpace(`
+CODEOWNERS file to check. Found in the usual places if not given
+	`))
+	activeSince := flagSet.String("active-since", "1 year ago", strings.TrimSpace(`
+Report owners who haven't committed to their paths since this date
+	`))
+	handlesPath := flagSet.String("handles", "", strings.TrimSpace(`
+File mapping authors to handles, one "<author> = <handle>" per line
+	`))
+
+	filterFlags := addFilterFlags(flagSet)
+
+	description := "Check a CODEOWNERS file against who actually edits each path"
+
+	flagSet.Usage = func() {
+		fmt.Println(strings.TrimSpace(`
+Usage: git-who codeowners check [options...] [revisions...] [[--] paths...]
+		`))
+		fmt.Println(description)
+		fmt.Println("Exits with a non-zero status if any problems are found.")
+		fmt.Println()
+		flagSet.PrintDefaults()
+	}
+
+	return command{
+		flagSet:     flagSet,
+		description: description,
+		run: func(args []string) error {
+			revs, pathspecs, err := git.ParseArgs(args)
+			if err != nil {
+				return fmt.Errorf("could not parse args: %w", err)
+			}
+
+			err = checkPathspecs(pathspecs)
+			if err != nil {
+				return err
+			}
+
+			mode := tally.CommitMode
+			if *useLines {
+				mode = tally.LinesMode
+			}
+
+			return subcommands.CodeownersCheck(
+				revs,
+				pathspecs,
+				mode,
+				*codeownersPath,
+				*activeSince,
+				*handlesPath,
+				*countMerges,
+				*filterFlags.since,
+				*filterFlags.until,
+				filterFlags.authors,
+				filterFlags.nauthors,
+			)
+		},
+	}
+}
+
+func cacheCmd() command {
+	flagSet := flag.NewFlagSet("git-who cache", flag.ExitOnError)
+
+	description := "Inspect and maintain the cache of parsed commits"
+
+	flagSet.Usage = func() {
+		fmt.Println(strings.TrimSpace(`
+Usage: git-who cache [stats]
+       git-who cache clear [--all]
+       git-who cache warm [revisions...] [[--] paths...]
+       git-who cache prune
+       git-who cache verify
+       git-who cache export <file>
+       git-who cache import <file>
+		`))
+		fmt.Println(description)
+		fmt.Println()
+		fmt.Println("Actions:")
+		fmt.Println("  stats   Print the size and location of the cache (default)")
+		fmt.Println("  clear   Delete the cache for this repository")
+		fmt.Println("  warm    Parse and cache commits without tallying them")
+		fmt.Println("  prune   Remove commits no longer reachable from any ref")
+		fmt.Println("  verify  Read back every cached commit and report corruption")
+		fmt.Println("  export  Write the cache to an archive file")
+		fmt.Println("  import  Add the commits in an archive file to the cache")
+		fmt.Println()
+		fmt.Println("Run git-who cache <action> -h for help with an action")
+	}
+
+	return command{
+		flagSet:     flagSet,
+		description: description,
+		subcommands: map[string]command{
+			"stats":  cacheStatsCmd(),
+			"clear":  cacheClearCmd(),
+			"warm":   cacheWarmCmd(),
+			"prune":  cachePruneCmd(),
+			"verify": cacheVerifyCmd(),
+			"export": cacheExportCmd(),
+			"import": cacheImportCmd(),
+		},
+		run: func(args []string) error {
+			if len(args) > 0 {
+				return fmt.Errorf("unknown cache action: %s", args[0])
+			}
+
+			return subcommands.CacheStats()
+		},
+	}
+}
+
+func cacheStatsCmd() command {
+	flagSet := flag.NewFlagSet("git-who cache stats", flag.ExitOnError)
+
+	description := "Print the size and location of the cache for this repository"
+
+	flagSet.Usage = func() {
+		fmt.Println("Usage: git-who cache stats")
+		fmt.Println(description)
+	}
+
+	return command{
+		flagSet:     flagSet,
+		description: description,
+		run: func(args []string) error {
+			if len(args) > 0 {
+				return errors.New("cache stats takes no arguments")
+			}
+
+			return subcommands.CacheStats()
+		},
+	}
+}
+
+func cacheClearCmd() command {
+	flagSet := flag.NewFlagSet("git-who cache clear", flag.ExitOnError)
+
+	all := flagSet.Bool("all", false, "Delete the caches for every repository")
+
+	description := "Delete the cache for this repository"
+
+	flagSet.Usage = func() {
+		fmt.Println("Usage: git-who cache clear [--all]")
+		fmt.Println(description)
+		fmt.Println()
+		flagSet.PrintDefaults()
+	}
+
+	return command{
+		flagSet:     flagSet,
+		description: description,
+		run: func(args []string) error {
+			if len(args) > 0 {
+				return errors.New("cache clear takes no arguments")
+			}
+
+			return subcommands.CacheClear(*all)
+		},
+	}
+}
+
+func cacheWarmCmd() command {
+	flagSet := flag.NewFlagSet("git-who cache warm", flag.ExitOnError)
+
+	description := "Parse and cache commits without tallying them"
+
+	flagSet.Usage = func() {
+		fmt.Println(strings.TrimSpace(`
+Usage: git-who cache warm [revisions...] [[--] paths...]
+		`))
+		fmt.Println(description)
+	}
+
+	return command{
+		flagSet:     flagSet,
+		description: description,
+		run: func(args []string) error {
+			revs, pathspecs, err := git.ParseArgs(args)
+			if err != nil {
+				return fmt.Errorf("could not parse args: %w", err)
+			}
+
+			err = checkPathspecs(pathspecs)
+			if err != nil {
+				return err
+			}
+
+			return subcommands.CacheWarm(revs, pathspecs)
+		},
+	}
+}
+
+func cachePruneCmd() command {
+	flagSet := flag.NewFlagSet("git-who cache prune", flag.ExitOnError)
+
+	description := "Remove commits no longer reachable from any ref from the cache"
+
+	flagSet.Usage = func() {
+		fmt.Println("Usage: git-who cache prune")
+		fmt.Println(description)
+	}
+
+	return command{
+		flagSet:     flagSet,
+		description: description,
+		run: func(args []string) error {
+			if len(args) > 0 {
+				return errors.New("cache prune takes no arguments")
+			}
+
+			return subcommands.CachePrune()
+		},
+	}
+}
+
+func cacheVerifyCmd() command {
+	flagSet := flag.NewFlagSet("git-who cache verify", flag.ExitOnError)
+
+	description := "Read back every cached commit and report any that are corrupt"
+
+	flagSet.Usage = func() {
+		fmt.Println("Usage: git-who cache verify")
+		fmt.Println(description)
+		fmt.Println("Exits with a non-zero status if any corrupt commits are found.")
+	}
+
+	return command{
+		flagSet:     flagSet,
+		description: description,
+		run: func(args []string) error {
+			if len(args) > 0 {
+				return errors.New("cache verify takes no arguments")
+			}
+
+			return subcommands.CacheVerify()
+		},
+	}
+}
+
+func cacheExportCmd() command {
+	flagSet := flag.NewFlagSet("git-who cache export", flag.ExitOnError)
+
+	description := "Write the cache for this repository to an archive file"
+
+	flagSet.Usage = func() {
+		fmt.Println("Usage: git-who cache export <file>")
+		fmt.Println(description)
+		fmt.Println("Writes to stdout if <file> is \"-\".")
+	}
+
+	return command{
+		flagSet:     flagSet,
+		description: description,
+		run: func(args []string) error {
+			if len(args) != 1 {
+				return errors.New("expected a single file to export to")
+			}
+
+			return subcommands.CacheExport(args[0])
+		},
+	}
+}
+
+func cacheImportCmd() command {
+	flagSet := flag.NewFlagSet("git-who cache import", flag.ExitOnError)
+
+	description := "Add the commits in an archive file to the cache for this repository"
+
+	flagSet.Usage = func() {
+		fmt.Println("Usage: git-who cache import <file>")
+		fmt.Println(description)
+		fmt.Println("Reads from stdin if <file> is \"-\".")
+	}
+
+	return command{
+		flagSet:     flagSet,
+		description: description,
+		run: func(args []string) error {
+			if len(args) != 1 {
+				return errors.New("expected a single file to import from")
+			}
+
+			return subcommands.CacheImport(args[0])
+		},
+	}
+}
+
+func dumpCmd() command {
+	flagSet := flag.NewFlagSet("git-who dump", flag.ExitOnError)
+
+	short := flagSet.Bool("s", false, "Use short log")
+
+	filterFlags := addFilterFlags(flagSet)
+
+	return command{
+		flagSet: flagSet,
+		run: func(args []string) error {
+			revs, pathspecs, err := git.ParseArgs(args)
+			if err != nil {
+				return fmt.Errorf("could not parse args: %w", err)
+			}
+
+			err = checkPathspecs(pathspecs)
+			if err != nil {
+				return err
+			}
+
+			// We match authors only after applying the mailmap, which dump
+			// doesn't do
+			if len(filterFlags.authors) > 0 || len(filterFlags.nauthors) > 0 {
+				return errors.New(
+					"--author and --nauthor cannot be used with dump",
+				)
+			}
+
+			return subcommands.Dump(
+				revs,
+				pathspecs,
+				*short,
+				*filterFlags.since,
+				*filterFlags.until,
+			)
+		},
+	}
+}
+
+func parseCmd() command {
+	flagSet := flag.NewFlagSet("git-who parse", flag.ExitOnError)
+
+	short := flagSet.Bool("s", false, "Use short log")
+
+	filterFlags := addFilterFlags(flagSet)
+
+	return command{
+		flagSet: flagSet,
+		run: func(args []string) error {
+			revs, pathspecs, err := git.ParseArgs(args)
+			if err != nil {
+				return fmt.Errorf("could not parse args: %w", err)
+			}
+
+			err = checkPathspecs(pathspecs)
+			if err != nil {
+				return err
+			}
+
+			return subcommands.Parse(
+				revs,
+				pathspecs,
+				*short,
+				*filterFlags.since,
+				*filterFlags.until,
+				filterFlags.authors,
+				filterFlags.nauthors,
+			)
+		},
+	}
+}
+
+// -^---------------------------------------------------------------------------
+
+func configureLogging(level slog.Level) {
+	handler := slog.NewTextHandler(
+		os.Stderr,
+		&slog.HandlerOptions{
+			Level: level,
+		},
+	)
+	logger := slog.New(handler)
+	slog.SetDefault(logger)
+}
+
+// Parses the value of the --coauthors flag.
+func parseCoAuthorCredit(s string) (tally.CoAuthorCredit, error) {
+	switch s {
+	case "":
+		return tally.NoCoAuthorCredit, nil
+	case "full":
+		return tally.FullCoAuthorCredit, nil
+	case "split":
+		return tally.SplitCoAuthorCredit, nil
+	default:
+		return tally.NoCoAuthorCredit, fmt.Errorf(
+			"--coauthors must be \"full\" or \"split\" but got: %s",
+			s,
+		)
+	}
+}
+
+// Blame mode looks only at the lines in the working tree, so options that
+// filter or attribute commits don't apply.
+func checkBlameOpts(
+	filters *filterFlags,
+	countMerges bool,
+	coAuthors tally.CoAuthorCredit,
+	committer bool,
+) error {
+	if *filters.since != "" || *filters.until != "" {
+		return errors.New("--since and --until cannot be used with -b")
+	}
+
+	if len(filters.authors) > 0 || len(filters.nauthors) > 0 {
+		return errors.New("--author and --nauthor cannot be used with -b")
+	}
+
+	if countMerges {
+		return errors.New("--merges cannot be used with -b")
+	}
+
+	if coAuthors != tally.NoCoAuthorCredit {
+		return errors.New("--coauthors cannot be used with -b")
+	}
+
+	if committer {
+		return errors.New("--committer cannot be used with -b")
+	}
+
+	return nil
+}
+
+// Resolves a single revision range given on the command line, e.g.
+// "v1.0..v2.0", into revisions.
+func parseRange(arg string) ([]string, error) {
+	revs, pathspecs, err := git.ParseArgs([]string{arg})
+	if err != nil {
+		return nil, fmt.Errorf("could not parse args: %w", err)
+	}
+
+	if len(pathspecs) > 0 {
+		return nil, fmt.Errorf("not a revision range: %s", arg)
+	}
+
+	return revs, nil
+}
+
+// Parses the value of the --half-life flag, given in days.
+func parseHalfLife(days int, mode tally.TallyMode) (time.Duration, error) {
+	if days < 0 {
+		return 0, errors.New("--half-life must be a positive number of days")
+	}
+
+	if days > 0 {
+		switch mode {
+		case tally.FirstModifiedMode, tally.LastModifiedMode:
+			return 0, errors.New("--half-life cannot be used with -c or -m")
+		case tally.BlameMode:
+			return 0, errors.New("--half-life cannot be used with -b")
+		case tally.CompositeMode:
+			return 0, errors.New("--half-life cannot be used with -w")
+		}
+	}
+
+	return time.Duration(days) * 24 * time.Hour, nil
+}
+
+// Returns the weights for composite mode, given either by the --weights flag
+// or, failing that, by the git-who.weights setting in the git config.
+func compositeWeights(
+	spec string,
+	mode tally.TallyMode,
+) (tally.Weights, error) {
+	if mode != tally.CompositeMode {
+		if spec != "" {
+			return tally.Weights{}, errors.New("--weights can only be used with -w")
+		}
+
+		return tally.Weights{}, nil
+	}
+
+	if spec == "" {
+		var err error
+		spec, err = config.CompositeWeights()
+		if err != nil {
+			return tally.Weights{}, err
+		}
+	}
+
+	if spec == "" {
+		return tally.DefaultWeights, nil
+	}
+
+	return tally.ParseWeights(spec)
+}
+
+// Used to check mutual exclusion.
+func isOnlyOne(flags ...bool) bool {
+	var foundOne bool
+	for _, f := range flags {
+		if f {
+			if foundOne {
+				return false
+			}
+
+			foundOne = true
+		}
+	}
+
+	return true
+}
+
+type outputFlags struct {
+	csv    *bool
+	json   *bool
+	ndjson *bool
+}
+
+func addOutputFlags(set *flag.FlagSet) *outputFlags {
+	return &outputFlags{
+		csv:  set.Bool("csv", false, "Output as csv"),
+		json: set.Bool("json", false, "Output as JSON"),
+		ndjson: set.Bool("ndjson", false, strings.TrimSpace(`
+Output as newline-delimited JSON. The first line describes the run
+		`)),
+	}
+}
+
+func (flags *outputFlags) format() (subcommands.OutputFormat, error) {
+	if !isOnlyOne(*flags.csv, *flags.json, *flags.ndjson) {
+		return subcommands.TerminalOutput, errors.New(
+			"all output format flags are mutually exclusive",
+		)
+	}
+
+	if *flags.csv {
+		return subcommands.CsvOutput, nil
+	} else if *flags.json {
+		return subcommands.JsonOutput, nil
+	} else if *flags.ndjson {
+		return subcommands.NdjsonOutput, nil
+	}
+
+	return subcommands.TerminalOutput, nil
 }
 
 type filterFlags struct {
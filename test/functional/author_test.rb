@@ -0,0 +1,46 @@
+require 'minitest/autorun'
+
+require 'lib/cmd'
+require 'lib/repo'
+
+class TestAuthor < Minitest::Test
+  def test_author
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'author', 'Bob'
+    refute_empty(stdout_s)
+  end
+
+  def test_author_by_email
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'author', 'sinclairtarget@gmail.com'
+    refute_empty(stdout_s)
+  end
+
+  def test_author_all_flags
+    flagsets = [
+      ['', '-l', '-f'],
+      ['', '-n 1'],
+      ['', '--merges'],
+    ]
+
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    GitWho.generate_args_cartesian_product(flagsets).each do |flags|
+      stdout_s = cmd.run 'author', *flags, 'Bob'
+      refute_empty(stdout_s, "author #{flags.join(' ')} Bob printed nothing")
+    end
+  end
+
+  def test_author_unknown
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    assert_raises(GitWhoError) do
+      cmd.run 'author', 'Nobody'
+    end
+  end
+
+  def test_author_missing
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    assert_raises(GitWhoError) do
+      cmd.run 'author'
+    end
+  end
+end
@@ -0,0 +1,27 @@
+require 'minitest/autorun'
+
+require 'lib/cmd'
+require 'lib/repo'
+
+class TestBus < Minitest::Test
+  def test_bus
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'bus'
+    refute_empty(stdout_s)
+  end
+
+  def test_bus_all_flags
+    flagsets = [
+      ['', '-l', '-f'],
+      ['', '--share=0.9'],
+      ['', '-d 1'],
+      ['', '--active-since=2000-01-01'],
+    ]
+
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    GitWho.generate_args_cartesian_product(flagsets).each do |flags|
+      stdout_s = cmd.run 'bus', *flags
+      refute_empty(stdout_s, "bus #{flags.join(' ')} printed nothing")
+    end
+  end
+end
@@ -0,0 +1,122 @@
+require 'json'
+require 'minitest/autorun'
+require 'tmpdir'
+
+require 'lib/cmd'
+require 'lib/repo'
+
+class TestCache < Minitest::Test
+  def test_cache_stats
+    Dir.mktmpdir do |cache_home|
+      cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+      stdout_s = cmd.run 'cache', cache_home: cache_home
+      assert_match(/Commits:\s+0/, stdout_s)
+
+      stdout_s = cmd.run 'cache', 'stats', cache_home: cache_home
+      assert_match(/Commits:\s+0/, stdout_s)
+    end
+  end
+
+  def test_cache_warm
+    Dir.mktmpdir do |cache_home|
+      cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+      cmd.run 'cache', 'warm', cache_home: cache_home
+
+      stdout_s = cmd.run 'cache', 'stats', cache_home: cache_home
+      refute_match(/Commits:\s+0\n/, stdout_s)
+
+      # Tallying from a warmed cache gives the same result
+      cached = cmd.run 'table', '-l', cache_home: cache_home
+      uncached = cmd.run 'table', '-l'
+      assert_equal uncached, cached
+    end
+  end
+
+  def test_cache_prune_verify
+    Dir.mktmpdir do |cache_home|
+      cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+      cmd.run 'cache', 'warm', cache_home: cache_home
+
+      stdout_s = cmd.run 'cache', 'prune', cache_home: cache_home
+      assert_match(/Pruned 0 /, stdout_s)
+
+      stdout_s = cmd.run 'cache', 'verify', cache_home: cache_home
+      assert_match(/Checked \d+ commits/, stdout_s)
+    end
+  end
+
+  def test_cache_clear
+    Dir.mktmpdir do |cache_home|
+      cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+      cmd.run 'cache', 'warm', cache_home: cache_home
+      cmd.run 'cache', 'clear', cache_home: cache_home
+
+      stdout_s = cmd.run 'cache', 'stats', cache_home: cache_home
+      assert_match(/Commits:\s+0/, stdout_s)
+
+      cmd.run 'cache', 'clear', '--all', cache_home: cache_home
+    end
+  end
+
+  def test_cache_export_import
+    Dir.mktmpdir do |dir|
+      cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+      archive = File.join(dir, 'cache.gwc')
+
+      first_home = File.join(dir, 'first')
+      cmd.run 'cache', 'warm', cache_home: first_home
+      cmd.run 'cache', 'export', archive, cache_home: first_home
+      exported = cmd.run 'cache', 'stats', cache_home: first_home
+
+      second_home = File.join(dir, 'second')
+      cmd.run 'cache', 'import', archive, cache_home: second_home
+      imported = cmd.run 'cache', 'stats', cache_home: second_home
+
+      commits = exported[/Commits:.*/]
+      refute_nil(commits)
+      assert_equal commits, imported[/Commits:.*/]
+    end
+  end
+
+  def test_cache_import_bad_archive
+    Dir.mktmpdir do |dir|
+      cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+      archive = File.join(dir, 'bad.gwc')
+      File.write(archive, 'not an archive')
+
+      assert_raises(GitWhoError) do
+        cmd.run 'cache', 'import', archive, cache_home: dir
+      end
+    end
+  end
+
+  def test_cache_manifest
+    Dir.mktmpdir do |cache_home|
+      cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+      cmd.run 'cache', 'warm', cache_home: cache_home
+
+      manifest_path = File.join(cache_home, 'git-who', 'manifest.json')
+      assert File.exist?(manifest_path)
+
+      repos = JSON.parse(File.read(manifest_path))['repos']
+      repo_paths = repos.values.map { |e| e['repoPath'] }
+      assert_includes repo_paths, File.realpath(TestRepo.path)
+    end
+  end
+
+  def test_cache_disabled
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    assert_raises(GitWhoError) do
+      cmd.run 'cache', 'stats'
+    end
+  end
+
+  def test_cache_unknown_action
+    Dir.mktmpdir do |cache_home|
+      cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+      assert_raises(GitWhoError) do
+        cmd.run 'cache', 'bogus', cache_home: cache_home
+      end
+    end
+  end
+end
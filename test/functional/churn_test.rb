@@ -0,0 +1,58 @@
+require 'minitest/autorun'
+require 'csv'
+require 'json'
+
+require 'lib/cmd'
+require 'lib/repo'
+
+class TestChurn < Minitest::Test
+  def test_churn
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'churn'
+    refute_empty(stdout_s)
+  end
+
+  def test_churn_all_flags
+    flagsets = [
+      ['', '-l'],
+      ['', '--dirs'],
+      ['', '-n 1'],
+      ['', '--merges'],
+      ['', '--csv', '--json', '--ndjson'],
+    ]
+
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    GitWho.generate_args_cartesian_product(flagsets).each do |flags|
+      stdout_s = cmd.run 'churn', *flags
+      refute_empty(stdout_s, "churn #{flags.join(' ')} printed nothing")
+    end
+  end
+
+  def test_churn_csv
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'churn', '--csv'
+    refute_empty(stdout_s)
+
+    data = CSV.parse(stdout_s, headers: true)
+    assert_equal data.headers, [
+      'path',
+      'directory',
+      'commits',
+      'lines added',
+      'lines removed',
+      'authors',
+      'score',
+    ]
+    assert_equal data[0]['directory'], 'false'
+  end
+
+  def test_churn_json
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'churn', '--json', '--dirs', '-n 0'
+    refute_empty(stdout_s)
+
+    data = JSON.parse(stdout_s)
+    assert_equal data['paths'].length, data['total_paths']
+    assert data['paths'][0]['is_dir']
+  end
+end
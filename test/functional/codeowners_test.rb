@@ -0,0 +1,41 @@
+require 'minitest/autorun'
+require 'tmpdir'
+
+require 'lib/cmd'
+require 'lib/repo'
+
+class TestCodeowners < Minitest::Test
+  def test_codeowners
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'codeowners'
+    refute_empty(stdout_s)
+
+    rules = stdout_s.lines.reject { |line| line.start_with?('#') || line.strip.empty? }
+    refute_empty(rules)
+    assert rules[0].start_with?('*')
+  end
+
+  def test_codeowners_one_owner
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'codeowners', '-n', '1', '-l'
+    refute_empty(stdout_s)
+
+    rules = stdout_s.lines.reject { |line| line.start_with?('#') || line.strip.empty? }
+    rules.each do |rule|
+      assert_equal rule.split.length, 2
+    end
+  end
+
+  def test_codeowners_check_drift
+    Dir.mktmpdir do |dir|
+      path = File.join(dir, 'CODEOWNERS')
+      File.write(path, "* nobody@example.com\n")
+
+      cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+      err = assert_raises(GitWhoError) do
+        cmd.run 'codeowners', 'check', "--file=#{path}"
+      end
+      assert_match(/does not match history/, err.message)
+    end
+  end
+end
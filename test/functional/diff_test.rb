@@ -0,0 +1,72 @@
+require 'minitest/autorun'
+require 'csv'
+require 'json'
+
+require 'lib/cmd'
+require 'lib/repo'
+
+class TestDiff < Minitest::Test
+  def test_diff
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'diff', 'HEAD~1', 'HEAD'
+    refute_empty(stdout_s)
+  end
+
+  def test_diff_all_flags
+    flagsets = [
+      ['', '-l'],
+      ['', '-e'],
+      ['', '-d 2'],
+      ['', '-n 1'],
+      ['', '--merges'],
+    ]
+
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    GitWho.generate_args_cartesian_product(flagsets).each do |flags|
+      stdout_s = cmd.run 'diff', *flags, 'HEAD~1', 'HEAD'
+      refute_empty(stdout_s, "diff #{flags.join(' ')} printed nothing")
+    end
+  end
+
+  def test_diff_csv
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'diff', '--csv', 'HEAD~1', 'HEAD'
+    refute_empty(stdout_s)
+
+    data = CSV.parse(stdout_s, headers: true)
+    assert_equal data.headers, [
+      'kind',
+      'name',
+      'commits before',
+      'lines before',
+      'rank before',
+      'top author before',
+      'commits after',
+      'lines after',
+      'rank after',
+      'top author after',
+      'commits change',
+      'lines change',
+    ]
+    assert_equal data[0]['kind'], 'author'
+  end
+
+  def test_diff_json
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'diff', '--json', 'HEAD~1', 'HEAD'
+    refute_empty(stdout_s)
+
+    data = JSON.parse(stdout_s)
+    assert_equal data['before']['mode'], 'commits'
+    assert_equal data['authors'].length, data['total_authors']
+    refute_empty data['directories']
+    assert_equal data['directories'][0]['kind'], 'directory'
+  end
+
+  def test_diff_one_range
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    assert_raises(GitWhoError) do
+      cmd.run 'diff', 'HEAD'
+    end
+  end
+end
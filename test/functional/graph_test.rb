@@ -0,0 +1,55 @@
+require 'minitest/autorun'
+require 'json'
+
+require 'lib/cmd'
+require 'lib/repo'
+
+class TestGraph < Minitest::Test
+  def test_graph
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'graph'
+    assert stdout_s.start_with?('graph "git-who" {')
+  end
+
+  def test_graph_all_flags
+    flagsets = [
+      ['', '--dot', '--graphml', '--json'],
+      ['', '-e'],
+      ['', '--min-shared 2'],
+      ['', '--merges'],
+    ]
+
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    GitWho.generate_args_cartesian_product(flagsets).each do |flags|
+      stdout_s = cmd.run 'graph', *flags
+      refute_empty(stdout_s, "graph #{flags.join(' ')} printed nothing")
+    end
+  end
+
+  def test_graph_graphml
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'graph', '--graphml'
+    assert stdout_s.start_with?('<?xml')
+    assert_includes stdout_s, '<graphml'
+  end
+
+  def test_graph_json
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'graph', '--json'
+    refute_empty(stdout_s)
+
+    data = JSON.parse(stdout_s)
+    assert_equal data['nodes'].length, 2
+    assert_equal data['nodes'][0]['id'], 'a0'
+    data['edges'].each do |edge|
+      assert edge['shared_files'] > 0
+    end
+  end
+
+  def test_graph_two_formats
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    assert_raises(GitWhoError) do
+      cmd.run 'graph', '--graphml', '--json'
+    end
+  end
+end
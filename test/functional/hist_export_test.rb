@@ -0,0 +1,78 @@
+require 'minitest/autorun'
+require 'csv'
+require 'json'
+
+require 'lib/cmd'
+require 'lib/repo'
+
+class TestHistExport < Minitest::Test
+  def test_hist_csv
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'hist', '--csv', '--until 2025-02-01'
+    refute_empty(stdout_s)
+
+    data = CSV.parse(stdout_s, headers: true)
+    assert_equal data.headers, [
+      'bucket', 'start time', 'winner name', 'winner value', 'total value',
+    ]
+    refute_empty data
+  end
+
+  def test_hist_csv_all_authors
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'hist', '--csv', '-a', '-l', '--until 2025-02-01'
+    refute_empty(stdout_s)
+
+    data = CSV.parse(stdout_s, headers: true)
+    assert_equal data.headers, [
+      'bucket',
+      'start time',
+      'rank',
+      'value',
+      'total value',
+      'name',
+      'commits',
+      'lines added',
+      'lines removed',
+      'files',
+      'last commit time',
+      'first commit time',
+    ]
+    refute_empty data
+  end
+
+  def test_hist_json
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'hist', '--json', '--until 2025-02-01'
+    refute_empty(stdout_s)
+
+    data = JSON.parse(stdout_s)
+    assert_equal data['mode'], 'commits'
+    refute_empty data['buckets']
+    data['buckets'].each do |bucket|
+      refute bucket.key?('authors')
+      assert bucket['winner_value'] <= bucket['total_value']
+    end
+  end
+
+  def test_hist_json_all_authors
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'hist', '--json', '-a', '--until 2025-02-01'
+    refute_empty(stdout_s)
+
+    data = JSON.parse(stdout_s)
+    data['buckets'].each do |bucket|
+      assert bucket.key?('authors')
+      if bucket['winner']
+        assert_equal bucket['authors'][0]['name'], bucket['winner']['name']
+      end
+    end
+  end
+
+  def test_hist_all_authors_needs_export_format
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    assert_raises(GitWhoError) do
+      cmd.run 'hist', '-a'
+    end
+  end
+end
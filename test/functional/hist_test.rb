@@ -10,6 +10,9 @@ class TestHist < Minitest::Test
   MODE_FLAGS = ['', '-f', '-l']
   EMAIL_FLAGS = ['', '-e']
   MERGES_FLAGS = ['', '--merges']
+  COAUTHORS_FLAGS = ['--coauthors full', '--coauthors split']
+  HALF_LIFE_MODE_FLAGS = ['', '-f', '-l']
+  WEIGHTS_FLAGS = ['', '--weights commits=2,recency=1']
 
   AUTHOR_FILTER_FLAGS = ['', '--author Bob']
   NAUTHOR_FILTER_FLAGS = ['', '--nauthor Alice']
@@ -36,6 +39,50 @@ class TestHist < Minitest::Test
     end
   end
 
+  all_coauthors_flag_combos = GitWho.generate_args_cartesian_product([
+    MODE_FLAGS,
+    COAUTHORS_FLAGS,
+  ])
+  all_coauthors_flag_combos.each do |flags|
+    test_name = "test_hist_coauthors_(#{flags.join ','})"
+    define_method(test_name) do
+      cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+      stdout_s = cmd.run 'hist', *flags
+      refute_empty(stdout_s)
+    end
+  end
+
+  MODE_FLAGS.each do |mode_flag|
+    test_name = "test_hist_committer_(#{mode_flag})"
+    define_method(test_name) do
+      cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+      stdout_s = cmd.run 'hist', '--committer', mode_flag
+      refute_empty(stdout_s)
+    end
+  end
+
+  all_composite_flag_combos = GitWho.generate_args_cartesian_product([
+    EMAIL_FLAGS,
+    WEIGHTS_FLAGS,
+  ])
+  all_composite_flag_combos.each do |flags|
+    test_name = "test_hist_composite_(#{flags.join ','})"
+    define_method(test_name) do
+      cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+      stdout_s = cmd.run 'hist', '-w', *flags
+      refute_empty(stdout_s)
+    end
+  end
+
+  HALF_LIFE_MODE_FLAGS.each do |mode_flag|
+    test_name = "test_hist_half_life_(#{mode_flag})"
+    define_method(test_name) do
+      cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+      stdout_s = cmd.run 'hist', '--half-life 30', mode_flag
+      refute_empty(stdout_s)
+    end
+  end
+
   all_filter_flag_combos = GitWho.generate_args_cartesian_product([
     AUTHOR_FILTER_FLAGS,
     NAUTHOR_FILTER_FLAGS,
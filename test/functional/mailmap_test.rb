@@ -237,6 +237,54 @@ class TestMailmap < Minitest::Test
     end
   end
 
+  # Author filters should match identities after the mailmap is applied, on
+  # both the sequential and the concurrent paths and whether or not commits
+  # come from the cache
+  def test_local_mailmap_author_filter
+    Dir.mktmpdir do |dir|
+      mailmap_path = Pathname.new(BigRepo.path) / ".mailmap"
+      File.write(mailmap_path, LOCAL_MAILMAP)
+
+      cmd = GitWho.new(GitWho.built_bin_path, BigRepo.path)
+      [1, nil].each do |n_procs|
+        2.times do
+          stdout_s = cmd.run(
+            'table',
+            '--csv',
+            '-e',
+            '--author',
+            'bchesneau@gmail.com',
+            cache_home: dir,
+            n_procs: n_procs,
+          )
+          refute_empty(stdout_s)
+
+          data = CSV.parse(stdout_s, headers: true)
+          assert_equal data.length, 1
+          assert_equal data[0]['name'], 'Benoit Chesneau'
+          assert_equal data[0]['email'], 'bchesneau@gmail.com'
+          assert_equal data[0]['commits'], '1322'
+
+          stdout_s = cmd.run(
+            'table',
+            '--csv',
+            '-e',
+            '--nauthor',
+            'Benoit',
+            cache_home: dir,
+            n_procs: n_procs,
+          )
+          refute_empty(stdout_s)
+
+          data = CSV.parse(stdout_s, headers: true)
+          refute data.any? { |row| row['email'] == 'bchesneau@gmail.com' }
+        end
+      end
+
+      File.delete(mailmap_path)
+    end
+  end
+
   # If git config points to a nonexistent file
   def test_bad_configured_global_mailmap_path
     Dir.mktmpdir do |dir|
@@ -0,0 +1,42 @@
+require 'minitest/autorun'
+
+require 'lib/cmd'
+require 'lib/repo'
+
+class TestReviewers < Minitest::Test
+  def test_reviewers
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'reviewers', 'HEAD~1..HEAD'
+    refute_empty(stdout_s)
+  end
+
+  def test_reviewers_all_flags
+    flagsets = [
+      ['', '-f'],
+      ['', '-e'],
+      ['', '-n 1'],
+      ['', '--half-life 0'],
+      ['', '--merges'],
+    ]
+
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    GitWho.generate_args_cartesian_product(flagsets).each do |flags|
+      stdout_s = cmd.run 'reviewers', *flags, 'HEAD~1..HEAD'
+      refute_empty(stdout_s, "reviewers #{flags.join(' ')} printed nothing")
+    end
+  end
+
+  def test_reviewers_single_rev
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    assert_raises(GitWhoError) do
+      cmd.run 'reviewers', 'HEAD'
+    end
+  end
+
+  def test_reviewers_empty_patch
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    assert_raises(GitWhoError) do
+      cmd.run 'reviewers'
+    end
+  end
+end
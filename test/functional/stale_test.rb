@@ -0,0 +1,35 @@
+require 'minitest/autorun'
+
+require 'lib/cmd'
+require 'lib/repo'
+
+class TestStale < Minitest::Test
+  def test_stale
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'stale'
+    refute_empty(stdout_s)
+  end
+
+  def test_stale_all_flags
+    flagsets = [
+      ['', '--untouched-since=2000-01-01', '--untouched-since='],
+      ['', '--active-since=2000-01-01'],
+      ['', '-s'],
+      ['', '-e'],
+      ['', '-n 1'],
+    ]
+
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    GitWho.generate_args_cartesian_product(flagsets).each do |flags|
+      stdout_s = cmd.run 'stale', *flags
+      refute_empty(stdout_s, "stale #{flags.join(' ')} printed nothing")
+    end
+  end
+
+  def test_stale_no_checks
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    assert_raises(GitWhoError) do
+      cmd.run 'stale', '--untouched-since=', '--active-since='
+    end
+  end
+end
@@ -52,4 +52,58 @@ class TestTableCSV < Minitest::Test
     assert_equal data[0]['name'], 'Sinclair Target'
     assert_equal data[1]['name'], 'Bob'
   end
+
+  def test_table_csv_half_life
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'table', '--csv', '--half-life 30'
+    refute_empty(stdout_s)
+
+    data = CSV.parse(stdout_s, headers: true)
+    assert_equal data.headers, [
+      'name', 'commits', 'score', 'last commit time', 'first commit time',
+    ]
+    assert_equal data.length, 2
+    refute_nil data[0]['score']
+  end
+
+  def test_table_csv_composite
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'table', '--csv', '-w'
+    refute_empty(stdout_s)
+
+    data = CSV.parse(stdout_s, headers: true)
+    assert_equal data.headers, [
+      'name',
+      'commits',
+      'lines added',
+      'lines removed',
+      'files',
+      'score',
+      'last commit time',
+      'first commit time',
+    ]
+    assert_equal data.length, 2
+  end
+
+  def test_table_csv_shares
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'table', '--csv', '-p', '-l'
+    refute_empty(stdout_s)
+
+    data = CSV.parse(stdout_s, headers: true)
+    assert_equal data.headers, [
+      'name',
+      'commits',
+      'lines added',
+      'lines removed',
+      'files',
+      'last commit time',
+      'first commit time',
+      'commit share',
+      'line share',
+      'cumulative share',
+    ]
+    assert_equal data.length, 2
+    assert_in_delta data[1]['cumulative share'].to_f, 1.0, 0.0001
+  end
 end
@@ -0,0 +1,60 @@
+require 'minitest/autorun'
+require 'json'
+
+require 'lib/cmd'
+require 'lib/repo'
+
+class TestTableJSON < Minitest::Test
+  AUTHOR_KEYS = [
+    'name',
+    'email',
+    'commits',
+    'lines_added',
+    'lines_removed',
+    'files',
+    'first_commit_time',
+    'last_commit_time',
+  ]
+
+  def test_table_json
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'table', '--json'
+    refute_empty(stdout_s)
+
+    data = JSON.parse(stdout_s)
+    assert_equal data['mode'], 'commits'
+    assert_equal data['revs'], ['HEAD']
+    assert_equal data['total_authors'], 2
+    assert_equal data['num_filtered_out'], 0
+    assert_equal data['authors'].length, 2
+    assert_equal data['authors'][0].keys, AUTHOR_KEYS
+    assert_equal data['authors'][0]['name'], 'Sinclair Target'
+    assert_nil data['authors'][0]['lines_added']
+  end
+
+  def test_table_json_lines_limit
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'table', '--json', '-l', '-n 1'
+    refute_empty(stdout_s)
+
+    data = JSON.parse(stdout_s)
+    assert_equal data['mode'], 'lines'
+    assert_equal data['total_authors'], 2
+    assert_equal data['num_filtered_out'], 1
+    assert_equal data['authors'].length, 1
+    assert_equal data['authors'][0].keys, AUTHOR_KEYS
+    refute_nil data['authors'][0]['lines_added']
+  end
+
+  def test_table_ndjson
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'table', '--ndjson'
+    refute_empty(stdout_s)
+
+    lines = stdout_s.lines.map { |line| JSON.parse(line) }
+    assert_equal lines.length, 3
+    assert_equal lines[0]['total_authors'], 2
+    assert_equal lines[1]['name'], 'Sinclair Target'
+    assert_equal lines[2]['name'], 'Bob'
+  end
+end
@@ -1,3 +1,5 @@
+require 'tmpdir'
+
 require 'minitest/autorun'
 
 require 'lib/cmd'
@@ -10,6 +12,10 @@ class TestTable < Minitest::Test
   MODE_FLAGS = ['', '-c', '-f', '-l', '-m']
   EMAIL_FLAGS = ['', '-e']
   MERGES_FLAGS = ['', '--merges']
+  COAUTHORS_FLAGS = ['--coauthors full', '--coauthors split']
+  HALF_LIFE_MODE_FLAGS = ['', '-f', '-l']
+  WEIGHTS_FLAGS = ['', '--weights commits=2,recency=1']
+  SHARES_MODE_FLAGS = ['', '-c', '-f', '-l', '-m', '-b', '-w']
   LIMIT_FLAGS = ['', '-n 5']
 
   AUTHOR_FILTER_FLAGS = ['', '--author Bob']
@@ -38,6 +44,119 @@ class TestTable < Minitest::Test
     end
   end
 
+  all_coauthors_flag_combos = GitWho.generate_args_cartesian_product([
+    MODE_FLAGS,
+    COAUTHORS_FLAGS,
+  ])
+  all_coauthors_flag_combos.each do |flags|
+    test_name = "test_table_coauthors_(#{flags.join ','})"
+    define_method(test_name) do
+      cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+      stdout_s = cmd.run 'table', *flags
+      refute_empty(stdout_s)
+    end
+  end
+
+  MODE_FLAGS.each do |mode_flag|
+    test_name = "test_table_committer_(#{mode_flag})"
+    define_method(test_name) do
+      cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+      stdout_s = cmd.run 'table', '--committer', mode_flag
+      refute_empty(stdout_s)
+    end
+  end
+
+  all_composite_flag_combos = GitWho.generate_args_cartesian_product([
+    EMAIL_FLAGS,
+    WEIGHTS_FLAGS,
+  ])
+  all_composite_flag_combos.each do |flags|
+    test_name = "test_table_composite_(#{flags.join ','})"
+    define_method(test_name) do
+      cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+      stdout_s = cmd.run 'table', '-w', *flags
+      refute_empty(stdout_s)
+    end
+  end
+
+  HALF_LIFE_MODE_FLAGS.each do |mode_flag|
+    test_name = "test_table_half_life_(#{mode_flag})"
+    define_method(test_name) do
+      cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+      stdout_s = cmd.run 'table', '--half-life 30', mode_flag
+      refute_empty(stdout_s)
+    end
+  end
+
+  all_blame_flag_combos = GitWho.generate_args_cartesian_product([
+    EMAIL_FLAGS,
+    LIMIT_FLAGS,
+  ])
+  all_blame_flag_combos.each do |flags|
+    test_name = "test_table_blame_(#{flags.join ','})"
+    define_method(test_name) do
+      cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+      stdout_s = cmd.run 'table', '-b', *flags
+      refute_empty(stdout_s)
+    end
+  end
+
+  def test_table_blame_no_concurrent
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'table', '-b', n_procs: 1
+    refute_empty(stdout_s)
+  end
+
+  def test_table_blame_cached
+    Dir.mktmpdir do |cache_home|
+      cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+      first = cmd.run 'table', '-b', cache_home: cache_home
+      second = cmd.run 'table', '-b', cache_home: cache_home
+      assert_equal first, second
+    end
+  end
+
+  def test_table_blame_with_filter
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    assert_raises(GitWhoError) do
+      cmd.run 'table', '-b', '--since 2024-12-25'
+    end
+  end
+
+  def test_table_half_life_with_last_modified
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    assert_raises(GitWhoError) do
+      cmd.run 'table', '-m', '--half-life 30'
+    end
+  end
+
+  all_shares_flag_combos = GitWho.generate_args_cartesian_product([
+    SHARES_MODE_FLAGS,
+    LIMIT_FLAGS,
+  ])
+  all_shares_flag_combos.each do |flags|
+    test_name = "test_table_shares_(#{flags.join ','})"
+    define_method(test_name) do
+      cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+      stdout_s = cmd.run 'table', '-p', *flags
+      refute_empty(stdout_s)
+    end
+  end
+
+  def test_table_shares_with_json
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    assert_raises(GitWhoError) do
+      cmd.run 'table', '-p', '--json'
+    end
+  end
+
+  def test_table_weights_without_composite
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    assert_raises(GitWhoError) do
+      cmd.run 'table', '--weights commits=1'
+    end
+  end
+
   all_filter_flag_combos = GitWho.generate_args_cartesian_product([
     AUTHOR_FILTER_FLAGS,
     NAUTHOR_FILTER_FLAGS,
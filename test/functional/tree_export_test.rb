@@ -0,0 +1,53 @@
+require 'minitest/autorun'
+require 'csv'
+require 'json'
+
+require 'lib/cmd'
+require 'lib/repo'
+
+class TestTreeExport < Minitest::Test
+  def test_tree_csv
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'tree', '--csv'
+    refute_empty(stdout_s)
+
+    data = CSV.parse(stdout_s, headers: true)
+    assert_equal data.headers, [
+      'path',
+      'directory',
+      'in working tree',
+      'rank',
+      'name',
+      'commits',
+      'lines added',
+      'lines removed',
+      'files',
+      'last commit time',
+      'first commit time',
+    ]
+    assert_equal data[0]['path'], '.'
+    assert_equal data[0]['rank'], '1'
+  end
+
+  def test_tree_json_depth
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'tree', '--json', '-d 1'
+    refute_empty(stdout_s)
+
+    data = JSON.parse(stdout_s)
+    refute_empty data['nodes']
+    data['nodes'].each do |node|
+      assert node['path'].count('/') < 1
+      assert_equal node['winner'], node['authors'][0]
+    end
+  end
+
+  def test_tree_json_hidden
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    visible = JSON.parse(cmd.run('tree', '--json'))['nodes']
+    all = JSON.parse(cmd.run('tree', '--json', '-a'))['nodes']
+
+    assert visible.all? { |node| node['in_work_tree'] }
+    assert all.length >= visible.length
+  end
+end
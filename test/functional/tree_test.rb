@@ -1,3 +1,5 @@
+require 'tmpdir'
+
 require 'minitest/autorun'
 
 require 'lib/cmd'
@@ -11,6 +13,10 @@ class TestTree < Minitest::Test
   MODE_FLAGS = ['', '-c', '-f', '-l', '-m']
   EMAIL_FLAGS = ['', '-e']
   MERGES_FLAGS = ['', '--merges']
+  COAUTHORS_FLAGS = ['--coauthors full', '--coauthors split']
+  HALF_LIFE_MODE_FLAGS = ['', '-f', '-l']
+  WEIGHTS_FLAGS = ['', '--weights commits=2,recency=1']
+  TOP_AUTHORS_MODE_FLAGS = ['', '-f', '-l', '-b']
 
   AUTHOR_FILTER_FLAGS = ['', '--author Bob']
   NAUTHOR_FILTER_FLAGS = ['', '--nauthor Alice']
@@ -80,6 +86,127 @@ class TestTree < Minitest::Test
     end
   end
 
+  all_coauthors_flag_combos = GitWho.generate_args_cartesian_product([
+    MODE_FLAGS,
+    COAUTHORS_FLAGS,
+  ])
+  all_coauthors_flag_combos.each do |flags|
+    test_name = "test_tree_coauthors_(#{flags.join ','})"
+    define_method(test_name) do
+      cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+      stdout_s = cmd.run 'tree', *flags
+      refute_empty(stdout_s)
+    end
+  end
+
+  MODE_FLAGS.each do |mode_flag|
+    test_name = "test_tree_committer_(#{mode_flag})"
+    define_method(test_name) do
+      cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+      stdout_s = cmd.run 'tree', '--committer', mode_flag
+      refute_empty(stdout_s)
+    end
+  end
+
+  MODE_FLAGS.each do |mode_flag|
+    test_name = "test_tree_follow_renames_(#{mode_flag})"
+    define_method(test_name) do
+      cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+      stdout_s = cmd.run 'tree', '--follow-renames', mode_flag
+      refute_empty(stdout_s)
+    end
+  end
+
+  def test_tree_follow_renames_subdir
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'tree', '--follow-renames', 'rename-new-dir'
+    refute_empty(stdout_s)
+  end
+
+  def test_tree_follow_renames_subdir_no_concurrent
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'tree', '--follow-renames', 'rename-new-dir', n_procs: 1
+    refute_empty(stdout_s)
+  end
+
+  all_top_authors_flag_combos = GitWho.generate_args_cartesian_product([
+    TOP_AUTHORS_MODE_FLAGS,
+    EMAIL_FLAGS,
+    SHOW_ALL_FLAGS,
+  ])
+  all_top_authors_flag_combos.each do |flags|
+    test_name = "test_tree_top_authors_(#{flags.join ','})"
+    define_method(test_name) do
+      cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+      stdout_s = cmd.run 'tree', '-k 3', *flags
+      refute_empty(stdout_s)
+    end
+  end
+
+  def test_tree_top_authors_last_modified
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    assert_raises(GitWhoError) do
+      cmd.run 'tree', '-k 2', '-m'
+    end
+  end
+
+  all_composite_flag_combos = GitWho.generate_args_cartesian_product([
+    EMAIL_FLAGS,
+    WEIGHTS_FLAGS,
+  ])
+  all_composite_flag_combos.each do |flags|
+    test_name = "test_tree_composite_(#{flags.join ','})"
+    define_method(test_name) do
+      cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+      stdout_s = cmd.run 'tree', '-w', *flags
+      refute_empty(stdout_s)
+    end
+  end
+
+  HALF_LIFE_MODE_FLAGS.each do |mode_flag|
+    test_name = "test_tree_half_life_(#{mode_flag})"
+    define_method(test_name) do
+      cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+      stdout_s = cmd.run 'tree', '--half-life 30', mode_flag
+      refute_empty(stdout_s)
+    end
+  end
+
+  all_blame_flag_combos = GitWho.generate_args_cartesian_product([
+    EMAIL_FLAGS,
+    SHOW_ALL_FLAGS,
+  ])
+  all_blame_flag_combos.each do |flags|
+    test_name = "test_tree_blame_(#{flags.join ','})"
+    define_method(test_name) do
+      cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+      stdout_s = cmd.run 'tree', '-b', *flags
+      refute_empty(stdout_s)
+    end
+  end
+
+  def test_tree_blame_no_concurrent
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    stdout_s = cmd.run 'tree', '-b', n_procs: 1
+    refute_empty(stdout_s)
+  end
+
+  def test_tree_blame_cached
+    Dir.mktmpdir do |cache_home|
+      cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+      first = cmd.run 'tree', '-b', cache_home: cache_home
+      second = cmd.run 'tree', '-b', cache_home: cache_home
+      assert_equal first, second
+    end
+  end
+
+  def test_tree_blame_with_filter
+    cmd = GitWho.new(GitWho.built_bin_path, TestRepo.path)
+    assert_raises(GitWhoError) do
+      cmd.run 'tree', '-b', '--since 2024-12-25'
+    end
+  end
+
   all_filter_flag_combos = GitWho.generate_args_cartesian_product([
     AUTHOR_FILTER_FLAGS,
     NAUTHOR_FILTER_FLAGS,